@@ -0,0 +1,140 @@
+package khqr
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// BakongAccountID is a parsed Bakong account identifier of the form
+// "user@bank", where the suffix after "@" identifies the participant bank.
+type BakongAccountID struct {
+	user string
+	bank string
+}
+
+// ParseBakongAccountID parses and validates a Bakong account ID. It applies
+// the same format rules as generation and does not consult the bank
+// registry; use ParseBakongAccountIDStrict to reject unknown banks.
+func ParseBakongAccountID(s string) (BakongAccountID, error) {
+	if err := validateAccountID(s); err != nil {
+		return BakongAccountID{}, err
+	}
+	user, bank, _ := strings.Cut(s, "@")
+	return BakongAccountID{user: user, bank: bank}, nil
+}
+
+// ParseBakongAccountIDStrict is like ParseBakongAccountID but also returns
+// ErrAccountIDUnknownBank when the bank suffix is not registered.
+func ParseBakongAccountIDStrict(s string) (BakongAccountID, error) {
+	id, err := ParseBakongAccountID(s)
+	if err != nil {
+		return BakongAccountID{}, err
+	}
+	if _, ok := LookupBank(id.bank); !ok {
+		return BakongAccountID{}, ErrAccountIDUnknownBank
+	}
+	return id, nil
+}
+
+// User returns the part of the account ID before "@".
+func (id BakongAccountID) User() string {
+	return id.user
+}
+
+// BankCode returns the participant bank suffix after "@" (e.g. "aclb").
+func (id BakongAccountID) BankCode() string {
+	return id.bank
+}
+
+// Bank returns the registry entry for the account's bank suffix.
+func (id BakongAccountID) Bank() (Bank, bool) {
+	return LookupBank(id.bank)
+}
+
+// String returns the account ID in "user@bank" form.
+func (id BakongAccountID) String() string {
+	if id.user == "" && id.bank == "" {
+		return ""
+	}
+	return id.user + "@" + id.bank
+}
+
+// Bank describes a Bakong participant identified by its account ID suffix.
+type Bank struct {
+	Code       string     // account ID suffix, e.g. "aclb"
+	Name       string     // legal or trading name
+	SWIFT      string     // SWIFT/BIC, empty when unknown
+	Currencies []Currency // currencies the participant accepts
+}
+
+// Supports reports whether the bank accepts payments in the given currency.
+func (b Bank) Supports(c Currency) bool {
+	return slices.Contains(b.Currencies, c)
+}
+
+// bankRegistry holds the known Bakong participants keyed by lowercase suffix.
+var bankRegistry = struct {
+	sync.RWMutex
+	banks map[string]Bank
+}{banks: map[string]Bank{}}
+
+// defaultBanks is the built-in snapshot of Bakong participant suffixes.
+// The Bakong participant list changes over time; callers can add or
+// override entries with RegisterBank.
+var defaultBanks = []Bank{
+	{Code: "abaa", Name: "Advanced Bank of Asia Ltd.", SWIFT: "ABAAKHPP", Currencies: []Currency{KHR, USD}},
+	{Code: "aclb", Name: "ACLEDA Bank Plc.", SWIFT: "ACLBKHPP", Currencies: []Currency{KHR, USD}},
+	{Code: "bkrt", Name: "Bakong (National Bank of Cambodia)", Currencies: []Currency{KHR, USD}},
+	{Code: "cadi", Name: "Canadia Bank Plc.", SWIFT: "CADIKHPP", Currencies: []Currency{KHR, USD}},
+	{Code: "ftcc", Name: "Foreign Trade Bank of Cambodia", SWIFT: "FTCCKHPP", Currencies: []Currency{KHR, USD}},
+	{Code: "ppcb", Name: "Phnom Penh Commercial Bank", SWIFT: "PPCBKHPP", Currencies: []Currency{KHR, USD}},
+	{Code: "wing", Name: "Wing Bank (Cambodia) Plc", SWIFT: "WINGKHPP", Currencies: []Currency{KHR, USD}},
+}
+
+func init() {
+	for _, b := range defaultBanks {
+		RegisterBank(b)
+	}
+}
+
+// RegisterBank adds a participant to the bank registry, replacing any
+// existing entry with the same code. Codes are matched case-insensitively.
+func RegisterBank(b Bank) {
+	b.Code = strings.ToLower(strings.TrimSpace(b.Code))
+	b.Currencies = slices.Clone(b.Currencies)
+	bankRegistry.Lock()
+	defer bankRegistry.Unlock()
+	bankRegistry.banks[b.Code] = b
+}
+
+// UnregisterBank removes a participant from the bank registry.
+func UnregisterBank(code string) {
+	bankRegistry.Lock()
+	defer bankRegistry.Unlock()
+	delete(bankRegistry.banks, strings.ToLower(code))
+}
+
+// LookupBank returns the registered participant for an account ID suffix.
+func LookupBank(code string) (Bank, bool) {
+	bankRegistry.RLock()
+	defer bankRegistry.RUnlock()
+	b, ok := bankRegistry.banks[strings.ToLower(code)]
+	if ok {
+		b.Currencies = slices.Clone(b.Currencies)
+	}
+	return b, ok
+}
+
+// Banks returns all registered participants sorted by code.
+func Banks() []Bank {
+	bankRegistry.RLock()
+	defer bankRegistry.RUnlock()
+	banks := make([]Bank, 0, len(bankRegistry.banks))
+	for _, b := range bankRegistry.banks {
+		b.Currencies = slices.Clone(b.Currencies)
+		banks = append(banks, b)
+	}
+	slices.SortFunc(banks, func(a, b Bank) int { return strings.Compare(a.Code, b.Code) })
+	return banks
+}
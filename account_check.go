@@ -0,0 +1,155 @@
+package khqr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccountChecker confirms that a Bakong account exists. CheckAccount
+// returns nil if it does, ErrAccountNotFound if it does not, and any other
+// error if the check could not be made.
+type AccountChecker interface {
+	CheckAccount(ctx context.Context, accountID string) error
+}
+
+// AccountCheckerFunc adapts a function to the AccountChecker interface.
+type AccountCheckerFunc func(ctx context.Context, accountID string) error
+
+// CheckAccount implements AccountChecker.
+func (f AccountCheckerFunc) CheckAccount(ctx context.Context, accountID string) error {
+	return f(ctx, accountID)
+}
+
+// WithAccountChecker makes the Generator confirm that BakongAccountID
+// exists before issuing a code. The offline format check still runs first,
+// so malformed IDs fail with ErrAccountIDInvalid without a network call.
+func WithAccountChecker(c AccountChecker) GeneratorOption {
+	return func(g *Generator) { g.accounts = c }
+}
+
+// BakongAPIURL is the production Bakong Open API.
+const BakongAPIURL = "https://api-bakong.nbc.gov.kh"
+
+// bakongAccountNotFound is the errorCode Bakong returns for unknown accounts.
+const bakongAccountNotFound = 11
+
+// defaultAccountCheckTimeout bounds a single account lookup.
+const defaultAccountCheckTimeout = 10 * time.Second
+
+// BakongAccountChecker is an AccountChecker backed by the Bakong Open API
+// check_bakong_account endpoint. Results, including "not found", are
+// cached for TTL; transport and API errors are not cached.
+type BakongAccountChecker struct {
+	BaseURL string        // defaults to BakongAPIURL
+	Token   string        // Bakong Open API bearer token
+	Client  *http.Client  // defaults to http.DefaultClient
+	Timeout time.Duration // per lookup; defaults to 10s
+	TTL     time.Duration // 0 disables caching
+
+	mu    sync.Mutex
+	cache map[string]accountCheckResult
+}
+
+type accountCheckResult struct {
+	err     error // nil or ErrAccountNotFound
+	expires time.Time
+}
+
+// CheckAccount implements AccountChecker.
+func (b *BakongAccountChecker) CheckAccount(ctx context.Context, accountID string) error {
+	if r, ok := b.cached(accountID); ok {
+		return r.err
+	}
+	timeout := b.Timeout
+	if timeout <= 0 {
+		timeout = defaultAccountCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := b.lookup(ctx, accountID)
+	if err == nil || errors.Is(err, ErrAccountNotFound) {
+		b.store(accountID, err)
+	}
+	return err
+}
+
+func (b *BakongAccountChecker) cached(accountID string) (accountCheckResult, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	r, ok := b.cache[accountID]
+	if !ok || time.Now().After(r.expires) {
+		return accountCheckResult{}, false
+	}
+	return r, true
+}
+
+func (b *BakongAccountChecker) store(accountID string, err error) {
+	if b.TTL <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cache == nil {
+		b.cache = map[string]accountCheckResult{}
+	}
+	b.cache[accountID] = accountCheckResult{err: err, expires: time.Now().Add(b.TTL)}
+}
+
+func (b *BakongAccountChecker) lookup(ctx context.Context, accountID string) error {
+	body, err := json.Marshal(map[string]string{"accountId": accountID})
+	if err != nil {
+		return err
+	}
+	base := b.BaseURL
+	if base == "" {
+		base = BakongAPIURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(base, "/")+"/v1/check_bakong_account", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+b.Token)
+
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		ResponseCode    int    `json:"responseCode"`
+		ResponseMessage string `json:"responseMessage"`
+		ErrorCode       *int   `json:"errorCode"`
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16)) //nolint:mnd // response envelope is small
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return fmt.Errorf("bakong: unexpected response (status %s): %w", resp.Status, err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusOK && out.ResponseCode == 0:
+		return nil
+	case out.ErrorCode != nil && *out.ErrorCode == bakongAccountNotFound:
+		return ErrAccountNotFound
+	case out.ErrorCode != nil:
+		return fmt.Errorf("bakong: %s (status %s, errorCode %d)", out.ResponseMessage, resp.Status, *out.ErrorCode)
+	default:
+		return fmt.Errorf("bakong: %s (status %s)", out.ResponseMessage, resp.Status)
+	}
+}
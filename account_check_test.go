@@ -0,0 +1,125 @@
+package khqr_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	khqr "github.com/ishinvin/go-khqr"
+	"github.com/ishinvin/go-khqr/khqrtest"
+)
+
+func TestBakongAccountChecker(t *testing.T) {
+	t.Parallel()
+
+	srv := khqrtest.NewServer(t, khqrtest.WithAccounts("shop@aclb"))
+	c := &khqr.BakongAccountChecker{BaseURL: srv.URL, Token: srv.Token, Client: srv.Client()}
+
+	tests := []struct {
+		name    string
+		id      string
+		wantErr error
+	}{
+		{"exists", "shop@aclb", nil},
+		{"not_found", "ghost@aclb", khqr.ErrAccountNotFound},
+	}
+	for _, tt := range tests {
+		if err := c.CheckAccount(context.Background(), tt.id); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: CheckAccount(%q) error = %v, want %v", tt.name, tt.id, err, tt.wantErr)
+		}
+	}
+	if errors.Is(khqr.ErrAccountNotFound, khqr.ErrAccountIDInvalid) {
+		t.Error("ErrAccountNotFound must be distinct from ErrAccountIDInvalid")
+	}
+}
+
+func TestBakongAccountCheckerCache(t *testing.T) {
+	t.Parallel()
+
+	srv := khqrtest.NewServer(t, khqrtest.WithAccounts("shop@aclb"))
+	c := &khqr.BakongAccountChecker{BaseURL: srv.URL, Token: srv.Token, TTL: time.Minute}
+	ctx := context.Background()
+
+	for range 3 {
+		_ = c.CheckAccount(ctx, "shop@aclb")
+		_ = c.CheckAccount(ctx, "ghost@aclb")
+	}
+	if got := srv.Requests(khqrtest.PathCheckAccount); got != 2 {
+		t.Errorf("requests with cache = %d, want 2", got)
+	}
+
+	// Failures are not cached.
+	srv.Fail(khqrtest.PathCheckAccount, khqrtest.Fault{Status: http.StatusBadGateway, Times: 1})
+	if err := c.CheckAccount(ctx, "new@aclb"); err == nil || errors.Is(err, khqr.ErrAccountNotFound) {
+		t.Errorf("CheckAccount() during outage error = %v, want transport error", err)
+	}
+	srv.AddAccount("new@aclb")
+	if err := c.CheckAccount(ctx, "new@aclb"); err != nil {
+		t.Errorf("CheckAccount() after outage error = %v, want nil", err)
+	}
+}
+
+func TestBakongAccountCheckerTimeout(t *testing.T) {
+	t.Parallel()
+
+	srv := khqrtest.NewServer(t, khqrtest.WithLatency(300*time.Millisecond))
+	c := &khqr.BakongAccountChecker{BaseURL: srv.URL, Token: srv.Token, Timeout: 20 * time.Millisecond}
+	if err := c.CheckAccount(context.Background(), "shop@aclb"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CheckAccount() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestBakongAccountCheckerUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	srv := khqrtest.NewServer(t)
+	c := &khqr.BakongAccountChecker{BaseURL: srv.URL, Token: "expired"}
+	err := c.CheckAccount(context.Background(), "shop@aclb")
+	if err == nil || errors.Is(err, khqr.ErrAccountNotFound) {
+		t.Errorf("CheckAccount() with bad token error = %v, want API error", err)
+	}
+}
+
+func TestGeneratorAccountChecker(t *testing.T) {
+	t.Parallel()
+
+	srv := khqrtest.NewServer(t, khqrtest.WithAccounts("shop@devb"))
+	store := khqr.NewMemoryStore()
+	g := khqr.NewGenerator(
+		khqr.WithStore(store),
+		khqr.WithAccountChecker(&khqr.BakongAccountChecker{BaseURL: srv.URL, Token: srv.Token}),
+	)
+	info := khqr.MerchantInfo{
+		BakongAccountID: "shop@devb",
+		MerchantName:    "Shop",
+		MerchantCity:    "Phnom Penh",
+		MerchantID:      "123456",
+		AcquiringBank:   "Dev Bank",
+	}
+	ctx := context.Background()
+
+	if _, err := g.GenerateMerchant(ctx, info); err != nil {
+		t.Fatalf("GenerateMerchant() known account unexpected error: %v", err)
+	}
+
+	info.BakongAccountID = "ghost@devb"
+	if _, err := g.GenerateMerchant(ctx, info); !errors.Is(err, khqr.ErrAccountNotFound) {
+		t.Errorf("GenerateMerchant() unknown account error = %v, want %v", err, khqr.ErrAccountNotFound)
+	}
+
+	// Malformed IDs are rejected offline.
+	info.BakongAccountID = "not-an-id"
+	before := srv.Requests(khqrtest.PathCheckAccount)
+	if _, err := g.GenerateMerchant(ctx, info); !errors.Is(err, khqr.ErrAccountIDInvalid) {
+		t.Errorf("GenerateMerchant() malformed ID error = %v, want %v", err, khqr.ErrAccountIDInvalid)
+	}
+	if srv.Requests(khqrtest.PathCheckAccount) != before {
+		t.Error("malformed ID triggered an online check")
+	}
+
+	if got, _ := store.Find(ctx, khqr.Query{}); len(got) != 1 {
+		t.Errorf("store has %d records, want 1", len(got))
+	}
+}
@@ -0,0 +1,140 @@
+package khqr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseBakongAccountID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		id       string
+		wantUser string
+		wantBank string
+		wantErr  error
+	}{
+		{"known_bank", "ishin_vin@aclb", "ishin_vin", "aclb", nil},
+		{"unknown_bank", "user@abaa1", "user", "abaa1", nil},
+		{"empty", "", "", "", ErrAccountIDRequired},
+		{"no_at_sign", "userabaa", "", "", ErrAccountIDInvalid},
+		{"two_at_signs", "user@ab@aa", "", "", ErrAccountIDInvalid},
+		{"too_long", strings.Repeat("a", 30) + "@aclb", "", "", ErrAccountIDTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseBakongAccountID(tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseBakongAccountID(%q) error = %v, want %v", tt.id, err, tt.wantErr)
+			}
+			if got.User() != tt.wantUser || got.BankCode() != tt.wantBank {
+				t.Errorf("ParseBakongAccountID(%q) = (%q, %q), want (%q, %q)",
+					tt.id, got.User(), got.BankCode(), tt.wantUser, tt.wantBank)
+			}
+			if err == nil && got.String() != tt.id {
+				t.Errorf("String() = %q, want %q", got.String(), tt.id)
+			}
+		})
+	}
+}
+
+func TestParseBakongAccountIDStrict(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		id      string
+		wantErr error
+	}{
+		{"aclb", "khqr@aclb", nil},
+		{"abaa", "abaakhppxxx@abaa", nil},
+		{"bakong_wallet", "ishin_vin@bkrt", nil},
+		{"uppercase_suffix", "user@ABAA", nil},
+		{"typo_suffix", "user@abaaa", ErrAccountIDUnknownBank},
+		{"test_suffix", "jonhsmith@devb", ErrAccountIDUnknownBank},
+		{"invalid_format", "user", ErrAccountIDInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ParseBakongAccountIDStrict(tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseBakongAccountIDStrict(%q) error = %v, want %v", tt.id, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBakongAccountIDBank(t *testing.T) {
+	t.Parallel()
+
+	id, err := ParseBakongAccountID("khqr@aclb")
+	if err != nil {
+		t.Fatalf("ParseBakongAccountID() unexpected error: %v", err)
+	}
+	bank, ok := id.Bank()
+	if !ok {
+		t.Fatal("Bank() ok = false, want true")
+	}
+	if bank.SWIFT != "ACLBKHPP" {
+		t.Errorf("Bank().SWIFT = %q, want %q", bank.SWIFT, "ACLBKHPP")
+	}
+	if !bank.Supports(USD) || !bank.Supports(KHR) {
+		t.Errorf("Bank().Currencies = %v, want KHR and USD", bank.Currencies)
+	}
+}
+
+func TestRegisterBank(t *testing.T) {
+	t.Parallel()
+
+	RegisterBank(Bank{Code: "TSTB", Name: "Test Bank", Currencies: []Currency{KHR}})
+	t.Cleanup(func() { UnregisterBank("tstb") })
+
+	if _, err := ParseBakongAccountIDStrict("user@tstb"); err != nil {
+		t.Fatalf("ParseBakongAccountIDStrict() after RegisterBank error = %v", err)
+	}
+	bank, ok := LookupBank("tstb")
+	if !ok || bank.Name != "Test Bank" {
+		t.Fatalf("LookupBank(%q) = %+v, %v", "tstb", bank, ok)
+	}
+	if bank.Supports(USD) {
+		t.Error("Supports(USD) = true, want false")
+	}
+
+	RegisterBank(Bank{Code: "tstb", Name: "Test Bank Renamed"})
+	if bank, _ := LookupBank("tstb"); bank.Name != "Test Bank Renamed" {
+		t.Errorf("LookupBank() after override Name = %q, want %q", bank.Name, "Test Bank Renamed")
+	}
+
+	UnregisterBank("TSTB")
+	if _, ok := LookupBank("tstb"); ok {
+		t.Error("LookupBank() after UnregisterBank ok = true, want false")
+	}
+}
+
+func TestLookupBankReturnsCopy(t *testing.T) {
+	t.Parallel()
+
+	bank, _ := LookupBank("wing")
+	bank.Currencies[0] = Currency(999)
+	if again, _ := LookupBank("wing"); again.Currencies[0] == Currency(999) {
+		t.Error("LookupBank() returned a slice aliasing the registry")
+	}
+}
+
+func TestBanksSorted(t *testing.T) {
+	t.Parallel()
+
+	banks := Banks()
+	if len(banks) < len(defaultBanks) {
+		t.Fatalf("Banks() returned %d entries, want at least %d", len(banks), len(defaultBanks))
+	}
+	for i := 1; i < len(banks); i++ {
+		if banks[i-1].Code >= banks[i].Code {
+			t.Errorf("Banks() not sorted: %q before %q", banks[i-1].Code, banks[i].Code)
+		}
+	}
+}
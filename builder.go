@@ -0,0 +1,285 @@
+package khqr
+
+import "time"
+
+// expiry is the expiration setting shared by the builders. The deadline is
+// resolved when the code is built, so a builder can be reused.
+type expiry struct {
+	in  time.Duration
+	at  time.Time
+	set bool
+}
+
+func (e *expiry) timestamp(amount float64) (int64, error) {
+	if !e.set {
+		return 0, nil
+	}
+	if amount == 0 {
+		return 0, ErrExpirationOnStaticKHQR
+	}
+	if !e.at.IsZero() {
+		return e.at.UnixMilli(), nil
+	}
+	return time.Now().Add(e.in).UnixMilli(), nil
+}
+
+// MerchantBuilder builds a MerchantInfo step by step. Required fields are
+// checked by Build with the same validators as GenerateMerchant; an amount
+// without an expiration, or an expiration without an amount, is rejected.
+type MerchantBuilder struct {
+	info   MerchantInfo
+	expiry expiry
+}
+
+// NewMerchant starts a merchant code for the given Bakong account.
+func NewMerchant(bakongAccountID string) *MerchantBuilder {
+	return &MerchantBuilder{info: MerchantInfo{BakongAccountID: bakongAccountID}}
+}
+
+// Name sets the merchant name (required).
+func (b *MerchantBuilder) Name(name string) *MerchantBuilder {
+	b.info.MerchantName = name
+	return b
+}
+
+// City sets the merchant city (required).
+func (b *MerchantBuilder) City(city string) *MerchantBuilder {
+	b.info.MerchantCity = city
+	return b
+}
+
+// MerchantID sets the merchant ID assigned by the acquiring bank (required).
+func (b *MerchantBuilder) MerchantID(id string) *MerchantBuilder {
+	b.info.MerchantID = id
+	return b
+}
+
+// AcquiringBank sets the acquiring bank name (required).
+func (b *MerchantBuilder) AcquiringBank(bank string) *MerchantBuilder {
+	b.info.AcquiringBank = bank
+	return b
+}
+
+// Currency sets the transaction currency. Defaults to KHR.
+func (b *MerchantBuilder) Currency(c Currency) *MerchantBuilder {
+	b.info.Currency = c
+	return b
+}
+
+// Category sets the merchant category code. Defaults to "5999".
+func (b *MerchantBuilder) Category(mcc string) *MerchantBuilder {
+	b.info.MerchantCategoryCode = mcc
+	return b
+}
+
+// Amount makes the code dynamic. An expiration must also be set.
+func (b *MerchantBuilder) Amount(amount float64) *MerchantBuilder {
+	b.info.Amount = amount
+	return b
+}
+
+// ExpiresIn sets the expiration to d after Build is called.
+func (b *MerchantBuilder) ExpiresIn(d time.Duration) *MerchantBuilder {
+	b.expiry = expiry{in: d, set: true}
+	return b
+}
+
+// ExpiresAt sets a fixed expiration time.
+func (b *MerchantBuilder) ExpiresAt(t time.Time) *MerchantBuilder {
+	b.expiry = expiry{at: t, set: true}
+	return b
+}
+
+// BillNumber sets the bill or invoice number.
+func (b *MerchantBuilder) BillNumber(s string) *MerchantBuilder {
+	b.info.BillNumber = s
+	return b
+}
+
+// StoreLabel sets the store label.
+func (b *MerchantBuilder) StoreLabel(s string) *MerchantBuilder {
+	b.info.StoreLabel = s
+	return b
+}
+
+// TerminalLabel sets the terminal label.
+func (b *MerchantBuilder) TerminalLabel(s string) *MerchantBuilder {
+	b.info.TerminalLabel = s
+	return b
+}
+
+// MobileNumber sets the mobile number.
+func (b *MerchantBuilder) MobileNumber(s string) *MerchantBuilder {
+	b.info.MobileNumber = s
+	return b
+}
+
+// Purpose sets the purpose of transaction.
+func (b *MerchantBuilder) Purpose(s string) *MerchantBuilder {
+	b.info.Purpose = s
+	return b
+}
+
+// UPIAccountInfo sets the UPI account information (not supported with USD).
+func (b *MerchantBuilder) UPIAccountInfo(s string) *MerchantBuilder {
+	b.info.UPIAccountInfo = s
+	return b
+}
+
+// AltLanguage sets the alternate-language merchant name and city together
+// with the ISO 639-1 language they are written in. city may be empty.
+func (b *MerchantBuilder) AltLanguage(lang, name, city string) *MerchantBuilder {
+	b.info.AltLanguagePreference, b.info.AltMerchantName, b.info.AltMerchantCity = lang, name, city
+	return b
+}
+
+// Info returns the MerchantInfo with the expiration resolved, for use with
+// a Generator or GenerateMerchantPair.
+func (b *MerchantBuilder) Info() (MerchantInfo, error) {
+	info := b.info
+	ts, err := b.expiry.timestamp(info.Amount)
+	if err != nil {
+		return MerchantInfo{}, err
+	}
+	info.ExpirationTimestamp = ts
+	return info, nil
+}
+
+// Build validates the fields and generates the code.
+func (b *MerchantBuilder) Build() (*Data, error) {
+	info, err := b.Info()
+	if err != nil {
+		return nil, err
+	}
+	return generateMerchant(&info)
+}
+
+// IndividualBuilder builds an IndividualInfo step by step, with the same
+// checks as MerchantBuilder.
+type IndividualBuilder struct {
+	info   IndividualInfo
+	expiry expiry
+}
+
+// NewIndividual starts an individual code for the given Bakong account.
+func NewIndividual(bakongAccountID string) *IndividualBuilder {
+	return &IndividualBuilder{info: IndividualInfo{BakongAccountID: bakongAccountID}}
+}
+
+// Name sets the merchant name (required).
+func (b *IndividualBuilder) Name(name string) *IndividualBuilder {
+	b.info.MerchantName = name
+	return b
+}
+
+// City sets the merchant city. Defaults to "Phnom Penh".
+func (b *IndividualBuilder) City(city string) *IndividualBuilder {
+	b.info.MerchantCity = city
+	return b
+}
+
+// AcquiringBank sets the acquiring bank name.
+func (b *IndividualBuilder) AcquiringBank(bank string) *IndividualBuilder {
+	b.info.AcquiringBank = bank
+	return b
+}
+
+// AccountInfo sets the account information, such as a bank account number.
+func (b *IndividualBuilder) AccountInfo(s string) *IndividualBuilder {
+	b.info.AccountInfo = s
+	return b
+}
+
+// Currency sets the transaction currency. Defaults to KHR.
+func (b *IndividualBuilder) Currency(c Currency) *IndividualBuilder {
+	b.info.Currency = c
+	return b
+}
+
+// Category sets the merchant category code. Defaults to "5999".
+func (b *IndividualBuilder) Category(mcc string) *IndividualBuilder {
+	b.info.MerchantCategoryCode = mcc
+	return b
+}
+
+// Amount makes the code dynamic. An expiration must also be set.
+func (b *IndividualBuilder) Amount(amount float64) *IndividualBuilder {
+	b.info.Amount = amount
+	return b
+}
+
+// ExpiresIn sets the expiration to d after Build is called.
+func (b *IndividualBuilder) ExpiresIn(d time.Duration) *IndividualBuilder {
+	b.expiry = expiry{in: d, set: true}
+	return b
+}
+
+// ExpiresAt sets a fixed expiration time.
+func (b *IndividualBuilder) ExpiresAt(t time.Time) *IndividualBuilder {
+	b.expiry = expiry{at: t, set: true}
+	return b
+}
+
+// BillNumber sets the bill or invoice number.
+func (b *IndividualBuilder) BillNumber(s string) *IndividualBuilder {
+	b.info.BillNumber = s
+	return b
+}
+
+// StoreLabel sets the store label.
+func (b *IndividualBuilder) StoreLabel(s string) *IndividualBuilder {
+	b.info.StoreLabel = s
+	return b
+}
+
+// TerminalLabel sets the terminal label.
+func (b *IndividualBuilder) TerminalLabel(s string) *IndividualBuilder {
+	b.info.TerminalLabel = s
+	return b
+}
+
+// MobileNumber sets the mobile number.
+func (b *IndividualBuilder) MobileNumber(s string) *IndividualBuilder {
+	b.info.MobileNumber = s
+	return b
+}
+
+// Purpose sets the purpose of transaction.
+func (b *IndividualBuilder) Purpose(s string) *IndividualBuilder {
+	b.info.Purpose = s
+	return b
+}
+
+// UPIAccountInfo sets the UPI account information (not supported with USD).
+func (b *IndividualBuilder) UPIAccountInfo(s string) *IndividualBuilder {
+	b.info.UPIAccountInfo = s
+	return b
+}
+
+// AltLanguage sets the alternate-language merchant name and city together
+// with the ISO 639-1 language they are written in. city may be empty.
+func (b *IndividualBuilder) AltLanguage(lang, name, city string) *IndividualBuilder {
+	b.info.AltLanguagePreference, b.info.AltMerchantName, b.info.AltMerchantCity = lang, name, city
+	return b
+}
+
+// Info returns the IndividualInfo with the expiration resolved, for use
+// with a Generator.
+func (b *IndividualBuilder) Info() (IndividualInfo, error) {
+	info := b.info
+	ts, err := b.expiry.timestamp(info.Amount)
+	if err != nil {
+		return IndividualInfo{}, err
+	}
+	info.ExpirationTimestamp = ts
+	return info, nil
+}
+
+// Build validates the fields and generates the code.
+func (b *IndividualBuilder) Build() (*Data, error) {
+	info, err := b.Info()
+	if err != nil {
+		return nil, err
+	}
+	return generateIndividual(&info)
+}
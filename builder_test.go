@@ -0,0 +1,127 @@
+package khqr
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMerchantBuilder(t *testing.T) {
+	t.Parallel()
+
+	data, err := NewMerchant("jonhsmith@devb").
+		Name("Jonh Smith").
+		City("Phnom Penh").
+		MerchantID("123456").
+		AcquiringBank("Dev Bank").
+		Currency(USD).
+		Amount(1.5).
+		ExpiresIn(5*time.Minute).
+		BillNumber("INV-1").
+		AltLanguage("km", "ចន ស្មីន", "ភ្នំពេញ").
+		Build()
+	if err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+	d, err := Decode(data.QR)
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	if d.MerchantType != Merchant || d.TransactionAmount != "1.5" || d.BillNumber != "INV-1" || d.AltMerchantName != "ចន ស្មីន" {
+		t.Errorf("decoded = %+v", d)
+	}
+	exp := time.Now().Add(5 * time.Minute).UnixMilli()
+	if got := mustParseInt(t, d.ExpirationTimestamp); got < exp-5000 || got > exp {
+		t.Errorf("ExpirationTimestamp = %d, want about %d", got, exp)
+	}
+
+	// The builder output matches the equivalent struct literal.
+	b := NewMerchant("jonhsmith@devb").Name("Jonh Smith").City("Phnom Penh").MerchantID("123456").AcquiringBank("Dev Bank")
+	built, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	direct, err := GenerateMerchant(MerchantInfo{
+		BakongAccountID: "jonhsmith@devb", MerchantName: "Jonh Smith", MerchantCity: "Phnom Penh",
+		MerchantID: "123456", AcquiringBank: "Dev Bank",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if built.QR != direct.QR {
+		t.Errorf("Build() = %s, want %s", built.QR, direct.QR)
+	}
+}
+
+func TestMerchantBuilderErrors(t *testing.T) {
+	t.Parallel()
+
+	base := func() *MerchantBuilder {
+		return NewMerchant("jonhsmith@devb").Name("Jonh Smith").City("Phnom Penh").MerchantID("123456").AcquiringBank("Dev Bank")
+	}
+	tests := []struct {
+		name    string
+		b       *MerchantBuilder
+		wantErr error
+	}{
+		{"missing_name", NewMerchant("jonhsmith@devb").City("Phnom Penh").MerchantID("1").AcquiringBank("Dev Bank"), ErrMerchantNameRequired},
+		{"missing_merchant_id", NewMerchant("jonhsmith@devb").Name("x").City("Phnom Penh").AcquiringBank("Dev Bank"), ErrMerchantIDRequired},
+		{"missing_bank", NewMerchant("jonhsmith@devb").Name("x").City("Phnom Penh").MerchantID("1"), ErrAcquiringBankRequired},
+		{"invalid_account", NewMerchant("nope").Name("x").City("Phnom Penh").MerchantID("1").AcquiringBank("Dev Bank"), ErrAccountIDInvalid},
+		{"amount_without_expiry", base().Amount(100), ErrExpirationRequired},
+		{"expiry_without_amount", base().ExpiresIn(time.Minute), ErrExpirationOnStaticKHQR},
+		{"expired", base().Amount(100).ExpiresAt(time.Now().Add(-time.Minute)), ErrExpirationInPast},
+		{"usd_precision", base().Currency(USD).Amount(1.234).ExpiresIn(time.Minute), ErrInvalidAmount},
+		{"alt_without_name", base().AltLanguage("km", "", ""), ErrMerchantNameAltRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if _, err := tt.b.Build(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Build() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIndividualBuilder(t *testing.T) {
+	t.Parallel()
+
+	b := NewIndividual("jonhsmith@nbcq").Name("Jonh Smith").AccountInfo("012345678").Amount(5000).ExpiresIn(time.Minute)
+	info, err := b.Info()
+	if err != nil {
+		t.Fatalf("Info() unexpected error: %v", err)
+	}
+	if info.Amount != 5000 || info.AccountInfo != "012345678" || info.ExpirationTimestamp == 0 {
+		t.Errorf("Info() = %+v", info)
+	}
+
+	data, err := b.Build()
+	if err != nil {
+		t.Fatalf("Build() unexpected error: %v", err)
+	}
+	d, err := Decode(data.QR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.MerchantType != Individual || d.MerchantCity != defaultMerchantCity || d.TransactionCurrency != "116" {
+		t.Errorf("decoded = %+v", d)
+	}
+
+	if _, err := NewIndividual("jonhsmith@nbcq").Build(); !errors.Is(err, ErrMerchantNameRequired) {
+		t.Errorf("Build() without name error = %v, want %v", err, ErrMerchantNameRequired)
+	}
+	if _, err := NewIndividual("jonhsmith@nbcq").Name("x").ExpiresIn(time.Minute).Info(); !errors.Is(err, ErrExpirationOnStaticKHQR) {
+		t.Errorf("Info() static with expiry error = %v, want %v", err, ErrExpirationOnStaticKHQR)
+	}
+}
+
+func mustParseInt(t *testing.T, s string) int64 {
+	t.Helper()
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		t.Fatalf("not an integer: %q", s)
+	}
+	return n
+}
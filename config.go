@@ -0,0 +1,285 @@
+package khqr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// configFields holds raw MerchantInfo fields keyed by their JSON names.
+type configFields map[string]json.RawMessage
+
+// configLevels names the child collections of each level of the hierarchy
+// in JSON configs: the root holds merchants, merchants hold stores and
+// stores hold terminals.
+var configLevels = []string{"merchants", "stores", "terminals"}
+
+// configPerTransaction are MerchantInfo fields that vary per code and are
+// passed to Profile.Issue instead.
+var configPerTransaction = []string{"amount", "expiration_timestamp", "bill_number"}
+
+// ConfigEntry is one merchant, store or terminal with no children, with
+// every inherited default applied.
+type ConfigEntry struct {
+	Path    string // slash-separated, e.g. "coffee/bkk1/counter-1"
+	Info    MerchantInfo
+	Profile *Profile
+}
+
+// MerchantConfig is a validated merchant/store/terminal hierarchy.
+type MerchantConfig struct {
+	entries map[string]*ConfigEntry
+}
+
+// Entry returns the entry at path.
+func (c *MerchantConfig) Entry(path string) (*ConfigEntry, bool) {
+	e, ok := c.entries[path]
+	return e, ok
+}
+
+// Profile returns the profile for the entry at path.
+func (c *MerchantConfig) Profile(path string) (*Profile, bool) {
+	e, ok := c.entries[path]
+	if !ok {
+		return nil, false
+	}
+	return e.Profile, true
+}
+
+// Entries returns every entry sorted by path.
+func (c *MerchantConfig) Entries() []ConfigEntry {
+	out := make([]ConfigEntry, 0, len(c.entries))
+	for _, e := range c.entries {
+		out = append(out, *e)
+	}
+	slices.SortFunc(out, func(a, b ConfigEntry) int { return strings.Compare(a.Path, b.Path) })
+	return out
+}
+
+// LoadMerchantConfig reads a config file, as JSON if its extension is
+// ".json" and as INI otherwise.
+func LoadMerchantConfig(path string) (*MerchantConfig, error) {
+	f, err := os.Open(path) //nolint:gosec // path is chosen by the caller
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return ParseMerchantConfigJSON(f)
+	}
+	return ParseMerchantConfigINI(f)
+}
+
+// ParseMerchantConfigJSON reads a JSON config. Each level holds
+// MerchantInfo fields under their JSON names plus an object of children
+// keyed by name: "merchants" at the root, "stores" in a merchant and
+// "terminals" in a store. Children inherit every field they do not set.
+//
+//	{
+//	  "currency": "USD",
+//	  "merchants": {
+//	    "coffee": {
+//	      "bakong_account_id": "coffee@aclb", "merchant_id": "M1", ...,
+//	      "stores": {"bkk1": {"store_label": "BKK-1", "terminals": {"t1": {"terminal_label": "Counter 1"}}}}
+//	    }
+//	  }
+//	}
+func ParseMerchantConfigJSON(r io.Reader) (*MerchantConfig, error) {
+	var root map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&root); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+	}
+	sections := map[string]configFields{}
+	if err := flattenJSONConfig(sections, "", root, 0); err != nil {
+		return nil, err
+	}
+	return buildMerchantConfig(sections)
+}
+
+func flattenJSONConfig(sections map[string]configFields, path string, node map[string]json.RawMessage, depth int) error {
+	fields := configFields{}
+	for k, v := range node {
+		if slices.Contains(configLevels, k) {
+			if depth >= len(configLevels) || k != configLevels[depth] {
+				return fmt.Errorf("%w: %q: unexpected %q", ErrConfigInvalid, path, k)
+			}
+			continue
+		}
+		fields[k] = v
+	}
+	sections[path] = fields
+
+	if depth >= len(configLevels) {
+		return nil
+	}
+	raw, ok := node[configLevels[depth]]
+	if !ok {
+		return nil
+	}
+	var children map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &children); err != nil {
+		return fmt.Errorf("%w: %q: %s: %w", ErrConfigInvalid, path, configLevels[depth], err)
+	}
+	for name, child := range children {
+		if name == "" || strings.Contains(name, "/") {
+			return fmt.Errorf("%w: %q: invalid name %q", ErrConfigInvalid, path, name)
+		}
+		if err := flattenJSONConfig(sections, joinConfigPath(path, name), child, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseMerchantConfigINI reads an INI-style config. Keys before the first
+// section are defaults for everything; a section [merchant], [merchant/store]
+// or [merchant/store/terminal] inherits from each shorter prefix. Keys are
+// MerchantInfo JSON names; values may be quoted. Lines starting with ";" or
+// "#" are comments.
+//
+//	currency = USD
+//
+//	[coffee]
+//	bakong_account_id = coffee@aclb
+//	alt_merchant_name = "កាហ្វេ"
+//
+//	[coffee/bkk1/t1]
+//	terminal_label = Counter 1
+func ParseMerchantConfigINI(r io.Reader) (*MerchantConfig, error) {
+	sections := map[string]configFields{"": {}}
+	current := ""
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if n == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		switch {
+		case line == "" || line[0] == ';' || line[0] == '#':
+			continue
+		case line[0] == '[':
+			name, ok := strings.CutSuffix(line[1:], "]")
+			name = strings.TrimSpace(name)
+			if !ok || !validConfigPath(name) {
+				return nil, fmt.Errorf("%w: line %d: invalid section %s", ErrConfigInvalid, n, line)
+			}
+			if _, dup := sections[name]; dup {
+				return nil, fmt.Errorf("%w: line %d: duplicate section [%s]", ErrConfigInvalid, n, name)
+			}
+			current = name
+			sections[current] = configFields{}
+		default:
+			key, value, ok := strings.Cut(line, "=")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" {
+				return nil, fmt.Errorf("%w: line %d: expected key = value", ErrConfigInvalid, n)
+			}
+			value = strings.TrimSpace(value)
+			if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+				value = value[1 : len(value)-1]
+			}
+			raw, _ := json.Marshal(value)
+			sections[current][key] = raw
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return buildMerchantConfig(sections)
+}
+
+func validConfigPath(path string) bool {
+	parts := strings.Split(path, "/")
+	if len(parts) > len(configLevels) {
+		return false
+	}
+	for _, p := range parts {
+		if strings.TrimSpace(p) != p || p == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func joinConfigPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "/" + name
+}
+
+// buildMerchantConfig resolves inheritance and validates every leaf.
+// Validation errors for all entries are joined.
+func buildMerchantConfig(sections map[string]configFields) (*MerchantConfig, error) {
+	cfg := &MerchantConfig{entries: map[string]*ConfigEntry{}}
+	var errs []error
+	for path := range sections {
+		if path == "" || hasConfigChildren(sections, path) {
+			continue
+		}
+		entry, err := resolveConfigEntry(sections, path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%q: %w", path, err))
+			continue
+		}
+		cfg.entries[path] = entry
+	}
+	if len(errs) > 0 {
+		slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+		return nil, fmt.Errorf("%w: %w", ErrConfigInvalid, errors.Join(errs...))
+	}
+	if len(cfg.entries) == 0 {
+		return nil, fmt.Errorf("%w: no merchants defined", ErrConfigInvalid)
+	}
+	return cfg, nil
+}
+
+func hasConfigChildren(sections map[string]configFields, path string) bool {
+	for p := range sections {
+		if strings.HasPrefix(p, path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveConfigEntry(sections map[string]configFields, path string) (*ConfigEntry, error) {
+	merged := configFields{}
+	maps := []configFields{sections[""]}
+	parts := strings.Split(path, "/")
+	for i := range parts {
+		maps = append(maps, sections[strings.Join(parts[:i+1], "/")])
+	}
+	for _, m := range maps {
+		for k, v := range m {
+			if slices.Contains(configPerTransaction, k) {
+				return nil, fmt.Errorf("%q is set per code, not in config", k)
+			}
+			merged[k] = v
+		}
+	}
+
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var info MerchantInfo
+	if err := dec.Decode(&info); err != nil {
+		return nil, err
+	}
+	p, err := NewMerchantProfile(info)
+	if err != nil {
+		return nil, err
+	}
+	info.applyDefaults()
+	return &ConfigEntry{Path: path, Info: info, Profile: p}, nil
+}
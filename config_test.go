@@ -0,0 +1,195 @@
+package khqr
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testConfigINI = `
+; shared by every merchant
+currency = USD
+merchant_city = Phnom Penh
+acquiring_bank = ACLEDA Bank
+
+[coffee]
+bakong_account_id = coffee@aclb
+merchant_name = Brown Coffee
+merchant_id = M-001
+alt_language_preference = km
+alt_merchant_name = "ប្រោនកាហ្វេ"
+
+[coffee/bkk1]
+store_label = BKK-1
+
+[coffee/bkk1/counter-1]
+terminal_label = Counter 1
+
+[coffee/bkk1/counter-2]
+terminal_label = Counter 2
+currency = KHR
+
+[coffee/siemreap]
+store_label = SR-1
+merchant_city = Siem Reap
+
+# a merchant with no stores is an entry itself
+[bakery]
+bakong_account_id = bakery@aclb
+merchant_name = Bakery
+merchant_id = M-002
+`
+
+const testConfigJSON = `{
+  "currency": "USD",
+  "merchant_city": "Phnom Penh",
+  "acquiring_bank": "ACLEDA Bank",
+  "merchants": {
+    "coffee": {
+      "bakong_account_id": "coffee@aclb",
+      "merchant_name": "Brown Coffee",
+      "merchant_id": "M-001",
+      "alt_language_preference": "km",
+      "alt_merchant_name": "ប្រោនកាហ្វេ",
+      "stores": {
+        "bkk1": {
+          "store_label": "BKK-1",
+          "terminals": {
+            "counter-1": {"terminal_label": "Counter 1"},
+            "counter-2": {"terminal_label": "Counter 2", "currency": 116}
+          }
+        },
+        "siemreap": {"store_label": "SR-1", "merchant_city": "Siem Reap"}
+      }
+    },
+    "bakery": {"bakong_account_id": "bakery@aclb", "merchant_name": "Bakery", "merchant_id": "M-002"}
+  }
+}`
+
+func TestMerchantConfig(t *testing.T) {
+	t.Parallel()
+
+	for name, parse := range map[string]func() (*MerchantConfig, error){
+		"ini":  func() (*MerchantConfig, error) { return ParseMerchantConfigINI(strings.NewReader(testConfigINI)) },
+		"json": func() (*MerchantConfig, error) { return ParseMerchantConfigJSON(strings.NewReader(testConfigJSON)) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			cfg, err := parse()
+			if err != nil {
+				t.Fatalf("parse unexpected error: %v", err)
+			}
+
+			var paths []string
+			for _, e := range cfg.Entries() {
+				paths = append(paths, e.Path)
+			}
+			want := []string{"bakery", "coffee/bkk1/counter-1", "coffee/bkk1/counter-2", "coffee/siemreap"}
+			if strings.Join(paths, ",") != strings.Join(want, ",") {
+				t.Fatalf("Entries() paths = %v, want %v", paths, want)
+			}
+
+			e, _ := cfg.Entry("coffee/bkk1/counter-1")
+			wantInfo := MerchantInfo{
+				BakongAccountID: "coffee@aclb", MerchantName: "Brown Coffee", MerchantCity: "Phnom Penh",
+				MerchantID: "M-001", AcquiringBank: "ACLEDA Bank", Currency: USD, MerchantCategoryCode: "5999",
+				StoreLabel: "BKK-1", TerminalLabel: "Counter 1", AltLanguagePreference: "km", AltMerchantName: "ប្រោនកាហ្វេ",
+			}
+			if e.Info != wantInfo {
+				t.Errorf("counter-1 Info = %+v\nwant %+v", e.Info, wantInfo)
+			}
+
+			if e, _ := cfg.Entry("coffee/bkk1/counter-2"); e.Info.Currency != KHR || e.Info.StoreLabel != "BKK-1" {
+				t.Errorf("counter-2 overrides = %+v", e.Info)
+			}
+			if e, _ := cfg.Entry("coffee/siemreap"); e.Info.MerchantCity != "Siem Reap" || e.Info.TerminalLabel != "" {
+				t.Errorf("siemreap = %+v", e.Info)
+			}
+
+			p, ok := cfg.Profile("coffee/bkk1/counter-1")
+			if !ok {
+				t.Fatal("Profile() not found")
+			}
+			data, err := p.Issue(2.5, "INV-1", time.Now().Add(time.Minute))
+			if err != nil {
+				t.Fatalf("Issue() unexpected error: %v", err)
+			}
+			d, err := Decode(data.QR)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.TerminalLabel != "Counter 1" || d.StoreLabel != "BKK-1" || d.MerchantID != "M-001" {
+				t.Errorf("issued code = %+v", d)
+			}
+
+			if _, ok := cfg.Entry("coffee"); ok {
+				t.Error("Entry(coffee) found, want only leaves")
+			}
+		})
+	}
+}
+
+func TestMerchantConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		ini     string
+		wantErr error
+		want    string
+	}{
+		{"missing_merchant_id", "[a]\nbakong_account_id = a@b\nmerchant_name = A\nmerchant_city = PP\nacquiring_bank = X\n", ErrMerchantIDRequired, `"a"`},
+		{"unknown_key", "[a]\nmerchant_nmae = A\n", ErrConfigInvalid, "merchant_nmae"},
+		{"per_transaction", "amount = 5\n[a]\n", ErrConfigInvalid, "per code"},
+		{"too_deep", "[a/b/c/d]\n", ErrConfigInvalid, "invalid section"},
+		{"bad_line", "[a]\njust text\n", ErrConfigInvalid, "line 2"},
+		{"duplicate", "[a]\n[a]\n", ErrConfigInvalid, "duplicate"},
+		{"empty", "currency = USD\n", ErrConfigInvalid, "no merchants"},
+		{"bad_currency", "[a]\ncurrency = EUR\n", ErrInvalidCurrency, `"a"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := ParseMerchantConfigINI(strings.NewReader(tt.ini))
+			if !errors.Is(err, tt.wantErr) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %v containing %q", err, tt.wantErr, tt.want)
+			}
+		})
+	}
+
+	// Every invalid entry is reported, not just the first.
+	_, err := ParseMerchantConfigINI(strings.NewReader("[a]\nmerchant_name = A\n[b]\nmerchant_name = B\n"))
+	if err == nil || !strings.Contains(err.Error(), `"a"`) || !strings.Contains(err.Error(), `"b"`) {
+		t.Errorf("error = %v, want both entries reported", err)
+	}
+
+	_, err = ParseMerchantConfigJSON(strings.NewReader(`{"merchants": {"a": {"terminals": {}}}}`))
+	if !errors.Is(err, ErrConfigInvalid) || !strings.Contains(err.Error(), "terminals") {
+		t.Errorf("JSON misplaced level error = %v", err)
+	}
+}
+
+func TestLoadMerchantConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{"merchants.ini": testConfigINI, "merchants.json": testConfigJSON} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := LoadMerchantConfig(path)
+		if err != nil {
+			t.Fatalf("LoadMerchantConfig(%s) unexpected error: %v", name, err)
+		}
+		if got := len(cfg.Entries()); got != 4 {
+			t.Errorf("LoadMerchantConfig(%s) entries = %d, want 4", name, got)
+		}
+	}
+	if _, err := LoadMerchantConfig(filepath.Join(dir, "missing.ini")); err == nil {
+		t.Error("LoadMerchantConfig(missing) expected error, got nil")
+	}
+}
@@ -1,27 +1,20 @@
 package khqr
 
-import "fmt"
-
-// Currency represents ISO 4217 numeric currency codes supported by KHQR.
+// Currency represents an ISO 4217 numeric currency code.
+// Only KHR and USD can be used for generation; the other registered
+// currencies are accepted when decoding cross-border codes.
 type Currency int
 
 const (
 	KHR Currency = 116
 	USD Currency = 840
+	CNY Currency = 156
+	LAK Currency = 418
+	MYR Currency = 458
+	VND Currency = 704
+	THB Currency = 764
 )
 
-// String returns the ISO 4217 alphabetic code for the currency.
-func (c Currency) String() string {
-	switch c {
-	case KHR:
-		return "KHR"
-	case USD:
-		return "USD"
-	default:
-		return fmt.Sprintf("Currency(%d)", int(c))
-	}
-}
-
 // MerchantType indicates whether a KHQR code is for an individual or merchant.
 type MerchantType string
 
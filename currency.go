@@ -0,0 +1,131 @@
+package khqr
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CurrencyInfo describes an ISO 4217 currency known to the SDK.
+type CurrencyInfo struct {
+	Currency   Currency // numeric code, e.g. 764
+	Code       string   // alphabetic code, e.g. "THB"
+	Name       string   // e.g. "Thai Baht"
+	MinorUnits int      // decimal places allowed in amounts
+}
+
+// khqrCurrencies are the currencies Bakong accepts for KHQR generation.
+var khqrCurrencies = []Currency{KHR, USD}
+
+// currencyRegistry holds the known currencies keyed by numeric code.
+var currencyRegistry = struct {
+	sync.RWMutex
+	byNumeric map[Currency]CurrencyInfo
+	byCode    map[string]Currency
+}{byNumeric: map[Currency]CurrencyInfo{}, byCode: map[string]Currency{}}
+
+// defaultCurrencies is the built-in registry. KHR is listed with no minor
+// units because KHQR amounts in riel must be whole, although ISO 4217
+// defines two.
+var defaultCurrencies = []CurrencyInfo{
+	{Currency: KHR, Code: "KHR", Name: "Cambodian Riel", MinorUnits: 0},
+	{Currency: USD, Code: "USD", Name: "US Dollar", MinorUnits: 2},
+	{Currency: CNY, Code: "CNY", Name: "Chinese Yuan Renminbi", MinorUnits: 2},
+	{Currency: LAK, Code: "LAK", Name: "Lao Kip", MinorUnits: 2},
+	{Currency: MYR, Code: "MYR", Name: "Malaysian Ringgit", MinorUnits: 2},
+	{Currency: VND, Code: "VND", Name: "Vietnamese Dong", MinorUnits: 0},
+	{Currency: THB, Code: "THB", Name: "Thai Baht", MinorUnits: 2},
+}
+
+func init() {
+	for _, info := range defaultCurrencies {
+		registerCurrency(info)
+	}
+}
+
+// RegisterCurrency adds a currency to the registry, replacing any existing
+// entry with the same numeric or alphabetic code. Registered currencies
+// can be decoded, verified and marshaled; generation remains limited to
+// KHR and USD, which cannot be overridden.
+func RegisterCurrency(info CurrencyInfo) error {
+	info.Code = strings.ToUpper(strings.TrimSpace(info.Code))
+	for _, c := range khqrCurrencies {
+		if info.Currency == c || info.Code == c.String() {
+			return fmt.Errorf("%w: %s", ErrCurrencyReserved, c)
+		}
+	}
+	registerCurrency(info)
+	return nil
+}
+
+func registerCurrency(info CurrencyInfo) {
+	currencyRegistry.Lock()
+	defer currencyRegistry.Unlock()
+	if old, ok := currencyRegistry.byNumeric[info.Currency]; ok {
+		delete(currencyRegistry.byCode, old.Code)
+	}
+	if old, ok := currencyRegistry.byCode[info.Code]; ok {
+		delete(currencyRegistry.byNumeric, old)
+	}
+	currencyRegistry.byNumeric[info.Currency] = info
+	currencyRegistry.byCode[info.Code] = info.Currency
+}
+
+// LookupCurrency returns the registry entry for a numeric currency code.
+func LookupCurrency(c Currency) (CurrencyInfo, bool) {
+	currencyRegistry.RLock()
+	defer currencyRegistry.RUnlock()
+	info, ok := currencyRegistry.byNumeric[c]
+	return info, ok
+}
+
+// Currencies returns all registered currencies sorted by numeric code.
+func Currencies() []CurrencyInfo {
+	currencyRegistry.RLock()
+	defer currencyRegistry.RUnlock()
+	infos := make([]CurrencyInfo, 0, len(currencyRegistry.byNumeric))
+	for _, info := range currencyRegistry.byNumeric {
+		infos = append(infos, info)
+	}
+	slices.SortFunc(infos, func(a, b CurrencyInfo) int { return int(a.Currency) - int(b.Currency) })
+	return infos
+}
+
+// ParseCurrency resolves a registered currency from its alphabetic code
+// (e.g. "THB", case-insensitive) or numeric code (e.g. "764").
+func ParseCurrency(s string) (Currency, error) {
+	s = strings.TrimSpace(s)
+	currencyRegistry.RLock()
+	c, ok := currencyRegistry.byCode[strings.ToUpper(s)]
+	currencyRegistry.RUnlock()
+	if ok {
+		return c, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, ErrInvalidCurrency
+	}
+	if _, ok := LookupCurrency(Currency(n)); !ok {
+		return 0, ErrInvalidCurrency
+	}
+	return Currency(n), nil
+}
+
+// String returns the ISO 4217 alphabetic code for the currency.
+func (c Currency) String() string {
+	if info, ok := LookupCurrency(c); ok {
+		return info.Code
+	}
+	return fmt.Sprintf("Currency(%d)", int(c))
+}
+
+// minorUnits returns the number of decimal places allowed for amounts in c.
+// Unregistered currencies default to two.
+func minorUnits(c Currency) int {
+	if info, ok := LookupCurrency(c); ok {
+		return info.MinorUnits
+	}
+	return 2 //nolint:mnd // ISO 4217 default exponent
+}
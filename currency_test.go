@@ -0,0 +1,247 @@
+package khqr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseCurrency(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    Currency
+		wantErr error
+	}{
+		{"KHR_alpha", "KHR", KHR, nil},
+		{"USD_numeric", "840", USD, nil},
+		{"THB_alpha", "THB", THB, nil},
+		{"VND_lowercase", "vnd", VND, nil},
+		{"LAK_numeric", "418", LAK, nil},
+		{"MYR_alpha", "MYR", MYR, nil},
+		{"CNY_numeric", "156", CNY, nil},
+		{"unregistered_alpha", "EUR", 0, ErrInvalidCurrency},
+		{"unregistered_numeric", "978", 0, ErrInvalidCurrency},
+		{"empty", "", 0, ErrInvalidCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseCurrency(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseCurrency(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseCurrency(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrencyString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		currency Currency
+		want     string
+	}{
+		{KHR, "KHR"},
+		{USD, "USD"},
+		{THB, "THB"},
+		{Currency(999), "Currency(999)"},
+	}
+	for _, tt := range tests {
+		if got := tt.currency.String(); got != tt.want {
+			t.Errorf("Currency(%d).String() = %q, want %q", int(tt.currency), got, tt.want)
+		}
+	}
+}
+
+func TestLookupCurrencyMinorUnits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		currency Currency
+		want     int
+	}{
+		{KHR, 0},
+		{USD, 2},
+		{VND, 0},
+		{THB, 2},
+	}
+	for _, tt := range tests {
+		info, ok := LookupCurrency(tt.currency)
+		if !ok {
+			t.Fatalf("LookupCurrency(%v) ok = false", tt.currency)
+		}
+		if info.MinorUnits != tt.want {
+			t.Errorf("LookupCurrency(%v).MinorUnits = %d, want %d", tt.currency, info.MinorUnits, tt.want)
+		}
+	}
+}
+
+func TestRegisterCurrency(t *testing.T) {
+	t.Parallel()
+
+	const sgd = Currency(702)
+	if err := RegisterCurrency(CurrencyInfo{Currency: sgd, Code: "sgd", Name: "Singapore Dollar", MinorUnits: 2}); err != nil {
+		t.Fatalf("RegisterCurrency(SGD) unexpected error: %v", err)
+	}
+
+	got, err := ParseCurrency("SGD")
+	if err != nil || got != sgd {
+		t.Fatalf("ParseCurrency(%q) = %v, %v, want %v", "SGD", got, err, sgd)
+	}
+	if s := sgd.String(); s != "SGD" {
+		t.Errorf("String() = %q, want %q", s, "SGD")
+	}
+	if err := validateCurrency(sgd); !errors.Is(err, ErrInvalidCurrency) {
+		t.Errorf("validateCurrency(SGD) = %v, want %v", err, ErrInvalidCurrency)
+	}
+
+	if err := RegisterCurrency(CurrencyInfo{Currency: sgd, Code: "XSG", MinorUnits: 2}); err != nil {
+		t.Fatalf("RegisterCurrency(XSG) unexpected error: %v", err)
+	}
+	if _, err := ParseCurrency("SGD"); !errors.Is(err, ErrInvalidCurrency) {
+		t.Errorf("ParseCurrency(%q) after re-register error = %v, want %v", "SGD", err, ErrInvalidCurrency)
+	}
+
+	// Moving a code to a new number drops the old entry.
+	const xts, xts2 = Currency(963), Currency(964)
+	for _, c := range []Currency{xts, xts2} {
+		if err := RegisterCurrency(CurrencyInfo{Currency: c, Code: "XTS", MinorUnits: 2}); err != nil {
+			t.Fatalf("RegisterCurrency(%d) unexpected error: %v", c, err)
+		}
+	}
+	if _, ok := LookupCurrency(xts); ok {
+		t.Errorf("LookupCurrency(%d) still registered after its code moved", xts)
+	}
+	if got, err := ParseCurrency("XTS"); err != nil || got != xts2 {
+		t.Errorf("ParseCurrency(%q) = %v, %v, want %v", "XTS", got, err, xts2)
+	}
+}
+
+func TestRegisterCurrencyReserved(t *testing.T) {
+	t.Parallel()
+
+	for _, info := range []CurrencyInfo{
+		{Currency: KHR, Code: "KHR", MinorUnits: 2},
+		{Currency: USD, Code: "USX", MinorUnits: 0},
+		{Currency: 901, Code: "usd", MinorUnits: 2},
+	} {
+		if err := RegisterCurrency(info); !errors.Is(err, ErrCurrencyReserved) {
+			t.Errorf("RegisterCurrency(%+v) = %v, want %v", info, err, ErrCurrencyReserved)
+		}
+	}
+	if minorUnits(KHR) != 0 || minorUnits(USD) != 2 || USD.String() != "USD" {
+		t.Errorf("KHR/USD entries changed: %d, %d, %s", minorUnits(KHR), minorUnits(USD), USD)
+	}
+	if _, ok := LookupCurrency(901); ok {
+		t.Error("LookupCurrency(901) registered despite the error")
+	}
+}
+
+func TestCurrenciesSorted(t *testing.T) {
+	t.Parallel()
+
+	infos := Currencies()
+	if len(infos) < len(defaultCurrencies) {
+		t.Fatalf("Currencies() returned %d entries, want at least %d", len(infos), len(defaultCurrencies))
+	}
+	for i := 1; i < len(infos); i++ {
+		if infos[i-1].Currency >= infos[i].Currency {
+			t.Errorf("Currencies() not sorted: %d before %d", infos[i-1].Currency, infos[i].Currency)
+		}
+	}
+}
+
+func TestVerifyCrossBorderCurrency(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		base      string
+		wantErr   error
+		decodeErr error // Decode only checks that tag 53 is digits
+	}{
+		{
+			"THB_two_decimals",
+			"00020101021229180014jonhsmith@nbcq520459995303764540512.505802KH5910Jonh Smith6010Phnom Penh",
+			nil,
+			nil,
+		},
+		{
+			"VND_whole",
+			"00020101021229180014jonhsmith@nbcq5204599953037045405250005802KH5910Jonh Smith6010Phnom Penh",
+			nil,
+			nil,
+		},
+		{
+			"VND_decimals",
+			"00020101021229180014jonhsmith@nbcq52045999530370454062500.55802KH5910Jonh Smith6010Phnom Penh",
+			ErrInvalidAmount,
+			nil,
+		},
+		{
+			"unregistered",
+			"00020101021229180014jonhsmith@nbcq520459995303978540512.505802KH5910Jonh Smith6010Phnom Penh",
+			ErrInvalidCurrency,
+			nil,
+		},
+		{
+			"alphabetic",
+			"00020101021229180014jonhsmith@nbcq520459995303USD54031.55802KH5910Jonh Smith6010Phnom Penh",
+			ErrInvalidCurrency,
+			ErrInvalidCurrency,
+		},
+		{
+			"alphabetic_lowercase",
+			"00020101021229180014jonhsmith@nbcq520459995303thb540512.505802KH5910Jonh Smith6010Phnom Penh",
+			ErrInvalidCurrency,
+			ErrInvalidCurrency,
+		},
+		{
+			"signed_number",
+			"00020101021229180014jonhsmith@nbcq520459995303+84540512.505802KH5910Jonh Smith6010Phnom Penh",
+			ErrInvalidCurrency,
+			ErrInvalidCurrency,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			qr := mockTimestampAndCRC(tt.base)
+			if err := Verify(qr); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := Decode(qr); !errors.Is(err, tt.decodeErr) {
+				t.Errorf("Decode() = %v, want %v", err, tt.decodeErr)
+			}
+		})
+	}
+}
+
+func TestValidateTransactionAmountCurrency(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []string{"USD", "978", ""} {
+		data := DecodedData{TransactionAmount: "1.5", TransactionCurrency: tc}
+		if err := data.validateTransactionAmount(); !errors.Is(err, ErrInvalidCurrency) {
+			t.Errorf("validateTransactionAmount(currency %q) = %v, want %v", tc, err, ErrInvalidCurrency)
+		}
+	}
+}
+
+func TestGenerateRejectsCrossBorderCurrency(t *testing.T) {
+	t.Parallel()
+
+	_, err := GenerateIndividual(IndividualInfo{
+		BakongAccountID: "jonhsmith@nbcq",
+		MerchantName:    "Jonh Smith",
+		Currency:        THB,
+	})
+	if !errors.Is(err, ErrInvalidCurrency) {
+		t.Errorf("GenerateIndividual(THB) error = %v, want %v", err, ErrInvalidCurrency)
+	}
+}
@@ -72,6 +72,10 @@ func decode(qr string) (*DecodedData, error) {
 			return nil, err
 		}
 	}
+	if strings.ContainsFunc(data.TransactionCurrency, func(r rune) bool { return r < '0' || r > '9' }) {
+		return nil, ErrInvalidCurrency // tag 53 is the numeric ISO 4217 code
+	}
+	data.MerchantCategory = merchantCategoryDescription(data.MerchantCategoryCode)
 
 	return data, nil
 }
@@ -26,6 +26,7 @@ func TestDecode(t *testing.T) {
 				MerchantName:            "jonh smith",
 				TransactionAmount:       "5000.0",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Phnom Penh",
 				BillNumber:              "#INV-2003",
@@ -49,6 +50,7 @@ func TestDecode(t *testing.T) {
 				MerchantName:            "john smith actor",
 				TransactionAmount:       "5.0",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Phnom Penh",
 				TerminalLabel:           "Counter 2",
@@ -70,6 +72,7 @@ func TestDecode(t *testing.T) {
 				MerchantName:            "john smith actor",
 				TransactionAmount:       "5.0",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Phnom Penh",
 				BillNumber:              "Invoice#069",
@@ -92,6 +95,7 @@ func TestDecode(t *testing.T) {
 				MerchantName:            "john smith actor",
 				TransactionAmount:       "5.0",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Phnom Penh",
 				BillNumber:              "Invoice#069",
@@ -134,6 +138,7 @@ func TestDecode(t *testing.T) {
 				TransactionCurrency:     "840",
 				MerchantName:            "BUN MAO",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Phnom Penh",
 				MobileNumber:            "010500331",
@@ -156,6 +161,7 @@ func TestDecode(t *testing.T) {
 				TransactionCurrency:     "840",
 				MerchantName:            "KHQR TESTING",
 				MerchantCategoryCode:    "5931",
+				MerchantCategory:        "Used Merchandise and Secondhand Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Phnom Penh",
 				MobileNumber:            "017535771",
@@ -178,6 +184,7 @@ func TestDecode(t *testing.T) {
 				MerchantName:            "Jonh Smith",
 				TransactionAmount:       "50000.0",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Siam Reap",
 				BillNumber:              "INV-2021-07-65822",
@@ -211,6 +218,7 @@ func TestDecode(t *testing.T) {
 				MerchantName:            "john smith actor",
 				TransactionAmount:       "5.0",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Phnom Penh",
 				BillNumber:              "Invoice#069",
@@ -228,6 +236,7 @@ func TestDecode(t *testing.T) {
 				MerchantName:            "john smith actor",
 				TransactionAmount:       "5.0",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Phnom Penh",
 				BillNumber:              "Invoice#069",
@@ -282,6 +291,7 @@ func TestDecode(t *testing.T) {
 				MerchantName:            "john smith actor",
 				TransactionAmount:       "5.0",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Phnom Penh",
 				BillNumber:              "Invoice#069",
@@ -339,6 +349,7 @@ func TestDecode(t *testing.T) {
 				BakongAccountID:         "john_smith@devb",
 				MerchantType:            Individual,
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				TransactionCurrency:     "8840",
 				CRC:                     "FEA6",
 			},
@@ -352,6 +363,7 @@ func TestDecode(t *testing.T) {
 				BakongAccountID:         "john_smith@devb",
 				MerchantType:            Individual,
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				TransactionAmount:       "5.0",
 				CountryCode:             "KH",
 				MerchantName:            "john smith actor",
@@ -370,6 +382,7 @@ func TestDecode(t *testing.T) {
 				BakongAccountID:         "john_smith@devb",
 				MerchantType:            Individual,
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				TransactionCurrency:     "840",
 				TransactionAmount:       "5.0",
 				CountryCode:             "KKH",
@@ -385,6 +398,7 @@ func TestDecode(t *testing.T) {
 				BakongAccountID:         "john_smith@devb",
 				MerchantType:            Individual,
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				TransactionCurrency:     "840",
 				TransactionAmount:       "5.0",
 				MerchantName:            "john smith actor",
@@ -403,6 +417,7 @@ func TestDecode(t *testing.T) {
 				BakongAccountID:         "john_smith@devb",
 				MerchantType:            Individual,
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				TransactionCurrency:     "840",
 				TransactionAmount:       "5.0",
 				CountryCode:             "KH",
@@ -420,6 +435,7 @@ func TestDecode(t *testing.T) {
 				BakongAccountID:         "john_smith@devb",
 				MerchantType:            Individual,
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				TransactionCurrency:     "840",
 				TransactionAmount:       "5.0",
 				CountryCode:             "KH",
@@ -438,6 +454,7 @@ func TestDecode(t *testing.T) {
 				BakongAccountID:         "john_smith@devb",
 				MerchantType:            Individual,
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				TransactionCurrency:     "840",
 				TransactionAmount:       "5.0",
 				CountryCode:             "KH",
@@ -456,6 +473,7 @@ func TestDecode(t *testing.T) {
 				BakongAccountID:         "john_smith@devb",
 				MerchantType:            Individual,
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				TransactionCurrency:     "840",
 				TransactionAmount:       "5.0",
 				CountryCode:             "KH",
@@ -473,6 +491,7 @@ func TestDecode(t *testing.T) {
 				BakongAccountID:         "john_smith@devb",
 				MerchantType:            Individual,
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				TransactionCurrency:     "840",
 				TransactionAmount:       "5.0",
 				CountryCode:             "KH",
@@ -489,6 +508,7 @@ func TestDecode(t *testing.T) {
 				BakongAccountID:         "john_smith@devb",
 				MerchantType:            Individual,
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				TransactionCurrency:     "840",
 				TransactionAmount:       "5.0",
 				CountryCode:             "KH",
@@ -512,6 +532,7 @@ func TestDecode(t *testing.T) {
 				MerchantName:            "jonh smith",
 				TransactionAmount:       "5000.0",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Phnom Penh",
 				StoreLabel:              "Coffee Klaing",
@@ -532,6 +553,7 @@ func TestDecode(t *testing.T) {
 				MerchantName:            "jonh smith",
 				TransactionAmount:       "5000.0",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Phnom Penh",
 				BillNumber:              "#INV-2003",
@@ -552,6 +574,7 @@ func TestDecode(t *testing.T) {
 				MerchantName:            "jonh smith",
 				TransactionAmount:       "5000.0",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Phnom Penh",
 				BillNumber:              "#INV-2003",
@@ -569,6 +592,7 @@ func TestDecode(t *testing.T) {
 				BakongAccountID:         "john_smith@devb",
 				MerchantType:            Individual,
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				TransactionCurrency:     "849",
 				TransactionAmount:       "5.0",
 				CountryCode:             "KH",
@@ -593,6 +617,7 @@ func TestDecode(t *testing.T) {
 				TransactionCurrency:     "116",
 				MerchantName:            "Le Pure Cafe",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "PHNOM PENH",
 				BillNumber:              "#100731",
@@ -615,6 +640,7 @@ func TestDecode(t *testing.T) {
 				TransactionCurrency:     "116",
 				MerchantName:            "KHQR TESTING",
 				MerchantCategoryCode:    "5931",
+				MerchantCategory:        "Used Merchandise and Secondhand Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Phnom Penh",
 				MobileNumber:            "017535771",
@@ -637,6 +663,7 @@ func TestDecode(t *testing.T) {
 				TransactionCurrency:     "116",
 				MerchantName:            "Jonh Smith",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Phnom Penh",
 				MobileNumber:            "85512345678",
@@ -657,6 +684,7 @@ func TestDecode(t *testing.T) {
 				TransactionCurrency:     "116",
 				MerchantName:            "Jonh Smith",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Phnom Penh",
 				CreationTimestamp:       "1687316181161",
@@ -679,6 +707,7 @@ func TestDecode(t *testing.T) {
 				MerchantName:            "John Smith",
 				TransactionAmount:       "100",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "PHNOM PENH",
 				BillNumber:              "#12345",
@@ -708,6 +737,7 @@ func TestDecode(t *testing.T) {
 				MerchantName:            "John Smith",
 				TransactionAmount:       "100",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "PHNOM PENH",
 				BillNumber:              "#12345",
@@ -737,6 +767,7 @@ func TestDecode(t *testing.T) {
 				MerchantName:            "jonh smith",
 				TransactionAmount:       "5000.0",
 				MerchantCategoryCode:    "5999",
+				MerchantCategory:        "Miscellaneous and Specialty Retail Stores",
 				CountryCode:             "KH",
 				MerchantCity:            "Phnom Penh",
 				BillNumber:              "#INV-2003",
@@ -0,0 +1,121 @@
+package khqr
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind says how a field differs between two payloads.
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// FieldChange is one difference reported by Diff.
+type FieldChange struct {
+	Path string     `json:"path"` // tag IDs from the root, e.g. "62.01"
+	Name string     `json:"name"`
+	Kind ChangeKind `json:"kind"`
+	Old  string     `json:"old,omitempty"`
+	New  string     `json:"new,omitempty"`
+}
+
+// String formats the change for logs, e.g.
+// `54 Transaction Amount: changed "5" -> "50"`.
+func (c FieldChange) String() string { //nolint:gocritic // value receiver so []FieldChange elements print with %v
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("%s %s: added %q", c.Path, c.Name, c.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("%s %s: removed %q", c.Path, c.Name, c.Old)
+	}
+	return fmt.Sprintf("%s %s: changed %q -> %q", c.Path, c.Name, c.Old, c.New)
+}
+
+// DiffOption configures Diff.
+type DiffOption func(*diffConfig)
+
+type diffConfig struct {
+	ignore map[string]bool // paths skipped with everything below them
+}
+
+// IgnoreTimestamps leaves the creation and expiration timestamps (tag 99)
+// out of the comparison.
+func IgnoreTimestamps() DiffOption {
+	return func(c *diffConfig) { c.ignore[tagTimestamp] = true }
+}
+
+// Diff compares two payloads field by field and reports what was added,
+// removed or changed, ordered by tag path. Templates are compared by their
+// subtags. The CRC is always ignored. Either payload failing to parse
+// returns ErrInvalidQR; neither needs to pass Verify.
+func Diff(a, b string, opts ...DiffOption) ([]FieldChange, error) {
+	cfg := diffConfig{ignore: map[string]bool{tagCRC: true}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	left, err := diffLeaves(a, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	right, err := diffLeaves(b, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []FieldChange
+	for path, l := range left {
+		r, ok := right[path]
+		switch {
+		case !ok:
+			changes = append(changes, FieldChange{Path: path, Name: l.name, Kind: ChangeRemoved, Old: l.value})
+		case l.value != r.value:
+			changes = append(changes, FieldChange{Path: path, Name: l.name, Kind: ChangeChanged, Old: l.value, New: r.value})
+		}
+	}
+	for path, r := range right {
+		if _, ok := left[path]; !ok {
+			changes = append(changes, FieldChange{Path: path, Name: r.name, Kind: ChangeAdded, New: r.value})
+		}
+	}
+	slices.SortFunc(changes, func(x, y FieldChange) int { return strings.Compare(x.Path, y.Path) })
+	return changes, nil
+}
+
+// diffLeaves parses qr into its leaf fields keyed by path. A repeated tag
+// gets a "#2", "#3"... suffix so every copy is compared.
+func diffLeaves(qr string, cfg *diffConfig) (map[string]tlvNode, error) {
+	nodes, rest := parseTLVTree(strings.TrimSpace(qr), "", khqrTags)
+	if rest != "" {
+		return nil, ErrInvalidQR
+	}
+	leaves := map[string]tlvNode{}
+	var walk func(nodes []tlvNode, parent string)
+	walk = func(nodes []tlvNode, parent string) {
+		seen := map[string]int{}
+		for _, n := range nodes {
+			if cfg.ignore[n.path] {
+				continue
+			}
+			key := n.tag
+			if seen[n.tag]++; seen[n.tag] > 1 {
+				key += "#" + strconv.Itoa(seen[n.tag])
+			}
+			if parent != "" {
+				key = parent + "." + key
+			}
+			if n.children != nil {
+				walk(n.children, key)
+				continue
+			}
+			leaves[key] = n
+		}
+	}
+	walk(nodes, "")
+	return leaves, nil
+}
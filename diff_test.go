@@ -0,0 +1,101 @@
+package khqr
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	const (
+		head   = "000201010212"
+		acct   = "29190015john_smith@devb"
+		fields = "52045999" + "5303840" + "54015" + "5802KH" + "5910jonh smith" + "6010Phnom Penh"
+		bill   = "62070103INV"
+		times  = "99340013161302797275701131613027972757"
+	)
+	issued := withCRC(head + acct + fields + bill + times)
+
+	tests := []struct {
+		name string
+		b    string
+		opts []DiffOption
+		want []string
+	}{
+		{"identical", issued, nil, nil},
+		{"crc_only", head + acct + fields + bill + times + "6304FFFF", nil, nil},
+		{
+			"amount_tampered",
+			withCRC(head + acct + strings.Replace(fields, "54015", "540250", 1) + bill + times),
+			nil,
+			[]string{`54 Transaction Amount: changed "5" -> "50"`},
+		},
+		{
+			"account_swapped",
+			withCRC(head + "29190015evil_actor@devb" + fields + bill + times),
+			nil,
+			[]string{`29.00 Bakong Account ID: changed "john_smith@devb" -> "evil_actor@devb"`},
+		},
+		{
+			"individual_to_merchant",
+			withCRC(head + "30190015john_smith@devb" + fields + bill + times),
+			nil,
+			[]string{
+				`29.00 Bakong Account ID: removed "john_smith@devb"`,
+				`30.00 Bakong Account ID: added "john_smith@devb"`,
+			},
+		},
+		{
+			"timestamps",
+			withCRC(head + acct + fields + "62150103INV0704T-01" + "99340013170000000000001131700000000000"),
+			nil,
+			[]string{
+				`62.07 Terminal Label: added "T-01"`,
+				`99.00 Creation Timestamp: changed "1613027972757" -> "1700000000000"`,
+				`99.01 Expiration Timestamp: changed "1613027972757" -> "1700000000000"`,
+			},
+		},
+		{
+			"ignore_timestamps",
+			withCRC(head + acct + fields + bill),
+			[]DiffOption{IgnoreTimestamps()},
+			nil,
+		},
+		{
+			"unknown_and_duplicate",
+			withCRC(head + acct + fields + bill + times + "8506000201" + "5802US"),
+			nil,
+			[]string{
+				`58#2 Country Code: added "US"`,
+				`85.00 Unknown: added "01"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		changes, err := Diff(issued, tt.b, tt.opts...)
+		if err != nil {
+			t.Fatalf("%s: Diff() unexpected error: %v", tt.name, err)
+		}
+		var got []string
+		for _, c := range changes {
+			got = append(got, c.String())
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Diff() =\n%s\nwant\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
+
+func TestDiffInvalid(t *testing.T) {
+	t.Parallel()
+
+	valid := withCRC("000201010211")
+	for _, pair := range [][2]string{{"0002010", valid}, {valid, "00020101021"}} {
+		if _, err := Diff(pair[0], pair[1]); !errors.Is(err, ErrInvalidQR) {
+			t.Errorf("Diff(%q, %q) err = %v, want ErrInvalidQR", pair[0], pair[1], err)
+		}
+	}
+}
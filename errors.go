@@ -4,8 +4,8 @@ import "fmt"
 
 // Error represents a KHQR validation or processing error.
 type Error struct {
-	Code    int
-	Message string
+	Code    int    `json:"code"`
+	Message string `json:"message"`
 }
 
 func (e *Error) Error() string {
@@ -74,4 +74,24 @@ var (
 	ErrInvalidTimestamp               = &Error{Code: 49, Message: "Expiration timestamp length is invalid"}
 	ErrExpirationInPast               = &Error{Code: 50, Message: "Expiration timestamp is in the past"}
 	ErrMerchantCategoryCodeInvalid    = &Error{Code: 51, Message: "Invalid Merchant Category Code"}
+	ErrMerchantTypeInvalid            = &Error{Code: 52, Message: "Merchant type is invalid"}
+	ErrAccountIDUnknownBank           = &Error{Code: 53, Message: "Bakong Account ID bank suffix is not a known participant"}
+	ErrMerchantCategoryCodeUnassigned = &Error{Code: 54, Message: "Merchant Category Code is not assigned"}
+	ErrExchangeRateUnavailable        = &Error{Code: 55, Message: "Exchange rate is unavailable"}
+	ErrRecordNotFound                 = &Error{Code: 56, Message: "Issued KHQR record not found"}
+	ErrIdempotencyConflict            = &Error{Code: 57, Message: "Idempotency key was already used with different inputs"}
+	ErrWebhookSignatureInvalid        = &Error{Code: 58, Message: "Webhook signature is invalid"}
+	ErrAccountNotFound                = &Error{Code: 59, Message: "Bakong Account ID does not exist"}
+	ErrExpirationOnStaticKHQR         = &Error{Code: 60, Message: "Expiration requires an amount (dynamic KHQR)"}
+	ErrConfigInvalid                  = &Error{Code: 61, Message: "Merchant configuration is invalid"}
+	ErrStaticKHQRRequired             = &Error{Code: 62, Message: "Printed KHQR must be static (no amount)"}
+	ErrFontInvalid                    = &Error{Code: 63, Message: "Font is not a supported TrueType font"}
+	ErrFontGlyphMissing               = &Error{Code: 64, Message: "Font has no glyph for a character in the text"}
+	ErrSignatureMissing               = &Error{Code: 65, Message: "KHQR is not signed"}
+	ErrSignatureInvalid               = &Error{Code: 66, Message: "KHQR signature is invalid"}
+	ErrSignatureKeyUnknown            = &Error{Code: 67, Message: "KHQR signature key ID is not recognized"}
+	ErrSigningKeyInvalid              = &Error{Code: 68, Message: "Signing key ID or secret is invalid"}
+	ErrCurrencyReserved               = &Error{Code: 69, Message: "KHR and USD cannot be re-registered"}
+	ErrTextShapingUnsupported         = &Error{Code: 70, Message: "Font has no subscript forms for the Khmer text"}
+	ErrWebhookSecretInvalid           = &Error{Code: 71, Message: "Webhook secret is shorter than 16 bytes"}
 )
@@ -4,4 +4,6 @@ go 1.25.5
 
 require github.com/ishinvin/go-khqr v0.0.0
 
+require golang.org/x/text v0.40.0 // indirect
+
 replace github.com/ishinvin/go-khqr => ../
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	khqr "github.com/ishinvin/go-khqr"
+)
+
+func main() {
+	ansi := flag.Bool("ansi", false, "draw with ANSI background colors instead of half blocks")
+	invert := flag.Bool("invert", false, "swap dark and light, for terminals with a light background")
+	flag.Parse()
+
+	data, err := khqr.GenerateIndividual(khqr.IndividualInfo{
+		BakongAccountID: "ishin_vin@bkrt",
+		MerchantName:    "Ishin Vin",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var opts []khqr.TerminalOption
+	if *ansi {
+		opts = append(opts, khqr.WithTerminalANSI())
+	}
+	if *invert {
+		opts = append(opts, khqr.WithTerminalInvert())
+	}
+	if err := data.WriteTerminal(os.Stdout, opts...); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("MD5:", data.MD5())
+}
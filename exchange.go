@@ -0,0 +1,273 @@
+package khqr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ExchangeRate is the number of To units bought by one From unit.
+type ExchangeRate struct {
+	From Currency  `json:"from"`
+	To   Currency  `json:"to"`
+	Rate float64   `json:"rate"`
+	Date time.Time `json:"date,omitzero"` // effective date published by the source, if any
+}
+
+// RateProvider supplies exchange rates, typically the official NBC rate.
+type RateProvider interface {
+	Rate(ctx context.Context, from, to Currency) (ExchangeRate, error)
+}
+
+// lookupRate finds a direct rate in rates, falling back to the inverse of
+// the reverse pair.
+func lookupRate(rates []ExchangeRate, from, to Currency) (ExchangeRate, error) {
+	for _, r := range rates {
+		if r.From == from && r.To == to && r.Rate > 0 {
+			return r, nil
+		}
+	}
+	for _, r := range rates {
+		if r.From == to && r.To == from && r.Rate > 0 {
+			return ExchangeRate{From: from, To: to, Rate: 1 / r.Rate, Date: r.Date}, nil
+		}
+	}
+	return ExchangeRate{}, ErrExchangeRateUnavailable
+}
+
+// StaticRates is a RateProvider backed by a fixed table of rates.
+// Inverse pairs are derived automatically.
+type StaticRates []ExchangeRate
+
+// Rate implements RateProvider.
+func (s StaticRates) Rate(_ context.Context, from, to Currency) (ExchangeRate, error) {
+	return lookupRate(s, from, to)
+}
+
+// FileRates is a RateProvider that reads a JSON array of ExchangeRate
+// values from a file, reloading it whenever the modification time changes.
+type FileRates struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	rates   []ExchangeRate
+}
+
+// NewFileRates returns a FileRates reading from path.
+func NewFileRates(path string) *FileRates {
+	return &FileRates{path: path}
+}
+
+// Rate implements RateProvider.
+func (f *FileRates) Rate(_ context.Context, from, to Currency) (ExchangeRate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return ExchangeRate{}, fmt.Errorf("%w: %w", ErrExchangeRateUnavailable, err)
+	}
+	if !info.ModTime().Equal(f.modTime) {
+		b, err := os.ReadFile(f.path)
+		if err != nil {
+			return ExchangeRate{}, fmt.Errorf("%w: %w", ErrExchangeRateUnavailable, err)
+		}
+		var rates []ExchangeRate
+		if err := json.Unmarshal(b, &rates); err != nil {
+			return ExchangeRate{}, fmt.Errorf("%w: %w", ErrExchangeRateUnavailable, err)
+		}
+		f.rates, f.modTime = rates, info.ModTime()
+	}
+	return lookupRate(f.rates, from, to)
+}
+
+// HTTPRates is a RateProvider that fetches a JSON array of ExchangeRate
+// values from a URL and caches it for TTL. Point it at the service that
+// republishes the official rate, or at a local stand-in during tests.
+type HTTPRates struct {
+	URL    string
+	Client *http.Client  // defaults to http.DefaultClient
+	TTL    time.Duration // 0 fetches on every call
+
+	mu        sync.Mutex
+	fetchedAt time.Time
+	rates     []ExchangeRate
+}
+
+// Rate implements RateProvider.
+func (h *HTTPRates) Rate(ctx context.Context, from, to Currency) (ExchangeRate, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.rates == nil || time.Since(h.fetchedAt) >= h.TTL {
+		rates, err := h.fetch(ctx)
+		if err != nil {
+			return ExchangeRate{}, fmt.Errorf("%w: %w", ErrExchangeRateUnavailable, err)
+		}
+		h.rates, h.fetchedAt = rates, time.Now()
+	}
+	return lookupRate(h.rates, from, to)
+}
+
+func (h *HTTPRates) fetch(ctx context.Context) ([]ExchangeRate, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20)) //nolint:mnd // 1 MiB is ample for a rate table
+	if err != nil {
+		return nil, err
+	}
+	var rates []ExchangeRate
+	if err := json.Unmarshal(body, &rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// RoundingMode selects how converted amounts are rounded.
+type RoundingMode int
+
+const (
+	RoundHalfUp RoundingMode = iota // round to the nearest increment, halves away from zero
+	RoundUp                         // round up to the next increment
+	RoundDown                       // round down to the previous increment
+)
+
+// Rounding describes how a converted amount is rounded. A zero Increment
+// rounds to the target currency's minor unit.
+type Rounding struct {
+	Mode      RoundingMode
+	Increment float64
+}
+
+// RielRounding rounds riel amounts to the nearest 100, the smallest note
+// in circulation.
+var RielRounding = Rounding{Mode: RoundHalfUp, Increment: 100}
+
+// Apply rounds amount in currency c according to the rounding policy.
+func (r Rounding) Apply(amount float64, c Currency) float64 {
+	inc := r.Increment
+	if inc <= 0 {
+		inc = 1 / math.Pow10(minorUnits(c))
+	}
+	// Snap to 1e-9 first so binary representation error does not push an
+	// exact multiple over the edge in RoundUp/RoundDown.
+	steps := math.Round(amount/inc*1e9) / 1e9 //nolint:mnd // float epsilon
+	switch r.Mode {
+	case RoundUp:
+		steps = math.Ceil(steps)
+	case RoundDown:
+		steps = math.Floor(steps)
+	default:
+		steps = math.Round(steps)
+	}
+	// Re-round to the currency's minor unit to drop float noise from the
+	// multiplication.
+	scale := math.Pow10(minorUnits(c))
+	return math.Round(steps*inc*scale) / scale
+}
+
+// Convert converts amount from one currency to another using the rate from
+// provider, rounding the result with rounding.
+func Convert(ctx context.Context, provider RateProvider, amount float64, from, to Currency, rounding Rounding) (float64, ExchangeRate, error) {
+	if from == to {
+		return amount, ExchangeRate{From: from, To: to, Rate: 1}, nil
+	}
+	rate, err := provider.Rate(ctx, from, to)
+	if err != nil {
+		return 0, ExchangeRate{}, err
+	}
+	if rate.Rate <= 0 {
+		return 0, ExchangeRate{}, ErrExchangeRateUnavailable
+	}
+	return rounding.Apply(amount*rate.Rate, to), rate, nil
+}
+
+// PairedData holds USD and KHR codes generated for the same checkout.
+type PairedData struct {
+	USD  *Data
+	KHR  *Data
+	Rate ExchangeRate // rate used for the conversion; zero for static codes
+}
+
+// GenerateMerchantPair generates USD and KHR codes from one MerchantInfo.
+// info.Currency (default KHR) is the pricing currency; its amount is
+// converted to the other currency with provider. rounding applies when
+// converting into KHR; a converted USD amount is rounded to the cent with
+// rounding's Mode, so RielRounding is safe in both directions. Static
+// codes (Amount 0) need no rate and provider may be nil.
+func GenerateMerchantPair(
+	ctx context.Context,
+	info MerchantInfo, //nolint:gocritic // info is defaulted in place; a copy keeps the caller's untouched
+	provider RateProvider,
+	rounding Rounding,
+) (*PairedData, error) {
+	if info.Currency == 0 {
+		info.Currency = KHR
+	}
+	if err := validateCurrency(info.Currency); err != nil {
+		return nil, err
+	}
+	other := USD
+	if info.Currency == USD {
+		other = KHR
+	}
+
+	source, err := generateMerchant(&info)
+	if err != nil {
+		return nil, err
+	}
+
+	pair := &PairedData{}
+	converted := info
+	converted.Currency = other
+	if info.Amount > 0 {
+		if provider == nil {
+			return nil, ErrExchangeRateUnavailable
+		}
+		if other != KHR {
+			rounding = Rounding{Mode: rounding.Mode}
+		}
+		converted.Amount, pair.Rate, err = Convert(ctx, provider, info.Amount, info.Currency, other, rounding)
+		if err != nil {
+			return nil, err
+		}
+		if converted.Amount <= 0 {
+			return nil, fmt.Errorf("%w: %v %s converts to 0 %s", ErrInvalidAmount, info.Amount, info.Currency, other)
+		}
+	}
+	target, err := generateMerchant(&converted)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Currency == USD {
+		pair.USD, pair.KHR = source, target
+	} else {
+		pair.KHR, pair.USD = source, target
+	}
+	return pair, nil
+}
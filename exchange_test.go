@@ -0,0 +1,345 @@
+package khqr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testRates = StaticRates{{From: USD, To: KHR, Rate: 4100}}
+
+func TestStaticRates(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		from, to Currency
+		want     float64
+		wantErr  error
+	}{
+		{"direct", USD, KHR, 4100, nil},
+		{"inverse", KHR, USD, 1.0 / 4100, nil},
+		{"missing", USD, THB, 0, ErrExchangeRateUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := testRates.Rate(context.Background(), tt.from, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Rate(%v, %v) error = %v, want %v", tt.from, tt.to, err, tt.wantErr)
+			}
+			if got.Rate != tt.want {
+				t.Errorf("Rate(%v, %v) = %v, want %v", tt.from, tt.to, got.Rate, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundingApply(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		rounding Rounding
+		amount   float64
+		currency Currency
+		want     float64
+	}{
+		{"riel_half_up_down", RielRounding, 41049, KHR, 41000},
+		{"riel_half_up_up", RielRounding, 41050, KHR, 41100},
+		{"riel_up", Rounding{Mode: RoundUp, Increment: 100}, 41001, KHR, 41100},
+		{"riel_up_exact", Rounding{Mode: RoundUp, Increment: 100}, 41000, KHR, 41000},
+		{"riel_down", Rounding{Mode: RoundDown, Increment: 100}, 41099, KHR, 41000},
+		{"riel_default_increment", Rounding{}, 41049.6, KHR, 41050},
+		{"usd_cents", Rounding{}, 12.345, USD, 12.35},
+		{"usd_cents_down", Rounding{Mode: RoundDown}, 12.349, USD, 12.34},
+		{"usd_exact_up", Rounding{Mode: RoundUp}, 0.29, USD, 0.29},
+		{"usd_quarter", Rounding{Mode: RoundUp, Increment: 0.25}, 12.01, USD, 12.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := tt.rounding.Apply(tt.amount, tt.currency)
+			if got != tt.want {
+				t.Errorf("Apply(%v, %v) = %v, want %v", tt.amount, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+
+	got, rate, err := Convert(context.Background(), testRates, 12.34, USD, KHR, RielRounding)
+	if err != nil {
+		t.Fatalf("Convert() unexpected error: %v", err)
+	}
+	if got != 50600 { // 12.34 * 4100 = 50594
+		t.Errorf("Convert() = %v, want 50600", got)
+	}
+	if rate.Rate != 4100 {
+		t.Errorf("Convert() rate = %v, want 4100", rate.Rate)
+	}
+
+	got, _, err = Convert(context.Background(), testRates, 50600, KHR, USD, Rounding{})
+	if err != nil {
+		t.Fatalf("Convert() unexpected error: %v", err)
+	}
+	if got != 12.34 {
+		t.Errorf("Convert() inverse = %v, want 12.34", got)
+	}
+
+	if got, _, _ := Convert(context.Background(), nil, 5, USD, USD, Rounding{}); got != 5 {
+		t.Errorf("Convert() same currency = %v, want 5", got)
+	}
+}
+
+func TestFileRates(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rates.json")
+	provider := NewFileRates(path)
+
+	if _, err := provider.Rate(context.Background(), USD, KHR); !errors.Is(err, ErrExchangeRateUnavailable) {
+		t.Fatalf("Rate() with missing file error = %v, want %v", err, ErrExchangeRateUnavailable)
+	}
+
+	writeRates := func(body string, mod time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeRates(`[{"from":"USD","to":"KHR","rate":4100,"date":"2026-10-19T00:00:00Z"}]`, time.Unix(1000, 0))
+	got, err := provider.Rate(context.Background(), KHR, USD)
+	if err != nil {
+		t.Fatalf("Rate() unexpected error: %v", err)
+	}
+	if got.Rate != 1.0/4100 || got.Date.IsZero() {
+		t.Errorf("Rate() = %+v, want inverse of 4100 with date", got)
+	}
+
+	writeRates(`[{"from":840,"to":116,"rate":4050}]`, time.Unix(2000, 0))
+	got, err = provider.Rate(context.Background(), USD, KHR)
+	if err != nil {
+		t.Fatalf("Rate() after reload unexpected error: %v", err)
+	}
+	if got.Rate != 4050 {
+		t.Errorf("Rate() after reload = %v, want 4050", got.Rate)
+	}
+
+	writeRates(`not json`, time.Unix(3000, 0))
+	if _, err := provider.Rate(context.Background(), USD, KHR); !errors.Is(err, ErrExchangeRateUnavailable) {
+		t.Errorf("Rate() with bad file error = %v, want %v", err, ErrExchangeRateUnavailable)
+	}
+}
+
+func TestHTTPRates(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"from":"USD","to":"KHR","rate":4100}]`))
+	}))
+	t.Cleanup(srv.Close)
+
+	provider := &HTTPRates{URL: srv.URL, Client: srv.Client(), TTL: time.Hour}
+	for range 3 {
+		got, err := provider.Rate(context.Background(), USD, KHR)
+		if err != nil {
+			t.Fatalf("Rate() unexpected error: %v", err)
+		}
+		if got.Rate != 4100 {
+			t.Errorf("Rate() = %v, want 4100", got.Rate)
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("server hit %d times, want 1 (cached)", n)
+	}
+}
+
+func TestHTTPRatesError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+
+	provider := &HTTPRates{URL: srv.URL, Client: srv.Client()}
+	if _, err := provider.Rate(context.Background(), USD, KHR); !errors.Is(err, ErrExchangeRateUnavailable) {
+		t.Errorf("Rate() error = %v, want %v", err, ErrExchangeRateUnavailable)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := provider.Rate(ctx, USD, KHR); !errors.Is(err, context.Canceled) {
+		t.Errorf("Rate() with canceled context error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestGenerateMerchantPair(t *testing.T) {
+	t.Parallel()
+
+	info := MerchantInfo{
+		BakongAccountID:     "jonhsmith@devb",
+		MerchantName:        "Jonh Smith",
+		MerchantCity:        "Phnom Penh",
+		MerchantID:          "123456",
+		AcquiringBank:       "Dev Bank",
+		Currency:            USD,
+		Amount:              12.34,
+		ExpirationTimestamp: time.Now().Add(5 * time.Minute).UnixMilli(),
+		BillNumber:          "INV-001",
+	}
+
+	pair, err := GenerateMerchantPair(context.Background(), info, testRates, RielRounding)
+	if err != nil {
+		t.Fatalf("GenerateMerchantPair() unexpected error: %v", err)
+	}
+	if pair.Rate.Rate != 4100 {
+		t.Errorf("Rate = %v, want 4100", pair.Rate.Rate)
+	}
+
+	usd, err := Decode(pair.USD.QR)
+	if err != nil {
+		t.Fatalf("Decode(USD) unexpected error: %v", err)
+	}
+	khr, err := Decode(pair.KHR.QR)
+	if err != nil {
+		t.Fatalf("Decode(KHR) unexpected error: %v", err)
+	}
+	if usd.TransactionCurrency != "840" || usd.TransactionAmount != "12.34" {
+		t.Errorf("USD code currency/amount = %s/%s, want 840/12.34", usd.TransactionCurrency, usd.TransactionAmount)
+	}
+	if khr.TransactionCurrency != "116" || khr.TransactionAmount != "50600" {
+		t.Errorf("KHR code currency/amount = %s/%s, want 116/50600", khr.TransactionCurrency, khr.TransactionAmount)
+	}
+	if usd.BillNumber != khr.BillNumber {
+		t.Errorf("bill numbers differ: %q vs %q", usd.BillNumber, khr.BillNumber)
+	}
+	if info.MerchantCategoryCode != "" {
+		t.Error("GenerateMerchantPair() mutated caller's struct")
+	}
+}
+
+func TestGenerateMerchantPairFromKHR(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		amount   float64
+		rounding Rounding
+		wantUSD  string
+		wantErr  error
+	}{
+		{"riel_rounding", 50000, RielRounding, "12.2", nil}, // 12.195
+		{"large", 250000, RielRounding, "60.98", nil},       // 60.9756
+		{"round_down", 50000, Rounding{Mode: RoundDown, Increment: 100}, "12.19", nil},
+		{"smallest_cent", 100, RielRounding, "0.02", nil}, // 0.0244
+		{"converts_to_zero", 10, RielRounding, "", ErrInvalidAmount},
+		{"round_down_to_zero", 40, Rounding{Mode: RoundDown}, "", ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			pair, err := GenerateMerchantPair(context.Background(), MerchantInfo{
+				BakongAccountID:     "jonhsmith@devb",
+				MerchantName:        "Jonh Smith",
+				MerchantCity:        "Phnom Penh",
+				MerchantID:          "123456",
+				AcquiringBank:       "Dev Bank",
+				Currency:            KHR,
+				Amount:              tt.amount,
+				ExpirationTimestamp: time.Now().Add(5 * time.Minute).UnixMilli(),
+			}, testRates, tt.rounding)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GenerateMerchantPair() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			usd, err := Decode(pair.USD.QR)
+			if err != nil {
+				t.Fatalf("Decode(USD) unexpected error: %v", err)
+			}
+			if usd.PointOfInitiationMethod != dynamicQR || usd.TransactionAmount != tt.wantUSD {
+				t.Errorf("USD code POI/amount = %s/%s, want %s/%s", usd.PointOfInitiationMethod, usd.TransactionAmount, dynamicQR, tt.wantUSD)
+			}
+			if err := Verify(pair.USD.QR); err != nil {
+				t.Errorf("Verify(USD) = %v", err)
+			}
+		})
+	}
+}
+
+func TestGenerateMerchantPairStatic(t *testing.T) {
+	t.Parallel()
+
+	pair, err := GenerateMerchantPair(context.Background(), MerchantInfo{
+		BakongAccountID: "jonhsmith@devb",
+		MerchantName:    "Jonh Smith",
+		MerchantCity:    "Phnom Penh",
+		MerchantID:      "123456",
+		AcquiringBank:   "Dev Bank",
+	}, nil, RielRounding)
+	if err != nil {
+		t.Fatalf("GenerateMerchantPair() unexpected error: %v", err)
+	}
+	if err := Verify(pair.USD.QR); err != nil {
+		t.Errorf("Verify(USD) = %v", err)
+	}
+	if err := Verify(pair.KHR.QR); err != nil {
+		t.Errorf("Verify(KHR) = %v", err)
+	}
+}
+
+func TestGenerateMerchantPairErrors(t *testing.T) {
+	t.Parallel()
+
+	base := MerchantInfo{
+		BakongAccountID:     "jonhsmith@devb",
+		MerchantName:        "Jonh Smith",
+		MerchantCity:        "Phnom Penh",
+		MerchantID:          "123456",
+		AcquiringBank:       "Dev Bank",
+		Currency:            USD,
+		Amount:              10,
+		ExpirationTimestamp: time.Now().Add(5 * time.Minute).UnixMilli(),
+	}
+
+	tests := []struct {
+		name     string
+		mutate   func(*MerchantInfo)
+		provider RateProvider
+		wantErr  error
+	}{
+		{"nil_provider", func(*MerchantInfo) {}, nil, ErrExchangeRateUnavailable},
+		{"missing_rate", func(*MerchantInfo) {}, StaticRates{}, ErrExchangeRateUnavailable},
+		{"unsupported_currency", func(i *MerchantInfo) { i.Currency = THB }, testRates, ErrInvalidCurrency},
+		{"invalid_source", func(i *MerchantInfo) { i.MerchantName = "" }, testRates, ErrMerchantNameRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			info := base
+			tt.mutate(&info)
+			_, err := GenerateMerchantPair(context.Background(), info, tt.provider, RielRounding)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GenerateMerchantPair() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+package khqr
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldStatus is the validation result of one explained field.
+type FieldStatus string
+
+const (
+	FieldOK      FieldStatus = "ok"
+	FieldInvalid FieldStatus = "invalid"
+	FieldUnknown FieldStatus = "unknown" // not a tag the SDK reads
+)
+
+// ExplainedField is one tag or subtag of a payload.
+type ExplainedField struct {
+	Path     string           `json:"path"` // tag IDs from the root, e.g. "62.01"
+	ID       string           `json:"id"`
+	Name     string           `json:"name"`
+	Length   int              `json:"length"` // in characters, as encoded
+	Value    string           `json:"value"`
+	Meaning  string           `json:"meaning,omitempty"` // e.g. the currency or bank the value stands for
+	Status   FieldStatus      `json:"status"`
+	Error    string           `json:"error,omitempty"`
+	Children []ExplainedField `json:"children,omitempty"`
+}
+
+// Explanation is an annotated breakdown of a KHQR payload, returned by
+// Explain. It marshals to JSON as is; WriteText renders it as a table.
+type Explanation struct {
+	QR          string           `json:"qr"`
+	Fields      []ExplainedField `json:"fields"`
+	CRCExpected string           `json:"crc_expected"` // computed over the payload; empty without a trailing CRC tag
+	CRCActual   string           `json:"crc_actual"`
+	Valid       bool             `json:"valid"`
+	Error       string           `json:"error,omitempty"` // the error Verify reports
+}
+
+// tagSpec describes a tag the SDK reads: its name, how to read its value
+// and how to validate it in the context of the decoded payload.
+type tagSpec struct {
+	name      string
+	sub       map[string]tagSpec // subtags, for templates
+	interpret func(v string) string
+	check     func(v string, d *DecodedData) error
+}
+
+func optionalSpec(name string, maxLen int, errTooLong *Error) tagSpec {
+	return tagSpec{name: name, check: func(v string, _ *DecodedData) error {
+		return validateOptionalField(v, maxLen, errTooLong)
+	}}
+}
+
+var accountIDSpec = tagSpec{
+	name:      "Bakong Account ID",
+	interpret: interpretAccountID,
+	check:     func(v string, _ *DecodedData) error { return validateAccountID(v) },
+}
+
+// khqrTags are the tags the SDK reads, keyed by top-level ID.
+var khqrTags = map[string]tagSpec{
+	tagPayloadFormatIndicator: {
+		name:  "Payload Format Indicator",
+		check: func(v string, _ *DecodedData) error { return validatePayloadFormatIndicator(v) },
+	},
+	tagPointOfInitiation: {
+		name:      "Point of Initiation Method",
+		interpret: interpretPointOfInitiation,
+		check: func(v string, _ *DecodedData) error {
+			_, err := validatePointOfInitiationMethod(v)
+			return err
+		},
+	},
+	tagUnionPay: {
+		name: "UnionPay Account Information",
+		check: func(v string, d *DecodedData) error {
+			return validateUPIForDecode(v, d.TransactionCurrency, d.CountryCode)
+		},
+	},
+	tagIndividualAccount: {
+		name: "Individual Account Information",
+		sub: map[string]tagSpec{
+			subtagGlobalID:      accountIDSpec,
+			subtagAccountInfo:   optionalSpec("Account Information", maxAccountIDLength, ErrAccountInfoTooLong),
+			subtagAcquiringBank: optionalSpec("Acquiring Bank", maxAcquiringBankLength, ErrAcquiringBankTooLong),
+		},
+	},
+	tagMerchantAccount: {
+		name: "Merchant Account Information",
+		sub: map[string]tagSpec{
+			subtagGlobalID:      accountIDSpec,
+			subtagMerchantID:    {name: "Merchant ID", check: func(v string, _ *DecodedData) error { return validateMerchantID(v) }},
+			subtagAcquiringBank: optionalSpec("Acquiring Bank", maxAcquiringBankLength, ErrAcquiringBankTooLong),
+		},
+	},
+	tagMerchantCategoryCode: {
+		name:      "Merchant Category Code",
+		interpret: merchantCategoryDescription,
+		check:     func(v string, _ *DecodedData) error { return validateDecodedMerchantCategoryCode(v) },
+	},
+	tagCurrency: {
+		name:      "Transaction Currency",
+		interpret: interpretCurrency,
+		check:     func(v string, _ *DecodedData) error { return validateTransactionCurrency(v) },
+	},
+	tagAmount: {
+		name: "Transaction Amount",
+		check: func(v string, d *DecodedData) error {
+			amount := DecodedData{TransactionAmount: v, TransactionCurrency: d.TransactionCurrency}
+			return amount.validateTransactionAmount()
+		},
+	},
+	tagCountryCode: {
+		name:  "Country Code",
+		check: func(v string, _ *DecodedData) error { return validateCountryCode(v) },
+	},
+	tagMerchantName: {
+		name:  "Merchant Name",
+		check: func(v string, _ *DecodedData) error { return validateMerchantName(v) },
+	},
+	tagMerchantCity: {
+		name:  "Merchant City",
+		check: func(v string, _ *DecodedData) error { return validateMerchantCity(v) },
+	},
+	tagAdditionalData: {
+		name: "Additional Data Field Template",
+		sub: map[string]tagSpec{
+			subtagBillNumber:    optionalSpec("Bill Number", maxBillNumberLength, ErrBillNumberTooLong),
+			subtagMobileNumber:  optionalSpec("Mobile Number", maxMobileNumberLength, ErrMobileNumberTooLong),
+			subtagStoreLabel:    optionalSpec("Store Label", maxStoreLabelLength, ErrStoreLabelTooLong),
+			subtagTerminalLabel: optionalSpec("Terminal Label", maxTerminalLabelLength, ErrTerminalLabelTooLong),
+			subtagPurpose:       optionalSpec("Purpose of Transaction", maxPurposeLength, ErrPurposeTooLong),
+		},
+	},
+	tagCRC: {
+		name:  "CRC",
+		check: func(v string, _ *DecodedData) error { return validateCRC(v) },
+	},
+	tagLanguageTemplate: {
+		name: "Merchant Information - Language Template",
+		sub: map[string]tagSpec{
+			subtagLanguagePreference: {name: "Language Preference"},
+			subtagMerchantNameAlt:    optionalSpec("Merchant Name - Alternate Language", maxMerchantNameAltLength, ErrMerchantNameAltTooLong),
+			subtagMerchantCityAlt:    optionalSpec("Merchant City - Alternate Language", maxMerchantCityAltLength, ErrMerchantCityAltTooLong),
+		},
+		check: func(_ string, d *DecodedData) error {
+			return validateLanguageTemplate(d.AltLanguagePreference, d.AltMerchantName, d.AltMerchantCity)
+		},
+	},
+	tagTimestamp: {
+		name: "Timestamp Template",
+		sub: map[string]tagSpec{
+			subtagCreationTimestamp:   {name: "Creation Timestamp", interpret: interpretTimestamp, check: checkTimestamp},
+			subtagExpirationTimestamp: {name: "Expiration Timestamp", interpret: interpretTimestamp, check: checkExpiration},
+		},
+	},
+}
+
+// emvTagName names a top-level tag the SDK does not read, by the EMV
+// merchant-presented QR ranges.
+//
+//nolint:mnd // EMV tag ranges
+func emvTagName(tag string) (name string, template bool) {
+	n, err := strconv.Atoi(tag)
+	switch {
+	case err != nil:
+		return "Unknown", false
+	case n >= 2 && n <= 25:
+		return "Merchant Account Information (payment network)", false
+	case n >= 26 && n <= 51:
+		return "Merchant Account Information (template)", true
+	case n == 55:
+		return "Tip or Convenience Indicator", false
+	case n == 56:
+		return "Value of Convenience Fee Fixed", false
+	case n == 57:
+		return "Value of Convenience Fee Percentage", false
+	case n == 61:
+		return "Postal Code", false
+	case n >= 65 && n <= 79:
+		return "Reserved for Future Use", false
+	case n >= 80:
+		return "Unreserved Template", true
+	}
+	return "Unknown", false
+}
+
+// emvAdditionalDataNames names the Additional Data subtags the SDK does not
+// read.
+var emvAdditionalDataNames = map[string]string{
+	"04": "Loyalty Number",
+	"05": "Reference Label",
+	"06": "Customer Label",
+	"09": "Additional Consumer Data Request",
+	"10": "Merchant Tax ID",
+	"11": "Merchant Channel",
+}
+
+// tlvNode is one entry of a parsed TLV tree.
+type tlvNode struct {
+	path, tag, name, value string
+	length                 int
+	spec                   *tagSpec // nil for tags the SDK does not read
+	children               []tlvNode
+}
+
+// parseTLVTree parses s leniently into a tree, descending into known and
+// EMV-defined templates. Anything after the last well-formed entry is
+// returned as rest.
+func parseTLVTree(s, parent string, specs map[string]tagSpec) (nodes []tlvNode, rest string) {
+	runes := []rune(s)
+	pos := 0
+	for pos < len(runes) {
+		if pos+4 > len(runes) {
+			break
+		}
+		length, err := strconv.Atoi(string(runes[pos+2 : pos+4]))
+		if err != nil || length < 0 || pos+4+length > len(runes) {
+			break
+		}
+		n := tlvNode{tag: string(runes[pos : pos+2]), value: string(runes[pos+4 : pos+4+length]), length: length}
+		pos += 4 + length
+
+		n.path = n.tag
+		if parent != "" {
+			n.path = parent + "." + n.tag
+		}
+		template := false
+		if spec, ok := specs[n.tag]; ok {
+			n.spec = &spec
+			n.name = spec.name
+			template = spec.sub != nil
+		} else {
+			n.name, template = unknownTagName(parent, n.tag)
+		}
+		if template {
+			var subs map[string]tagSpec
+			if n.spec != nil {
+				subs = n.spec.sub
+			}
+			if children, tail := parseTLVTree(n.value, n.path, subs); tail == "" {
+				n.children = children
+			}
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, string(runes[pos:])
+}
+
+func unknownTagName(parent, tag string) (string, bool) {
+	switch {
+	case parent == "":
+		return emvTagName(tag)
+	case parent == tagAdditionalData && emvAdditionalDataNames[tag] != "":
+		return emvAdditionalDataNames[tag], false
+	}
+	return "Unknown", false
+}
+
+// Explain breaks a payload down into every tag and subtag with its name,
+// length, value, meaning and validation status, including tags the SDK does
+// not read. It never fails: malformed input is reported in the result.
+func Explain(qr string) *Explanation {
+	qr = strings.TrimSpace(qr)
+	e := &Explanation{QR: qr, Valid: true}
+	if err := verify(qr); err != nil {
+		e.Valid, e.Error = false, err.Error()
+	}
+	d, err := decode(qr)
+	if err != nil {
+		d = &DecodedData{}
+	}
+
+	if n := len(qr); n >= 8 && qr[n-8:n-4] == tagCRC+"04" { //nolint:mnd // CRC tag, length and value
+		e.CRCExpected = crc16Hex(qr[:n-4])
+		e.CRCActual = qr[n-4:]
+	}
+
+	nodes, rest := parseTLVTree(qr, "", khqrTags)
+	e.Fields = explainNodes(nodes, d, e.CRCExpected)
+	if rest != "" {
+		e.Fields = append(e.Fields, ExplainedField{
+			Name:   "Malformed data",
+			Length: utf8.RuneCountInString(rest),
+			Value:  rest,
+			Status: FieldInvalid,
+			Error:  ErrInvalidQR.Error(),
+		})
+	}
+	return e
+}
+
+func explainNodes(nodes []tlvNode, d *DecodedData, crcExpected string) []ExplainedField {
+	fields := make([]ExplainedField, 0, len(nodes))
+	seen := map[string]bool{}
+	for _, n := range nodes {
+		f := ExplainedField{Path: n.path, ID: n.tag, Name: n.name, Length: n.length, Value: n.value, Status: FieldOK}
+		var err error
+		switch {
+		case seen[n.tag]:
+			err = fmt.Errorf("duplicate tag %s", n.tag)
+		case n.spec == nil:
+			f.Status = FieldUnknown
+		default:
+			if n.spec.interpret != nil {
+				f.Meaning = n.spec.interpret(n.value)
+			}
+			if n.spec.check != nil {
+				err = n.spec.check(n.value, d)
+			}
+			if n.path == tagCRC && err == nil && !strings.EqualFold(n.value, crcExpected) {
+				err = ErrCRCInvalid
+			}
+		}
+		seen[n.tag] = true
+		if err != nil {
+			f.Status, f.Error = FieldInvalid, err.Error()
+		}
+		f.Children = explainNodes(n.children, d, crcExpected)
+		if len(f.Children) == 0 {
+			f.Children = nil
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// WriteText writes the explanation as an indented table.
+func (e *Explanation) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:mnd // column padding
+	fmt.Fprintln(tw, "ID\tNAME\tLEN\tVALUE\tMEANING\tSTATUS")
+	var walk func(fields []ExplainedField, indent string)
+	walk = func(fields []ExplainedField, indent string) {
+		for i := range fields {
+			f := &fields[i]
+			value := strconv.Quote(f.Value)
+			if f.Children != nil {
+				value = ""
+			}
+			status := string(f.Status)
+			if f.Error != "" {
+				status += ": " + f.Error
+			}
+			fmt.Fprintf(tw, "%s%s\t%s\t%d\t%s\t%s\t%s\n", indent, f.ID, f.Name, f.Length, value, f.Meaning, status)
+			walk(f.Children, indent+"  ")
+		}
+	}
+	walk(e.Fields, "")
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if e.CRCExpected != "" {
+		if _, err := fmt.Fprintf(w, "\nCRC: expected %s, actual %s\n", e.CRCExpected, e.CRCActual); err != nil {
+			return err
+		}
+	}
+	result := "valid"
+	if !e.Valid {
+		result = "invalid: " + e.Error
+	}
+	_, err := fmt.Fprintf(w, "Result: %s\n", result)
+	return err
+}
+
+// String returns the text form written by WriteText.
+func (e *Explanation) String() string {
+	var b strings.Builder
+	_ = e.WriteText(&b)
+	return b.String()
+}
+
+func interpretPointOfInitiation(v string) string {
+	switch v {
+	case staticQR:
+		return "static"
+	case dynamicQR:
+		return "dynamic"
+	}
+	return ""
+}
+
+func interpretAccountID(v string) string {
+	id, err := ParseBakongAccountID(v)
+	if err != nil {
+		return ""
+	}
+	if bank, ok := id.Bank(); ok {
+		return bank.Name
+	}
+	return ""
+}
+
+func interpretCurrency(v string) string {
+	c, err := parseTransactionCurrency(v)
+	if err != nil {
+		return ""
+	}
+	info, _ := LookupCurrency(c)
+	return info.Code + " (" + info.Name + ")"
+}
+
+func interpretTimestamp(v string) string {
+	ms, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return ""
+	}
+	return time.UnixMilli(ms).UTC().Format(time.RFC3339)
+}
+
+func checkTimestamp(v string, _ *DecodedData) error {
+	if len(v) != 13 { //nolint:mnd // timestamp string length
+		return ErrInvalidTimestamp
+	}
+	if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+		return ErrInvalidTimestamp
+	}
+	return nil
+}
+
+func checkExpiration(v string, d *DecodedData) error {
+	if err := checkTimestamp(v, d); err != nil {
+		return err
+	}
+	ts, _ := strconv.ParseInt(v, 10, 64)
+	if d.PointOfInitiationMethod == dynamicQR && time.Now().UnixMilli() > ts {
+		return ErrKHQRExpired
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package khqr
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// withCRC appends a CRC tag computed over payload.
+func withCRC(payload string) string {
+	payload += tagCRC + "04"
+	return payload + crc16Hex(payload)
+}
+
+// findField returns the field at path, or nil.
+func findField(fields []ExplainedField, path string) *ExplainedField {
+	for i := range fields {
+		if fields[i].Path == path {
+			return &fields[i]
+		}
+		if f := findField(fields[i].Children, path); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+
+	const base = "000201010211" + "29190015john_smith@devb" + "52045999" + "5303116" + "5802KH" + "5910jonh smith" + "6010Phnom Penh"
+	type want struct {
+		status  FieldStatus
+		name    string
+		meaning string
+		err     string
+	}
+	tests := []struct {
+		name   string
+		qr     string
+		valid  bool
+		fields map[string]want
+	}{
+		{
+			"valid",
+			withCRC(base + "62070103INV"),
+			true,
+			map[string]want{
+				"01":    {FieldOK, "Point of Initiation Method", "static", ""},
+				"29":    {FieldOK, "Individual Account Information", "", ""},
+				"29.00": {FieldOK, "Bakong Account ID", "", ""},
+				"53":    {FieldOK, "Transaction Currency", "KHR (Cambodian Riel)", ""},
+				"62.01": {FieldOK, "Bill Number", "", ""},
+				"63":    {FieldOK, "CRC", "", ""},
+			},
+		},
+		{
+			"unknown_tags",
+			withCRC(base + "62090505REF01" + "8506000201" + "6105" + "12000"),
+			true,
+			map[string]want{
+				"62.05": {FieldUnknown, "Reference Label", "", ""},
+				"85":    {FieldUnknown, "Unreserved Template", "", ""},
+				"85.00": {FieldUnknown, "Unknown", "", ""},
+				"61":    {FieldUnknown, "Postal Code", "", ""},
+			},
+		},
+		{
+			"bad_crc",
+			base + "630400000",
+			false,
+			map[string]want{"63": {FieldInvalid, "CRC", "", ErrCRCInvalid.Error()}},
+		},
+		{
+			"alphabetic_currency",
+			withCRC(strings.Replace(base, "5303116", "5303USD", 1)),
+			false,
+			map[string]want{"53": {FieldInvalid, "Transaction Currency", "", ErrInvalidCurrency.Error()}},
+		},
+		{
+			"field_too_long",
+			withCRC(strings.Replace(base, "5910jonh smith", "5926abcdefghijklmnopqrstuvwxyz", 1)),
+			false,
+			map[string]want{"59": {FieldInvalid, "Merchant Name", "", ErrMerchantNameTooLong.Error()}},
+		},
+		{
+			"expired",
+			withCRC(strings.Replace(base, "010211", "010212", 1) + "54031.0" + "99340013161302797275701131613027972757"),
+			false,
+			map[string]want{
+				"99.01": {FieldInvalid, "Expiration Timestamp", "2021-02-11T07:19:32Z", ErrKHQRExpired.Error()},
+			},
+		},
+	}
+	for _, tt := range tests {
+		e := Explain(tt.qr)
+		if e.Valid != tt.valid {
+			t.Errorf("%s: Valid = %v (%s), want %v", tt.name, e.Valid, e.Error, tt.valid)
+		}
+		for path, w := range tt.fields {
+			f := findField(e.Fields, path)
+			if f == nil {
+				t.Errorf("%s: no field %s", tt.name, path)
+				continue
+			}
+			if f.Status != w.status || f.Name != w.name || f.Meaning != w.meaning || f.Error != w.err {
+				t.Errorf("%s: field %s = %+v, want %+v", tt.name, path, *f, w)
+			}
+		}
+	}
+}
+
+func TestExplainCRCAndMalformed(t *testing.T) {
+	t.Parallel()
+
+	e := Explain("00020101021229190015john_smith@devb6304ABCD")
+	if e.CRCActual != "ABCD" || e.CRCExpected != crc16Hex("00020101021229190015john_smith@devb6304") {
+		t.Errorf("CRC expected %q actual %q", e.CRCExpected, e.CRCActual)
+	}
+
+	e = Explain("00020101021")
+	last := e.Fields[len(e.Fields)-1]
+	if e.Valid || last.Name != "Malformed data" || last.Value != "01021" || last.Status != FieldInvalid {
+		t.Errorf("malformed tail = %+v", last)
+	}
+
+	e = Explain(withCRC("000201" + "5802KH" + "5802US"))
+	var dup []ExplainedField
+	for _, f := range e.Fields {
+		if f.ID == "58" {
+			dup = append(dup, f)
+		}
+	}
+	if len(dup) != 2 || dup[0].Status != FieldOK || dup[1].Status != FieldInvalid {
+		t.Errorf("duplicate tag fields = %+v", dup)
+	}
+}
+
+func TestExplainOutput(t *testing.T) {
+	t.Parallel()
+
+	e := Explain(withCRC("000201010211" + "29190015john_smith@devb" + "5802KH"))
+
+	text := e.String()
+	for _, want := range []string{
+		"ID  ", "29    Individual Account Information", "  00  Bakong Account ID", `"john_smith@devb"`,
+		"CRC: expected " + e.CRCExpected, "Result: invalid: ",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text missing %q:\n%s", want, text)
+		}
+	}
+
+	raw, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	var got Explanation
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("json.Unmarshal() unexpected error: %v", err)
+	}
+	if f := findField(got.Fields, "29.00"); f == nil || f.Value != "john_smith@devb" {
+		t.Errorf("JSON round trip lost 29.00: %s", raw)
+	}
+}
@@ -18,12 +18,14 @@ func formatCurrency(c Currency) string {
 }
 
 // formatAmount formats a transaction amount according to currency rules.
+// Amounts are written with the currency's minor units, then trailing zeros
+// are stripped.
 func formatAmount(amount float64, currency Currency) string {
-	if currency == KHR {
-		return strconv.FormatFloat(amount, 'f', 0, 64)
+	units := minorUnits(currency)
+	s := strconv.FormatFloat(amount, 'f', units, 64)
+	if units == 0 {
+		return s
 	}
-	// USD: format with 2 decimals, then strip trailing zeros
-	s := strconv.FormatFloat(amount, 'f', 2, 64)
 	s = strings.TrimRight(s, "0")
 	s = strings.TrimRight(s, ".")
 	return s
@@ -47,6 +47,9 @@ func TestFormatAmount(t *testing.T) {
 		{"USD_half_cent", 25.50, USD, "25.5"},
 		{"USD_zero_decimals", 100.00, USD, "100"},
 		{"USD_large", 9999999999.99, USD, "9999999999.99"},
+		{"THB_two_decimals", 12.50, THB, "12.5"},
+		{"VND_whole", 25000, VND, "25000"},
+		{"unregistered_defaults_two_decimals", 1.239, Currency(999), "1.24"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -31,65 +31,94 @@ type qrParams struct {
 }
 
 // generate builds a KHQR payload from type-agnostic parameters.
-// Tags are written in ascending order per the EMV QR Code specification.
-// Tags (00, 01, 15, 29/30, 52, 53, 54, 58, 59, 60, 62, 64, 99).
 func generate(p *qrParams) *Data {
-	isDynamic := p.amount > 0
-	var b strings.Builder
+	return newQRTemplate(p).render(p.amount, p.billNumber, p.expiration)
+}
 
-	// Payload Format Indicator (tag 00)
-	b.WriteString(encodeTLV(tagPayloadFormatIndicator, defaultPayloadFormatIndicator))
+// qrTemplate holds the pre-encoded segments of a payload that do not
+// depend on the amount, bill number or expiration.
+type qrTemplate struct {
+	currency Currency
+	account  string // tags 15, 29/30, 52, 53
+	merchant string // tags 58, 59, 60
+	addlTail string // tag 62 subtags after the bill number
+	language string // tag 64
+}
 
-	// Point of Initiation (tag 01)
-	poi := staticQR
-	if isDynamic {
-		poi = dynamicQR
-	}
-	b.WriteString(encodeTLV(tagPointOfInitiation, poi))
+func newQRTemplate(p *qrParams) *qrTemplate {
+	t := &qrTemplate{currency: p.currency}
 
+	var b strings.Builder
 	// UnionPay (tag 15)
 	if p.upiAccountInfo != "" {
 		b.WriteString(encodeTLV(tagUnionPay, p.upiAccountInfo))
 	}
-
 	// Individual/Merchant Account (tag 29/30)
 	b.WriteString(encodeTLV(p.accountTag, p.accountValue))
-
 	// Merchant Category Code (tag 52)
 	b.WriteString(encodeTLV(tagMerchantCategoryCode, p.categoryCode))
-
 	// Transaction Currency (tag 53)
 	b.WriteString(encodeTLV(tagCurrency, formatCurrency(p.currency)))
+	t.account = b.String()
 
-	// Transaction Amount (tag 54)
+	// Country Code (tag 58), Merchant Name (tag 59), Merchant City (tag 60)
+	t.merchant = encodeTLV(tagCountryCode, defaultCountryCode) +
+		encodeTLV(tagMerchantName, p.merchantName) +
+		encodeTLV(tagMerchantCity, p.merchantCity)
+
+	t.addlTail = buildAdditionalData("", p.mobileNumber, p.storeLabel, p.terminalLabel, p.purpose)
+	t.language = buildLanguageTemplate(p.altLanguagePreference, p.altMerchantName, p.altMerchantCity)
+	if t.language != "" {
+		t.language = encodeTLV(tagLanguageTemplate, t.language)
+	}
+	return t
+}
+
+// render builds the payload. Tags are written in ascending order per the
+// EMV QR Code specification.
+// Tags (00, 01, 15, 29/30, 52, 53, 54, 58, 59, 60, 62, 64, 99).
+func (t *qrTemplate) render(amount float64, billNumber string, expiration int64) *Data {
+	isDynamic := amount > 0
+	var b strings.Builder
+	b.Grow(len(t.account) + len(t.merchant) + len(t.addlTail) + len(t.language) + 128) //nolint:mnd // room for the variable tags
+
+	// Payload Format Indicator (tag 00)
+	b.WriteString(encodeTLV(tagPayloadFormatIndicator, defaultPayloadFormatIndicator))
+
+	// Point of Initiation (tag 01)
+	poi := staticQR
 	if isDynamic {
-		b.WriteString(encodeTLV(tagAmount, formatAmount(p.amount, p.currency)))
+		poi = dynamicQR
 	}
+	b.WriteString(encodeTLV(tagPointOfInitiation, poi))
 
-	// Country Code (tag 58)
-	b.WriteString(encodeTLV(tagCountryCode, defaultCountryCode))
+	// Tags 15 to 53
+	b.WriteString(t.account)
 
-	// Merchant Name (tag 59)
-	b.WriteString(encodeTLV(tagMerchantName, p.merchantName))
+	// Transaction Amount (tag 54)
+	if isDynamic {
+		b.WriteString(encodeTLV(tagAmount, formatAmount(amount, t.currency)))
+	}
 
-	// Merchant City (tag 60)
-	b.WriteString(encodeTLV(tagMerchantCity, p.merchantCity))
+	// Tags 58 to 60
+	b.WriteString(t.merchant)
 
 	// Additional Data (tag 62)
-	if ad := buildAdditionalData(p.billNumber, p.mobileNumber, p.storeLabel, p.terminalLabel, p.purpose); ad != "" {
-		b.WriteString(encodeTLV(tagAdditionalData, ad))
+	var ad tlvWriter
+	ad.writeTLV(subtagBillNumber, billNumber)
+	ad.WriteString(t.addlTail)
+	if ad.Len() > 0 {
+		b.WriteString(encodeTLV(tagAdditionalData, ad.String()))
 	}
 
 	// Language Template (tag 64)
-	if lt := buildLanguageTemplate(p.altLanguagePreference, p.altMerchantName, p.altMerchantCity); lt != "" {
-		b.WriteString(encodeTLV(tagLanguageTemplate, lt))
-	}
+	b.WriteString(t.language)
 
 	// Timestamp (tag 99) — dynamic only
 	if isDynamic {
 		var ts strings.Builder
 		ts.WriteString(encodeTLV(subtagCreationTimestamp, formatTimestamp(time.Now().UnixMilli())))
-		ts.WriteString(encodeTLV(subtagExpirationTimestamp, formatTimestamp(p.expiration)))
+		ts.WriteString(encodeTLV(subtagExpirationTimestamp, formatTimestamp(expiration)))
 		b.WriteString(encodeTLV(tagTimestamp, ts.String()))
 	}
 
@@ -103,6 +132,20 @@ func generate(p *qrParams) *Data {
 
 // generateIndividual builds a KHQR payload for an individual payment.
 func generateIndividual(info *IndividualInfo) (*Data, error) {
+	info.applyDefaults()
+	if err := info.validate(); err != nil {
+		return nil, err
+	}
+	return generate(info.qrParams()), nil
+}
+
+// applyDefaults fills in the optional fields that have defaults,
+// normalizes the alternate language text and romanizes a Khmer
+// AltMerchantName when MerchantName is empty.
+func (info *IndividualInfo) applyDefaults() {
+	info.AltMerchantName = NormalizeText(info.AltMerchantName)
+	info.AltMerchantCity = NormalizeText(info.AltMerchantCity)
+	info.MerchantName = romanizedMerchantName(info.MerchantName, info.AltLanguagePreference, info.AltMerchantName)
 	if info.Currency == 0 {
 		info.Currency = KHR
 	}
@@ -112,18 +155,17 @@ func generateIndividual(info *IndividualInfo) (*Data, error) {
 	if info.MerchantCity == "" {
 		info.MerchantCity = defaultMerchantCity
 	}
+}
 
-	if err := info.validate(); err != nil {
-		return nil, err
-	}
-
+// qrParams maps validated, defaulted individual info to generation inputs.
+func (info *IndividualInfo) qrParams() *qrParams {
 	// Build individual account subtags (tag 29)
 	var acc tlvWriter
 	acc.WriteString(encodeTLV(subtagGlobalID, info.BakongAccountID))
 	acc.writeTLV(subtagAccountInfo, info.AccountInfo)
 	acc.writeTLV(subtagAcquiringBank, info.AcquiringBank)
 
-	return generate(&qrParams{
+	return &qrParams{
 		accountTag:            tagIndividualAccount,
 		accountValue:          acc.String(),
 		merchantName:          info.MerchantName,
@@ -141,29 +183,42 @@ func generateIndividual(info *IndividualInfo) (*Data, error) {
 		altLanguagePreference: info.AltLanguagePreference,
 		altMerchantName:       info.AltMerchantName,
 		altMerchantCity:       info.AltMerchantCity,
-	}), nil
+	}
 }
 
 // generateMerchant builds a KHQR payload for a merchant payment.
 func generateMerchant(info *MerchantInfo) (*Data, error) {
+	info.applyDefaults()
+	if err := info.validate(); err != nil {
+		return nil, err
+	}
+	return generate(info.qrParams()), nil
+}
+
+// applyDefaults fills in the optional fields that have defaults,
+// normalizes the alternate language text and romanizes a Khmer
+// AltMerchantName when MerchantName is empty.
+func (info *MerchantInfo) applyDefaults() {
+	info.AltMerchantName = NormalizeText(info.AltMerchantName)
+	info.AltMerchantCity = NormalizeText(info.AltMerchantCity)
+	info.MerchantName = romanizedMerchantName(info.MerchantName, info.AltLanguagePreference, info.AltMerchantName)
 	if info.Currency == 0 {
 		info.Currency = KHR
 	}
 	if info.MerchantCategoryCode == "" {
 		info.MerchantCategoryCode = defaultMerchantCategoryCode
 	}
+}
 
-	if err := info.validate(); err != nil {
-		return nil, err
-	}
-
+// qrParams maps validated, defaulted merchant info to generation inputs.
+func (info *MerchantInfo) qrParams() *qrParams {
 	// Build merchant account subtags (tag 30)
 	var acc strings.Builder
 	acc.WriteString(encodeTLV(subtagGlobalID, info.BakongAccountID))
 	acc.WriteString(encodeTLV(subtagMerchantID, info.MerchantID))
 	acc.WriteString(encodeTLV(subtagAcquiringBank, info.AcquiringBank))
 
-	return generate(&qrParams{
+	return &qrParams{
 		accountTag:            tagMerchantAccount,
 		accountValue:          acc.String(),
 		merchantName:          info.MerchantName,
@@ -181,7 +236,7 @@ func generateMerchant(info *MerchantInfo) (*Data, error) {
 		altLanguagePreference: info.AltLanguagePreference,
 		altMerchantName:       info.AltMerchantName,
 		altMerchantCity:       info.AltMerchantCity,
-	}), nil
+	}
 }
 
 // buildAdditionalData constructs the additional data field (tag 62) content.
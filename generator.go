@@ -0,0 +1,182 @@
+package khqr
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// issuerKey is the context key for the issuer recorded with each code.
+type issuerKey struct{}
+
+// ContextWithIssuer returns a context carrying the identity (user, cashier,
+// service) that Generator records as the issuer of a code.
+func ContextWithIssuer(ctx context.Context, issuer string) context.Context {
+	return context.WithValue(ctx, issuerKey{}, issuer)
+}
+
+// IssuerFromContext returns the issuer set by ContextWithIssuer.
+func IssuerFromContext(ctx context.Context) string {
+	issuer, _ := ctx.Value(issuerKey{}).(string)
+	return issuer
+}
+
+// GeneratorOption configures a Generator.
+type GeneratorOption func(*Generator)
+
+// WithStore records every code the Generator issues in s.
+func WithStore(s Store) GeneratorOption {
+	return func(g *Generator) { g.store = s }
+}
+
+// Generator generates KHQR codes like GenerateIndividual and
+// GenerateMerchant, adding the hooks configured by its options.
+type Generator struct {
+	store       Store
+	idempotency IdempotencyStore
+	accounts    AccountChecker
+	signingKey  *SigningKey
+
+	mu sync.Mutex // serializes idempotent issuance
+}
+
+// NewGenerator returns a Generator with the given options.
+func NewGenerator(opts ...GeneratorOption) *Generator {
+	g := &Generator{}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// GenerateIndividual generates an individual KHQR code and records it.
+// If recording fails the error is returned and the code must not be used.
+// See WithIdempotency for how retries are deduplicated.
+//
+//nolint:gocritic // info is recorded as given; the copy keeps later caller edits out of the Record
+func (g *Generator) GenerateIndividual(ctx context.Context, info IndividualInfo) (*Data, error) {
+	data, err := generateIndividual(&info)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.checkAccount(ctx, info.BakongAccountID); err != nil {
+		return nil, err
+	}
+	if data, err = g.sign(data); err != nil {
+		return nil, err
+	}
+	r := newRecord(ctx, data, info.Amount, info.ExpirationTimestamp)
+	r.Individual = &info
+	key := IdempotencyKeyFromContext(ctx)
+	if key == "" {
+		key = billIdempotencyKey(info.BakongAccountID, "", info.BillNumber)
+	}
+	fp := info
+	return g.issue(ctx, key, fingerprint(&fp, nil), r)
+}
+
+// GenerateMerchant generates a merchant KHQR code and records it.
+// If recording fails the error is returned and the code must not be used.
+// See WithIdempotency for how retries are deduplicated.
+//
+//nolint:gocritic // info is recorded as given; the copy keeps later caller edits out of the Record
+func (g *Generator) GenerateMerchant(ctx context.Context, info MerchantInfo) (*Data, error) {
+	data, err := generateMerchant(&info)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.checkAccount(ctx, info.BakongAccountID); err != nil {
+		return nil, err
+	}
+	if data, err = g.sign(data); err != nil {
+		return nil, err
+	}
+	r := newRecord(ctx, data, info.Amount, info.ExpirationTimestamp)
+	r.Merchant = &info
+	key := IdempotencyKeyFromContext(ctx)
+	if key == "" {
+		key = billIdempotencyKey(info.BakongAccountID, info.MerchantID, info.BillNumber)
+	}
+	fp := info
+	return g.issue(ctx, key, fingerprint(nil, &fp), r)
+}
+
+// issue records r and returns its code, or returns the code already issued
+// for key when idempotency is enabled.
+func (g *Generator) issue(ctx context.Context, key, fp string, r *Record) (*Data, error) {
+	if g.idempotency == nil || key == "" {
+		if err := g.record(ctx, r); err != nil {
+			return nil, err
+		}
+		return &Data{QR: r.QR}, nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	prev, ok, err := g.idempotency.Load(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if ok && !prev.expired(time.Now()) {
+		if prev.Fingerprint != fp {
+			return nil, ErrIdempotencyConflict
+		}
+		return &Data{QR: prev.QR}, nil
+	}
+	// The key is claimed before the code is recorded, so a failed claim
+	// leaves no record behind for a retry to duplicate.
+	entry := IdempotencyEntry{Fingerprint: fp, QR: r.QR, ExpiresAt: r.ExpiresAt}
+	if err := g.idempotency.Store(ctx, key, entry); err != nil {
+		return nil, err
+	}
+	if err := g.record(ctx, r); err != nil {
+		// Release the key so a retry issues and records a fresh code.
+		released := IdempotencyEntry{Fingerprint: fp, ExpiresAt: time.Now()}
+		return nil, errors.Join(err, g.idempotency.Store(ctx, key, released))
+	}
+	return &Data{QR: r.QR}, nil
+}
+
+func (g *Generator) checkAccount(ctx context.Context, accountID string) error {
+	if g.accounts == nil {
+		return nil
+	}
+	return g.accounts.CheckAccount(ctx, accountID)
+}
+
+func (g *Generator) sign(data *Data) (*Data, error) {
+	if g.signingKey == nil {
+		return data, nil
+	}
+	qr, err := Sign(data.QR, *g.signingKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Data{QR: qr}, nil
+}
+
+func (g *Generator) record(ctx context.Context, r *Record) error {
+	if g.store == nil {
+		return nil
+	}
+	return g.store.Save(ctx, r)
+}
+
+// newRecord builds the audit record for a freshly generated code.
+func newRecord(ctx context.Context, data *Data, amount float64, expiration int64) *Record {
+	now := time.Now()
+	r := &Record{
+		MD5:       data.MD5(),
+		QR:        data.QR,
+		Issuer:    IssuerFromContext(ctx),
+		Status:    StatusIssued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if amount > 0 {
+		r.ExpiresAt = time.UnixMilli(expiration)
+	}
+	return r
+}
@@ -0,0 +1,116 @@
+package khqr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// failingStore is a Store whose Save always fails.
+type failingStore struct{ MemoryStore }
+
+var errStoreDown = errors.New("store down")
+
+func (*failingStore) Save(context.Context, *Record) error { return errStoreDown }
+
+func TestGeneratorRecordsIndividual(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	g := NewGenerator(WithStore(store))
+	ctx := ContextWithIssuer(context.Background(), "cashier-1")
+
+	expires := time.Now().Add(5 * time.Minute).UnixMilli()
+	data, err := g.GenerateIndividual(ctx, IndividualInfo{
+		BakongAccountID:     "jonhsmith@nbcq",
+		MerchantName:        "Jonh Smith",
+		Currency:            USD,
+		Amount:              2.5,
+		BillNumber:          "INV-001",
+		ExpirationTimestamp: expires,
+	})
+	if err != nil {
+		t.Fatalf("GenerateIndividual() unexpected error: %v", err)
+	}
+
+	r, err := store.Get(ctx, data.MD5())
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if r.QR != data.QR || r.Issuer != "cashier-1" || r.Status != StatusIssued {
+		t.Errorf("record = %+v, want QR, issuer cashier-1, status issued", r)
+	}
+	if r.BillNumber() != "INV-001" {
+		t.Errorf("BillNumber() = %q, want %q", r.BillNumber(), "INV-001")
+	}
+	if amount, currency := r.Amount(); amount != 2.5 || currency != USD {
+		t.Errorf("Amount() = %v %v, want 2.5 USD", amount, currency)
+	}
+	if r.ExpiresAt.UnixMilli() != expires {
+		t.Errorf("ExpiresAt = %v, want %v", r.ExpiresAt.UnixMilli(), expires)
+	}
+	if r.Individual.MerchantCity != defaultMerchantCity {
+		t.Errorf("recorded inputs missing defaults: MerchantCity = %q", r.Individual.MerchantCity)
+	}
+}
+
+func TestGeneratorRecordsStaticMerchant(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	g := NewGenerator(WithStore(store))
+
+	data, err := g.GenerateMerchant(context.Background(), MerchantInfo{
+		BakongAccountID:     "jonhsmith@devb",
+		MerchantName:        "Jonh Smith",
+		MerchantCity:        "Phnom Penh",
+		MerchantID:          "123456",
+		AcquiringBank:       "Dev Bank",
+		ExpirationTimestamp: time.Now().Add(time.Minute).UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("GenerateMerchant() unexpected error: %v", err)
+	}
+	r, err := store.Get(context.Background(), data.MD5())
+	if err != nil {
+		t.Fatalf("Get() unexpected error: %v", err)
+	}
+	if r.Merchant == nil || r.Individual != nil {
+		t.Errorf("record inputs = %+v / %+v, want merchant only", r.Merchant, r.Individual)
+	}
+	if !r.ExpiresAt.IsZero() {
+		t.Errorf("static code ExpiresAt = %v, want zero", r.ExpiresAt)
+	}
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	g := NewGenerator(WithStore(store))
+	if _, err := g.GenerateIndividual(context.Background(), IndividualInfo{MerchantName: "x"}); !errors.Is(err, ErrAccountIDRequired) {
+		t.Errorf("GenerateIndividual() error = %v, want %v", err, ErrAccountIDRequired)
+	}
+	if got, _ := store.Find(context.Background(), Query{}); len(got) != 0 {
+		t.Errorf("invalid input recorded %d codes, want 0", len(got))
+	}
+
+	g = NewGenerator(WithStore(&failingStore{}))
+	_, err := g.GenerateIndividual(context.Background(), IndividualInfo{BakongAccountID: "a@b", MerchantName: "x"})
+	if !errors.Is(err, errStoreDown) {
+		t.Errorf("GenerateIndividual() with failing store error = %v, want %v", err, errStoreDown)
+	}
+}
+
+func TestGeneratorWithoutStore(t *testing.T) {
+	t.Parallel()
+
+	data, err := NewGenerator().GenerateIndividual(context.Background(), IndividualInfo{BakongAccountID: "a@b", MerchantName: "x"})
+	if err != nil {
+		t.Fatalf("GenerateIndividual() unexpected error: %v", err)
+	}
+	if err := Verify(data.QR); err != nil {
+		t.Errorf("Verify() = %v", err)
+	}
+}
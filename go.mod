@@ -1,3 +1,5 @@
 module github.com/ishinvin/go-khqr
 
 go 1.25.5
+
+require golang.org/x/text v0.40.0
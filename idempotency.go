@@ -0,0 +1,122 @@
+package khqr
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idempotencyKey is the context key for an explicit idempotency key.
+type idempotencyKey struct{}
+
+// ContextWithIdempotencyKey returns a context carrying the key a Generator
+// configured with WithIdempotency uses instead of the bill number.
+func ContextWithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// IdempotencyKeyFromContext returns the key set by ContextWithIdempotencyKey.
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	return key
+}
+
+// IdempotencyEntry is the code remembered for an idempotency key.
+type IdempotencyEntry struct {
+	Fingerprint string    `json:"fingerprint"` // hash of the generation inputs, excluding the expiration
+	QR          string    `json:"qr"`
+	ExpiresAt   time.Time `json:"expires_at,omitzero"` // zero for static codes
+}
+
+// expired reports whether the remembered code can no longer be paid at t.
+func (e *IdempotencyEntry) expired(t time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !t.Before(e.ExpiresAt)
+}
+
+// IdempotencyStore remembers the code issued for each idempotency key.
+type IdempotencyStore interface {
+	// Load returns the entry for key and whether one exists.
+	Load(ctx context.Context, key string) (IdempotencyEntry, bool, error)
+	// Store saves the entry for key, replacing any existing one.
+	Store(ctx context.Context, key string, e IdempotencyEntry) error
+}
+
+// MemoryIdempotencyStore is an IdempotencyStore kept in memory. Expired
+// entries are dropped as new ones are stored. It is safe for concurrent use.
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]IdempotencyEntry
+}
+
+// NewMemoryIdempotencyStore returns an empty MemoryIdempotencyStore.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: map[string]IdempotencyEntry{}}
+}
+
+// Load implements IdempotencyStore.
+func (m *MemoryIdempotencyStore) Load(_ context.Context, key string) (IdempotencyEntry, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	return e, ok, nil
+}
+
+// Store implements IdempotencyStore.
+func (m *MemoryIdempotencyStore) Store(_ context.Context, key string, e IdempotencyEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for k, old := range m.entries {
+		if old.expired(now) {
+			delete(m.entries, k)
+		}
+	}
+	m.entries[key] = e
+	return nil
+}
+
+// WithIdempotency makes the Generator return the previously issued code
+// when it is asked again for the same key with identical inputs, as long as
+// that code has not expired. A request with the same key but different
+// inputs fails with ErrIdempotencyConflict. The key is taken from
+// ContextWithIdempotencyKey, defaulting to the bill number scoped to the
+// Bakong account and merchant ID; requests with neither are not
+// deduplicated.
+//
+// The expiration timestamp is not part of the comparison, so a retry that
+// recomputes "now + 5 minutes" still gets the original code back.
+func WithIdempotency(s IdempotencyStore) GeneratorOption {
+	return func(g *Generator) { g.idempotency = s }
+}
+
+// billIdempotencyKey scopes a bill number to the account and merchant it
+// is billed for, so two merchants reusing an invoice number do not share a
+// key. It is empty without a bill number.
+func billIdempotencyKey(accountID, merchantID, billNumber string) string {
+	if billNumber == "" {
+		return ""
+	}
+	return strings.Join([]string{accountID, merchantID, billNumber}, "\x00")
+}
+
+// fingerprint hashes generation inputs after defaults have been applied.
+// Exactly one of individual and merchant is non-nil; the caller passes a
+// copy so the expiration can be cleared.
+func fingerprint(individual *IndividualInfo, merchant *MerchantInfo) string {
+	if individual != nil {
+		individual.ExpirationTimestamp = 0
+	}
+	if merchant != nil {
+		merchant.ExpirationTimestamp = 0
+	}
+	b, _ := json.Marshal(struct {
+		Individual *IndividualInfo `json:"individual,omitempty"`
+		Merchant   *MerchantInfo   `json:"merchant,omitempty"`
+	}{individual, merchant})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
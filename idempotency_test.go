@@ -0,0 +1,226 @@
+package khqr
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func idempotentInfo(amount float64, expires time.Time) MerchantInfo {
+	return MerchantInfo{
+		BakongAccountID:     "jonhsmith@devb",
+		MerchantName:        "Jonh Smith",
+		MerchantCity:        "Phnom Penh",
+		MerchantID:          "123456",
+		AcquiringBank:       "Dev Bank",
+		Currency:            USD,
+		Amount:              amount,
+		BillNumber:          "INV-001",
+		ExpirationTimestamp: expires.UnixMilli(),
+	}
+}
+
+func TestGeneratorIdempotentRetry(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	g := NewGenerator(WithStore(store), WithIdempotency(NewMemoryIdempotencyStore()))
+	ctx := context.Background()
+
+	first, err := g.GenerateMerchant(ctx, idempotentInfo(5, time.Now().Add(5*time.Minute)))
+	if err != nil {
+		t.Fatalf("GenerateMerchant() unexpected error: %v", err)
+	}
+	// A retry recomputes the expiration; it must still get the same code.
+	second, err := g.GenerateMerchant(ctx, idempotentInfo(5, time.Now().Add(6*time.Minute)))
+	if err != nil {
+		t.Fatalf("GenerateMerchant() retry unexpected error: %v", err)
+	}
+	if second.QR != first.QR {
+		t.Errorf("retry QR = %q, want original %q", second.QR, first.QR)
+	}
+	if got, _ := store.Find(ctx, Query{}); len(got) != 1 {
+		t.Errorf("store has %d records, want 1", len(got))
+	}
+}
+
+func TestGeneratorIdempotencyConflict(t *testing.T) {
+	t.Parallel()
+
+	g := NewGenerator(WithIdempotency(NewMemoryIdempotencyStore()))
+	ctx := context.Background()
+	expires := time.Now().Add(5 * time.Minute)
+
+	if _, err := g.GenerateMerchant(ctx, idempotentInfo(5, expires)); err != nil {
+		t.Fatalf("GenerateMerchant() unexpected error: %v", err)
+	}
+	if _, err := g.GenerateMerchant(ctx, idempotentInfo(6, expires)); !errors.Is(err, ErrIdempotencyConflict) {
+		t.Errorf("GenerateMerchant() different amount error = %v, want %v", err, ErrIdempotencyConflict)
+	}
+	// The bill number is scoped to the account and merchant ID, so another
+	// merchant reusing it is not a conflict.
+	other := idempotentInfo(6, expires)
+	other.MerchantID = "654321"
+	if _, err := g.GenerateMerchant(ctx, other); err != nil {
+		t.Errorf("GenerateMerchant() other merchant, same bill error = %v, want nil", err)
+	}
+	_, err := g.GenerateIndividual(ctx, IndividualInfo{
+		BakongAccountID:     "jonhsmith@devb",
+		MerchantName:        "Jonh Smith",
+		Currency:            USD,
+		Amount:              5,
+		BillNumber:          "INV-001",
+		ExpirationTimestamp: expires.UnixMilli(),
+	})
+	if err != nil {
+		t.Errorf("GenerateIndividual() same bill error = %v, want nil", err)
+	}
+}
+
+func TestGeneratorIdempotencyExpired(t *testing.T) {
+	t.Parallel()
+
+	idem := NewMemoryIdempotencyStore()
+	g := NewGenerator(WithIdempotency(idem))
+	ctx := context.Background()
+
+	first, err := g.GenerateMerchant(ctx, idempotentInfo(5, time.Now().Add(5*time.Minute)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Age the remembered code past its expiration.
+	key := billIdempotencyKey("jonhsmith@devb", "123456", "INV-001")
+	e, _, _ := idem.Load(ctx, key)
+	e.ExpiresAt = time.Now().Add(-time.Second)
+	_ = idem.Store(ctx, key, e)
+
+	// Once expired, the key is free again, even for different inputs.
+	second, err := g.GenerateMerchant(ctx, idempotentInfo(6, time.Now().Add(5*time.Minute)))
+	if err != nil {
+		t.Fatalf("GenerateMerchant() after expiry unexpected error: %v", err)
+	}
+	if second.QR == first.QR {
+		t.Error("GenerateMerchant() after expiry returned the expired code")
+	}
+}
+
+func TestGeneratorIdempotencyKeys(t *testing.T) {
+	t.Parallel()
+
+	g := NewGenerator(WithIdempotency(NewMemoryIdempotencyStore()))
+	expires := time.Now().Add(5 * time.Minute)
+
+	// An explicit key overrides the bill number.
+	a, err := g.GenerateMerchant(ContextWithIdempotencyKey(context.Background(), "order-1"), idempotentInfo(5, expires))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := g.GenerateMerchant(ContextWithIdempotencyKey(context.Background(), "order-2"), idempotentInfo(6, expires))
+	if err != nil {
+		t.Fatalf("GenerateMerchant() distinct key unexpected error: %v", err)
+	}
+	if a.QR == b.QR {
+		t.Error("distinct keys returned the same code")
+	}
+
+	// Without a bill number or explicit key there is nothing to deduplicate.
+	info := idempotentInfo(5, expires)
+	info.BillNumber = ""
+	for range 2 {
+		if _, err := g.GenerateMerchant(context.Background(), info); err != nil {
+			t.Fatalf("GenerateMerchant() without key unexpected error: %v", err)
+		}
+	}
+	info.Amount = 7
+	if _, err := g.GenerateMerchant(context.Background(), info); err != nil {
+		t.Errorf("GenerateMerchant() without key error = %v, want nil", err)
+	}
+}
+
+// flakyStore is a Store whose Save fails while down is set.
+type flakyStore struct {
+	MemoryStore
+	down bool
+}
+
+func (s *flakyStore) Save(ctx context.Context, r *Record) error {
+	if s.down {
+		return errStoreDown
+	}
+	return s.MemoryStore.Save(ctx, r)
+}
+
+// failingIdempotencyStore is an IdempotencyStore whose Store always fails.
+type failingIdempotencyStore struct{ MemoryIdempotencyStore }
+
+var errIdempotencyDown = errors.New("idempotency store down")
+
+func (*failingIdempotencyStore) Store(context.Context, string, IdempotencyEntry) error {
+	return errIdempotencyDown
+}
+
+func TestGeneratorIdempotencyWriteFailure(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	info := idempotentInfo(5, time.Now().Add(5*time.Minute))
+
+	// A failed claim must not leave a record for the retry to duplicate.
+	store := NewMemoryStore()
+	g := NewGenerator(WithStore(store), WithIdempotency(&failingIdempotencyStore{}))
+	if _, err := g.GenerateMerchant(ctx, info); !errors.Is(err, errIdempotencyDown) {
+		t.Fatalf("GenerateMerchant() error = %v, want %v", err, errIdempotencyDown)
+	}
+	if got, _ := store.Find(ctx, Query{}); len(got) != 0 {
+		t.Errorf("store has %d records after a failed claim, want 0", len(got))
+	}
+
+	// A failed record releases the key, so the retry issues and records a
+	// code of its own instead of returning an unrecorded one.
+	flaky := &flakyStore{down: true}
+	g = NewGenerator(WithStore(flaky), WithIdempotency(NewMemoryIdempotencyStore()))
+	if _, err := g.GenerateMerchant(ctx, info); !errors.Is(err, errStoreDown) {
+		t.Fatalf("GenerateMerchant() error = %v, want %v", err, errStoreDown)
+	}
+	flaky.down = false
+	data, err := g.GenerateMerchant(ctx, info)
+	if err != nil {
+		t.Fatalf("GenerateMerchant() retry unexpected error: %v", err)
+	}
+	got, _ := flaky.Find(ctx, Query{})
+	if len(got) != 1 || got[0].QR != data.QR {
+		t.Errorf("store has %d records, want only the retried code", len(got))
+	}
+}
+
+func TestGeneratorIdempotencyConcurrent(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	g := NewGenerator(WithStore(store), WithIdempotency(NewMemoryIdempotencyStore()))
+	info := idempotentInfo(5, time.Now().Add(5*time.Minute))
+
+	var wg sync.WaitGroup
+	qrs := make([]string, 8)
+	for i := range qrs {
+		wg.Go(func() {
+			data, err := g.GenerateMerchant(context.Background(), info)
+			if err != nil {
+				t.Errorf("GenerateMerchant() unexpected error: %v", err)
+				return
+			}
+			qrs[i] = data.QR
+		})
+	}
+	wg.Wait()
+	for _, qr := range qrs[1:] {
+		if qr != qrs[0] {
+			t.Fatalf("concurrent retries returned different codes")
+		}
+	}
+	if got, _ := store.Find(context.Background(), Query{}); len(got) != 1 {
+		t.Errorf("store has %d records, want 1", len(got))
+	}
+}
@@ -0,0 +1,424 @@
+// Package khqrtest provides an in-process emulator of the Bakong Open API
+// for integration tests.
+//
+// NewServer starts an httptest server that answers the transaction check,
+// account lookup and deep link endpoints the way Bakong does. Tests mark
+// codes as paid with Server.Pay, register accounts with AddAccount, and
+// inject latency or error responses to exercise retry paths:
+//
+//	srv := khqrtest.NewServer(t)
+//	srv.Pay(data, khqrtest.Payment{FromAccountID: "payer@aclb"})
+//	// point the code under test at srv.URL with srv.Token
+package khqrtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	khqr "github.com/ishinvin/go-khqr"
+)
+
+// Endpoint paths served by the emulator.
+const (
+	PathCheckByMD5       = "/v1/check_transaction_by_md5"
+	PathCheckByHash      = "/v1/check_transaction_by_hash"
+	PathCheckByShortHash = "/v1/check_transaction_by_short_hash"
+	PathCheckAccount     = "/v1/check_bakong_account"
+	PathDeepLink         = "/v1/generate_deeplink_by_qr"
+)
+
+// Error codes returned in the errorCode field, as the Bakong Open API uses them.
+const (
+	ErrorTransactionNotFound = 1
+	ErrorTransactionFailed   = 3
+	ErrorMissingFields       = 5
+	ErrorUnauthorized        = 6
+	ErrorAccountNotFound     = 11
+	ErrorInternal            = 500
+)
+
+// DefaultToken is the bearer token accepted unless WithToken is used.
+const DefaultToken = "khqrtest-token"
+
+// Response is the envelope wrapping every Bakong Open API response.
+type Response struct {
+	ResponseCode    int             `json:"responseCode"` // 0 on success, 1 on failure
+	ResponseMessage string          `json:"responseMessage"`
+	ErrorCode       *int            `json:"errorCode"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// Transaction is the data returned by the transaction check endpoints.
+type Transaction struct {
+	Hash                string  `json:"hash"`
+	FromAccountID       string  `json:"fromAccountId"`
+	ToAccountID         string  `json:"toAccountId"`
+	Currency            string  `json:"currency"`
+	Amount              float64 `json:"amount"`
+	Description         string  `json:"description"`
+	CreatedDateMs       int64   `json:"createdDateMs"`
+	AcknowledgedDateMs  int64   `json:"acknowledgedDateMs"`
+	ReceiverBank        string  `json:"receiverBank"`
+	ReceiverBankAccount string  `json:"receiverBankAccount"`
+	InstructionRef      string  `json:"instructionRef"`
+	ExternalRef         string  `json:"externalRef"`
+
+	md5 string
+}
+
+// Payment describes how Server.Pay settles a code. Zero fields take
+// defaults: the code's own amount, "payer@devb", and the current time.
+type Payment struct {
+	Amount        float64
+	FromAccountID string
+	PaidAt        time.Time
+	Description   string
+}
+
+// Fault is an error response injected with Server.Fail.
+type Fault struct {
+	Status    int    // HTTP status; defaults to 200, as Bakong reports most errors in the body
+	ErrorCode int    // errorCode in the body; defaults to ErrorInternal
+	Message   string // responseMessage in the body
+	Times     int    // number of requests to fail; 0 fails until ClearFaults
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithToken sets the bearer token the emulator accepts.
+func WithToken(token string) Option {
+	return func(s *Server) { s.Token = token }
+}
+
+// WithAccounts registers Bakong account IDs that exist.
+func WithAccounts(ids ...string) Option {
+	return func(s *Server) { s.AddAccount(ids...) }
+}
+
+// WithLatency delays every response by d.
+func WithLatency(d time.Duration) Option {
+	return func(s *Server) { s.latency = d }
+}
+
+// Server is a running Bakong Open API emulator.
+type Server struct {
+	*httptest.Server
+	Token string
+
+	mu       sync.Mutex
+	latency  time.Duration
+	txs      []*Transaction
+	accounts map[string]bool
+	faults   map[string]*Fault // keyed by path; "" applies to every path
+	requests map[string]int
+}
+
+// NewServer starts an emulator and stops it when tb finishes.
+func NewServer(tb testing.TB, opts ...Option) *Server {
+	tb.Helper()
+	s := &Server{
+		Token:    DefaultToken,
+		accounts: map[string]bool{},
+		faults:   map[string]*Fault{},
+		requests: map[string]int{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+PathCheckByMD5, s.checkByMD5)
+	mux.HandleFunc("POST "+PathCheckByHash, s.checkByHash)
+	mux.HandleFunc("POST "+PathCheckByShortHash, s.checkByShortHash)
+	mux.HandleFunc("POST "+PathCheckAccount, s.checkAccount)
+	mux.HandleFunc("POST "+PathDeepLink, s.deepLink)
+	s.Server = httptest.NewServer(s.middleware(mux))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// AddAccount registers Bakong account IDs that exist.
+func (s *Server) AddAccount(ids ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		s.accounts[id] = true
+	}
+}
+
+// SetLatency delays every subsequent response by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Fail injects f for requests to path, or to every endpoint if path is "".
+func (s *Server) Fail(path string, f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[path] = &f
+}
+
+// ClearFaults removes every injected fault.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.faults)
+}
+
+// Requests returns how many requests path has received, including
+// rejected ones.
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// Pay marks data as paid and returns the transaction the endpoints will
+// report. The recipient is the code's account, which is registered as
+// existing. Static codes need an explicit Payment.Amount.
+func (s *Server) Pay(data *khqr.Data, p Payment) (Transaction, error) {
+	d, err := khqr.Decode(data.QR)
+	if err != nil {
+		return Transaction{}, err
+	}
+	currency, err := khqr.ParseCurrency(d.TransactionCurrency)
+	if err != nil {
+		return Transaction{}, err
+	}
+	if p.Amount == 0 && d.TransactionAmount != "" {
+		if p.Amount, err = strconv.ParseFloat(d.TransactionAmount, 64); err != nil {
+			return Transaction{}, err
+		}
+	}
+	if p.Amount <= 0 {
+		return Transaction{}, errors.New("khqrtest: static code needs Payment.Amount")
+	}
+	if p.FromAccountID == "" {
+		p.FromAccountID = "payer@devb"
+	}
+	if p.PaidAt.IsZero() {
+		p.PaidAt = time.Now()
+	}
+	tx := &Transaction{
+		Hash:                randomHex(32), //nolint:mnd // 256-bit transaction hash
+		FromAccountID:       p.FromAccountID,
+		ToAccountID:         d.BakongAccountID,
+		Currency:            currency.String(),
+		Amount:              p.Amount,
+		Description:         p.Description,
+		CreatedDateMs:       p.PaidAt.UnixMilli(),
+		AcknowledgedDateMs:  p.PaidAt.UnixMilli(),
+		ReceiverBank:        d.AcquiringBank,
+		ReceiverBankAccount: d.AccountInfo,
+		InstructionRef:      "KHQRTEST" + strconv.FormatInt(p.PaidAt.UnixNano(), 10),
+		ExternalRef:         d.BillNumber,
+		md5:                 data.MD5(),
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.txs = append(s.txs, tx)
+	s.accounts[d.BakongAccountID] = true
+	return *tx, nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// middleware counts requests, applies latency, checks the bearer token
+// and serves injected faults.
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		latency := s.latency
+		fault := s.takeFault(r.URL.Path)
+		s.mu.Unlock()
+
+		if latency > 0 {
+			select {
+			case <-time.After(latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if r.Header.Get("Authorization") != "Bearer "+s.Token {
+			writeError(w, http.StatusUnauthorized, ErrorUnauthorized, "Unauthorized, not yet requested for token or code invalid")
+			return
+		}
+		if fault != nil {
+			status, code, msg := fault.Status, fault.ErrorCode, fault.Message
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if code == 0 {
+				code = ErrorInternal
+			}
+			if msg == "" {
+				msg = "Injected fault"
+			}
+			writeError(w, status, code, msg)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// takeFault returns the fault for path, consuming one use. Callers must
+// hold mu.
+func (s *Server) takeFault(path string) *Fault {
+	for _, key := range []string{path, ""} {
+		f, ok := s.faults[key]
+		if !ok {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				delete(s.faults, key)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+func (s *Server) checkByMD5(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		MD5 string `json:"md5"`
+	}
+	if !decode(w, r, &req) || !require(w, req.MD5) {
+		return
+	}
+	s.writeTransaction(w, func(tx *Transaction) bool { return tx.md5 == req.MD5 })
+}
+
+func (s *Server) checkByHash(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Hash string `json:"hash"`
+	}
+	if !decode(w, r, &req) || !require(w, req.Hash) {
+		return
+	}
+	s.writeTransaction(w, func(tx *Transaction) bool { return tx.Hash == req.Hash })
+}
+
+func (s *Server) checkByShortHash(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Hash     string  `json:"hash"`
+		Amount   float64 `json:"amount"`
+		Currency string  `json:"currency"`
+	}
+	if !decode(w, r, &req) || !require(w, req.Hash, req.Currency) {
+		return
+	}
+	s.writeTransaction(w, func(tx *Transaction) bool {
+		return strings.HasPrefix(tx.Hash, req.Hash) && tx.Amount == req.Amount && strings.EqualFold(tx.Currency, req.Currency)
+	})
+}
+
+func (s *Server) writeTransaction(w http.ResponseWriter, match func(*Transaction) bool) {
+	s.mu.Lock()
+	var found *Transaction
+	for _, tx := range s.txs {
+		if match(tx) {
+			found = tx
+			break
+		}
+	}
+	s.mu.Unlock()
+	if found == nil {
+		writeError(w, http.StatusOK, ErrorTransactionNotFound, "Transaction could not be found. Please check and try again.")
+		return
+	}
+	writeOK(w, "Getting transaction successfully.", found)
+}
+
+func (s *Server) checkAccount(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AccountID string `json:"accountId"`
+	}
+	if !decode(w, r, &req) || !require(w, req.AccountID) {
+		return
+	}
+	s.mu.Lock()
+	exists := s.accounts[req.AccountID]
+	s.mu.Unlock()
+	if !exists {
+		writeError(w, http.StatusOK, ErrorAccountNotFound, "Account ID does not exist")
+		return
+	}
+	writeOK(w, "Account ID exists", nil)
+}
+
+func (s *Server) deepLink(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		QR         string `json:"qr"`
+		SourceInfo struct {
+			AppIconURL          string `json:"appIconUrl"`
+			AppName             string `json:"appName"`
+			AppDeepLinkCallback string `json:"appDeepLinkCallback"`
+		} `json:"sourceInfo"`
+	}
+	if !decode(w, r, &req) || !require(w, req.QR) {
+		return
+	}
+	if err := khqr.Verify(req.QR); err != nil {
+		writeError(w, http.StatusOK, ErrorMissingFields, "KHQR provided is invalid")
+		return
+	}
+	md5 := (&khqr.Data{QR: req.QR}).MD5()
+	writeOK(w, "Getting Deep Link successfully.", map[string]string{"shortLink": "https://bakong.page.link/" + md5[:12]})
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20)) //nolint:mnd // 1 MiB request limit
+	if err == nil {
+		err = json.Unmarshal(body, v)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrorMissingFields, fmt.Sprintf("Invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+func require(w http.ResponseWriter, fields ...string) bool {
+	for _, f := range fields {
+		if f == "" {
+			writeError(w, http.StatusOK, ErrorMissingFields, "Missing required fields")
+			return false
+		}
+	}
+	return true
+}
+
+func writeOK(w http.ResponseWriter, msg string, data any) {
+	raw, _ := json.Marshal(data)
+	writeJSON(w, http.StatusOK, Response{ResponseCode: 0, ResponseMessage: msg, Data: raw})
+}
+
+func writeError(w http.ResponseWriter, status, code int, msg string) {
+	writeJSON(w, status, Response{ResponseCode: 1, ResponseMessage: msg, ErrorCode: &code, Data: json.RawMessage("null")})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
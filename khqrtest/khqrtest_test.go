@@ -0,0 +1,202 @@
+package khqrtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	khqr "github.com/ishinvin/go-khqr"
+)
+
+func post(t *testing.T, s *Server, path, token string, body any) (int, Response) {
+	t.Helper()
+	b, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, s.URL+path, bytes.NewReader(b))
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatalf("POST %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	var out Response
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("POST %s: decoding response: %v", path, err)
+	}
+	return resp.StatusCode, out
+}
+
+func errorCode(r Response) int {
+	if r.ErrorCode == nil {
+		return 0
+	}
+	return *r.ErrorCode
+}
+
+func testData(t *testing.T, amount float64) *khqr.Data {
+	t.Helper()
+	info := khqr.IndividualInfo{
+		BakongAccountID: "jonhsmith@nbcq",
+		MerchantName:    "Jonh Smith",
+		Currency:        khqr.USD,
+		Amount:          amount,
+		BillNumber:      "INV-1",
+	}
+	if amount > 0 {
+		info.ExpirationTimestamp = time.Now().Add(5 * time.Minute).UnixMilli()
+	}
+	data, err := khqr.GenerateIndividual(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestCheckTransaction(t *testing.T) {
+	t.Parallel()
+
+	s := NewServer(t)
+	data := testData(t, 2.5)
+
+	if _, r := post(t, s, PathCheckByMD5, s.Token, map[string]string{"md5": data.MD5()}); errorCode(r) != ErrorTransactionNotFound {
+		t.Fatalf("unpaid check = %+v, want errorCode %d", r, ErrorTransactionNotFound)
+	}
+
+	tx, err := s.Pay(data, Payment{FromAccountID: "payer@aclb"})
+	if err != nil {
+		t.Fatalf("Pay() unexpected error: %v", err)
+	}
+	if tx.Amount != 2.5 || tx.Currency != "USD" || tx.ToAccountID != "jonhsmith@nbcq" || tx.ExternalRef != "INV-1" {
+		t.Errorf("Pay() = %+v", tx)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		body any
+		want int // errorCode, 0 for success
+	}{
+		{"md5", PathCheckByMD5, map[string]string{"md5": data.MD5()}, 0},
+		{"hash", PathCheckByHash, map[string]string{"hash": tx.Hash}, 0},
+		{"short_hash", PathCheckByShortHash, map[string]any{"hash": tx.Hash[:8], "amount": 2.5, "currency": "USD"}, 0},
+		{"short_hash_wrong_amount", PathCheckByShortHash, map[string]any{"hash": tx.Hash[:8], "amount": 3, "currency": "USD"}, ErrorTransactionNotFound},
+		{"unknown_hash", PathCheckByHash, map[string]string{"hash": "00"}, ErrorTransactionNotFound},
+		{"missing_md5", PathCheckByMD5, map[string]string{}, ErrorMissingFields},
+	}
+	for _, tt := range tests {
+		status, r := post(t, s, tt.path, s.Token, tt.body)
+		if status != http.StatusOK || errorCode(r) != tt.want {
+			t.Errorf("%s: status %d, response %+v, want errorCode %d", tt.name, status, r, tt.want)
+			continue
+		}
+		if tt.want == 0 {
+			var got Transaction
+			if err := json.Unmarshal(r.Data, &got); err != nil || got.Hash != tx.Hash || got.FromAccountID != "payer@aclb" {
+				t.Errorf("%s: data = %s, want transaction %s", tt.name, r.Data, tx.Hash)
+			}
+		}
+	}
+}
+
+func TestPayStatic(t *testing.T) {
+	t.Parallel()
+
+	s := NewServer(t)
+	data := testData(t, 0)
+	if _, err := s.Pay(data, Payment{}); err == nil {
+		t.Error("Pay() static without amount expected error, got nil")
+	}
+	tx, err := s.Pay(data, Payment{Amount: 7})
+	if err != nil || tx.Amount != 7 {
+		t.Errorf("Pay() static = %+v, %v, want amount 7", tx, err)
+	}
+}
+
+func TestCheckAccount(t *testing.T) {
+	t.Parallel()
+
+	s := NewServer(t, WithAccounts("known@aclb"))
+	for id, want := range map[string]int{"known@aclb": 0, "unknown@aclb": ErrorAccountNotFound} {
+		if _, r := post(t, s, PathCheckAccount, s.Token, map[string]string{"accountId": id}); errorCode(r) != want {
+			t.Errorf("check %s = %+v, want errorCode %d", id, r, want)
+		}
+	}
+	// Paid codes register their recipient.
+	if _, err := s.Pay(testData(t, 1), Payment{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, r := post(t, s, PathCheckAccount, s.Token, map[string]string{"accountId": "jonhsmith@nbcq"}); r.ResponseCode != 0 {
+		t.Errorf("check recipient = %+v, want success", r)
+	}
+}
+
+func TestDeepLink(t *testing.T) {
+	t.Parallel()
+
+	s := NewServer(t)
+	data := testData(t, 1)
+	_, r := post(t, s, PathDeepLink, s.Token, map[string]any{"qr": data.QR, "sourceInfo": map[string]string{"appName": "Test"}})
+	var link struct {
+		ShortLink string `json:"shortLink"`
+	}
+	if err := json.Unmarshal(r.Data, &link); err != nil || link.ShortLink == "" {
+		t.Errorf("deep link = %+v", r)
+	}
+	if _, r := post(t, s, PathDeepLink, s.Token, map[string]string{"qr": "garbage"}); errorCode(r) != ErrorMissingFields {
+		t.Errorf("deep link for invalid QR = %+v", r)
+	}
+}
+
+func TestAuthAndFaults(t *testing.T) {
+	t.Parallel()
+
+	s := NewServer(t, WithToken("secret"))
+	body := map[string]string{"accountId": "a@b"}
+
+	if status, r := post(t, s, PathCheckAccount, "wrong", body); status != http.StatusUnauthorized || errorCode(r) != ErrorUnauthorized {
+		t.Errorf("wrong token = %d %+v, want 401", status, r)
+	}
+
+	s.Fail(PathCheckAccount, Fault{Status: http.StatusServiceUnavailable, Times: 2})
+	for range 2 {
+		if status, r := post(t, s, PathCheckAccount, "secret", body); status != http.StatusServiceUnavailable || errorCode(r) != ErrorInternal {
+			t.Errorf("faulted = %d %+v, want 503", status, r)
+		}
+	}
+	if status, r := post(t, s, PathCheckAccount, "secret", body); status != http.StatusOK || errorCode(r) != ErrorAccountNotFound {
+		t.Errorf("after fault = %d %+v, want normal response", status, r)
+	}
+
+	s.Fail("", Fault{ErrorCode: ErrorTransactionFailed, Message: "down"})
+	if _, r := post(t, s, PathCheckByHash, "secret", map[string]string{"hash": "x"}); errorCode(r) != ErrorTransactionFailed || r.ResponseMessage != "down" {
+		t.Errorf("global fault = %+v", r)
+	}
+	s.ClearFaults()
+	if _, r := post(t, s, PathCheckByHash, "secret", map[string]string{"hash": "x"}); errorCode(r) != ErrorTransactionNotFound {
+		t.Errorf("after ClearFaults = %+v", r)
+	}
+	if got := s.Requests(PathCheckAccount); got != 4 {
+		t.Errorf("Requests() = %d, want 4", got)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	t.Parallel()
+
+	s := NewServer(t, WithLatency(200*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, s.URL+PathCheckAccount, bytes.NewReader([]byte(`{}`)))
+	_, err := s.Client().Do(req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("request with short deadline error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	s.SetLatency(0)
+	if _, r := post(t, s, PathCheckAccount, s.Token, map[string]string{"accountId": "a@b"}); errorCode(r) != ErrorAccountNotFound {
+		t.Errorf("after SetLatency(0) = %+v", r)
+	}
+}
@@ -0,0 +1,74 @@
+package khqr
+
+import (
+	"errors"
+	"maps"
+	"strings"
+	"sync"
+)
+
+// messageRegistry holds translated error messages keyed by normalized
+// locale, then by Error.Code.
+var messageRegistry = struct {
+	sync.RWMutex
+	locales map[string]map[int]string
+}{locales: map[string]map[int]string{}}
+
+func init() {
+	RegisterMessages("km", khmerMessages)
+}
+
+// RegisterMessages adds translations for locale, keyed by Error.Code,
+// replacing any existing message for the same code. Locales are BCP 47
+// tags such as "km" or "km-KH", matched case-insensitively.
+func RegisterMessages(locale string, messages map[int]string) {
+	locale = normalizeLocale(locale)
+	messageRegistry.Lock()
+	defer messageRegistry.Unlock()
+	m := messageRegistry.locales[locale]
+	if m == nil {
+		m = make(map[int]string, len(messages))
+		messageRegistry.locales[locale] = m
+	}
+	maps.Copy(m, messages)
+}
+
+// Localize returns the message of e in locale. The locale accepts BCP 47
+// tags, including the String form of a golang.org/x/text/language.Tag,
+// and POSIX names such as "km_KH.UTF-8". A region or script without its
+// own translation falls back to the base language ("km-KH" to "km"), and
+// anything untranslated falls back to the English Message.
+func (e *Error) Localize(locale string) string {
+	messageRegistry.RLock()
+	defer messageRegistry.RUnlock()
+	for tag := normalizeLocale(locale); tag != ""; {
+		if msg, ok := messageRegistry.locales[tag][e.Code]; ok {
+			return msg
+		}
+		i := strings.LastIndexByte(tag, '-')
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	return e.Message
+}
+
+// LocalizeError returns the message of the *Error wrapped in err in
+// locale, without the "khqr:" prefix, code or any wrapped detail, for
+// showing to end users. Other errors return err.Error().
+func LocalizeError(err error, locale string) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Localize(locale)
+	}
+	return err.Error()
+}
+
+// normalizeLocale lowercases a locale and converts POSIX forms such as
+// "km_KH.UTF-8" to BCP 47 "km-kh".
+func normalizeLocale(locale string) string {
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
@@ -0,0 +1,102 @@
+package khqr
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"testing"
+)
+
+// definedErrorCodes returns the code and English message of every *Error
+// literal in errors.go, so a new error without a translation fails the
+// test below.
+func definedErrorCodes(t *testing.T) map[int]string {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), "errors.go", nil, 0)
+	if err != nil {
+		t.Fatalf("parse errors.go: %v", err)
+	}
+	codes := map[int]string{}
+	ast.Inspect(f, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		if id, ok := lit.Type.(*ast.Ident); !ok || id.Name != "Error" {
+			return true
+		}
+		var code int
+		var msg string
+		for _, elt := range lit.Elts {
+			kv := elt.(*ast.KeyValueExpr)
+			val := kv.Value.(*ast.BasicLit).Value
+			switch kv.Key.(*ast.Ident).Name {
+			case "Code":
+				code, _ = strconv.Atoi(val)
+			case "Message":
+				msg, _ = strconv.Unquote(val)
+			}
+		}
+		codes[code] = msg
+		return true
+	})
+	if len(codes) == 0 {
+		t.Fatal("no errors found in errors.go")
+	}
+	return codes
+}
+
+func TestEveryErrorTranslated(t *testing.T) {
+	t.Parallel()
+
+	codes := definedErrorCodes(t)
+	for code, msg := range codes {
+		e := &Error{Code: code, Message: msg}
+		if msg == "" || e.Localize("en") != msg {
+			t.Errorf("code %d: English message %q", code, e.Localize("en"))
+		}
+		if km := e.Localize("km"); km == "" || km == msg {
+			t.Errorf("code %d has no Khmer translation", code)
+		}
+	}
+	for code := range khmerMessages {
+		if _, ok := codes[code]; !ok {
+			t.Errorf("Khmer translation for undefined code %d", code)
+		}
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	t.Parallel()
+
+	RegisterMessages("x-test", map[int]string{ErrInvalidQR.Code: "test: invalid"})
+	khmer := khmerMessages[ErrInvalidQR.Code]
+
+	tests := []struct {
+		locale string
+		err    error
+		want   string
+	}{
+		{"km", ErrInvalidQR, khmer},
+		{"km-KH", ErrInvalidQR, khmer},
+		{"KM_kh.UTF-8", ErrInvalidQR, khmer},
+		{"km-Khmr-KH", ErrInvalidQR, khmer},
+		{"en", ErrInvalidQR, ErrInvalidQR.Message},
+		{"en-US", ErrInvalidQR, ErrInvalidQR.Message},
+		{"", ErrInvalidQR, ErrInvalidQR.Message},
+		{"fr", ErrInvalidQR, ErrInvalidQR.Message},
+		{"kmr", ErrInvalidQR, ErrInvalidQR.Message},
+		{"x-test-ab", ErrInvalidQR, "test: invalid"},
+		{"x-test", ErrInvalidAmount, ErrInvalidAmount.Message},
+		{"km", fmt.Errorf("%w: %q", ErrSignatureKeyUnknown, "v9"), khmerMessages[ErrSignatureKeyUnknown.Code]},
+		{"km", errors.New("boom"), "boom"},
+	}
+	for _, tt := range tests {
+		if got := LocalizeError(tt.err, tt.locale); got != tt.want {
+			t.Errorf("LocalizeError(%v, %q) = %q, want %q", tt.err, tt.locale, got, tt.want)
+		}
+	}
+}
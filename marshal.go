@@ -0,0 +1,63 @@
+package khqr
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MarshalText encodes the currency as its ISO 4217 alphabetic code. The
+// zero value encodes as an empty string.
+func (c Currency) MarshalText() ([]byte, error) {
+	if c == 0 {
+		return []byte{}, nil
+	}
+	info, ok := LookupCurrency(c)
+	if !ok {
+		return nil, ErrInvalidCurrency
+	}
+	return []byte(info.Code), nil
+}
+
+// UnmarshalText decodes a registered currency from its ISO 4217 alphabetic
+// code (e.g. "USD") or numeric code (e.g. "840"). An empty string decodes
+// to the zero value.
+func (c *Currency) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*c = 0
+		return nil
+	}
+	parsed, err := ParseCurrency(string(text))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// UnmarshalJSON decodes a currency from a JSON string ("USD", "840")
+// or a JSON number (840).
+func (c *Currency) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return ErrInvalidCurrency
+		}
+		return c.UnmarshalText([]byte(s))
+	}
+	return c.UnmarshalText(data)
+}
+
+// UnmarshalText decodes a merchant type, rejecting values other than
+// "individual" and "merchant". An empty value leaves the type unset.
+func (mt *MerchantType) UnmarshalText(text []byte) error {
+	switch v := MerchantType(text); v {
+	case "", Individual, Merchant:
+		*mt = v
+		return nil
+	default:
+		return ErrMerchantTypeInvalid
+	}
+}
@@ -0,0 +1,291 @@
+package khqr
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCurrencyMarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		currency Currency
+		want     string
+		wantErr  error
+	}{
+		{"KHR", KHR, `"KHR"`, nil},
+		{"USD", USD, `"USD"`, nil},
+		{"THB", THB, `"THB"`, nil},
+		{"unset", 0, `""`, nil},
+		{"unsupported", Currency(999), "", ErrInvalidCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := json.Marshal(tt.currency)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("json.Marshal(%d) error = %v, want %v", int(tt.currency), err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("json.Marshal(%d) unexpected error: %v", int(tt.currency), err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("json.Marshal(%d) = %s, want %s", int(tt.currency), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrencyUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    Currency
+		wantErr error
+	}{
+		{"alpha_USD", `"USD"`, USD, nil},
+		{"alpha_KHR", `"KHR"`, KHR, nil},
+		{"alpha_lowercase", `"usd"`, USD, nil},
+		{"numeric_string", `"840"`, USD, nil},
+		{"numeric_string_KHR", `"116"`, KHR, nil},
+		{"number", `840`, USD, nil},
+		{"number_KHR", `116`, KHR, nil},
+		{"null", `null`, 0, nil},
+		{"unknown_alpha", `"EUR"`, 0, ErrInvalidCurrency},
+		{"unknown_number", `978`, 0, ErrInvalidCurrency},
+		{"empty", `""`, 0, nil},
+		{"fraction", `840.5`, 0, ErrInvalidCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got Currency
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("json.Unmarshal(%s) error = %v, want %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("json.Unmarshal(%s) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerchantTypeUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    MerchantType
+		wantErr error
+	}{
+		{"individual", `"individual"`, Individual, nil},
+		{"merchant", `"merchant"`, Merchant, nil},
+		{"empty", `""`, "", nil},
+		{"unknown", `"corporate"`, "", ErrMerchantTypeInvalid},
+		{"wrong_case", `"Merchant"`, "", ErrMerchantTypeInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got MerchantType
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("json.Unmarshal(%s) error = %v, want %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("json.Unmarshal(%s) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnsetCurrencyJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	n := PaymentNotification{ID: "evt-1", Amount: 5}
+	b, err := json.Marshal(n)
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	if !strings.Contains(string(b), `"currency":""`) {
+		t.Errorf("json.Marshal() = %s, want an empty currency", b)
+	}
+	var got PaymentNotification
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json.Unmarshal() unexpected error: %v", err)
+	}
+	if got.Currency != 0 || got.ID != n.ID {
+		t.Errorf("round trip = %+v, want %+v", got, n)
+	}
+}
+
+func TestIndividualInfoJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	info := IndividualInfo{
+		BakongAccountID:       "jonhsmith@nbcq",
+		MerchantName:          "Jonh Smith",
+		Currency:              USD,
+		Amount:                1.25,
+		ExpirationTimestamp:   1726821915797,
+		BillNumber:            "INV-2021-07-65822",
+		AltLanguagePreference: "km",
+		AltMerchantName:       "ចន ស្មីន",
+	}
+
+	b, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	want := `{"bakong_account_id":"jonhsmith@nbcq","merchant_name":"Jonh Smith","currency":"USD","amount":1.25,` +
+		`"expiration_timestamp":1726821915797,"bill_number":"INV-2021-07-65822","alt_language_preference":"km",` +
+		`"alt_merchant_name":"ចន ស្មីន"}`
+	if string(b) != want {
+		t.Errorf("json.Marshal() =\n%s\nwant\n%s", b, want)
+	}
+
+	var got IndividualInfo
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json.Unmarshal() unexpected error: %v", err)
+	}
+	if got != info {
+		t.Errorf("round trip = %+v, want %+v", got, info)
+	}
+}
+
+func TestMerchantInfoJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	info := MerchantInfo{
+		BakongAccountID: "jonhsmith@devb",
+		MerchantName:    "Jonh Smith",
+		MerchantCity:    "Phnom Penh",
+		MerchantID:      "123456",
+		AcquiringBank:   "Dev Bank",
+		Currency:        KHR,
+		StoreLabel:      "BKK-1",
+	}
+
+	b, err := json.Marshal(info)
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	want := `{"bakong_account_id":"jonhsmith@devb","merchant_name":"Jonh Smith","merchant_city":"Phnom Penh",` +
+		`"merchant_id":"123456","acquiring_bank":"Dev Bank","currency":"KHR","store_label":"BKK-1"}`
+	if string(b) != want {
+		t.Errorf("json.Marshal() =\n%s\nwant\n%s", b, want)
+	}
+
+	var got MerchantInfo
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json.Unmarshal() unexpected error: %v", err)
+	}
+	if got != info {
+		t.Errorf("round trip = %+v, want %+v", got, info)
+	}
+}
+
+func TestMerchantInfoUnmarshalNumericCurrency(t *testing.T) {
+	t.Parallel()
+
+	var got MerchantInfo
+	if err := json.Unmarshal([]byte(`{"bakong_account_id":"a@b","currency":840}`), &got); err != nil {
+		t.Fatalf("json.Unmarshal() unexpected error: %v", err)
+	}
+	if got.Currency != USD {
+		t.Errorf("Currency = %v, want %v", got.Currency, USD)
+	}
+}
+
+func TestDecodedDataJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	qr := "00020101021230410015john_smith@devb01061234560208Dev Bank52045999530384054035.05802KH5916john smith actor" +
+		"6010Phnom Penh62130709Counter 299170013161343857579463048EF2"
+	data, err := Decode(qr)
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		t.Fatalf("json.Unmarshal() unexpected error: %v", err)
+	}
+	for key, want := range map[string]any{
+		"bakong_account_id":    "john_smith@devb",
+		"merchant_type":        "merchant",
+		"transaction_currency": "840",
+		"terminal_label":       "Counter 2",
+		"crc":                  "8EF2",
+	} {
+		if raw[key] != want {
+			t.Errorf("key %q = %v, want %v", key, raw[key], want)
+		}
+	}
+
+	var got DecodedData
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json.Unmarshal() unexpected error: %v", err)
+	}
+	if got != *data {
+		t.Errorf("round trip = %+v, want %+v", got, *data)
+	}
+}
+
+func TestDecodedDataUnmarshalInvalidMerchantType(t *testing.T) {
+	t.Parallel()
+
+	var got DecodedData
+	err := json.Unmarshal([]byte(`{"merchant_type":"bank"}`), &got)
+	if !errors.Is(err, ErrMerchantTypeInvalid) {
+		t.Errorf("json.Unmarshal() error = %v, want %v", err, ErrMerchantTypeInvalid)
+	}
+}
+
+func TestDataJSON(t *testing.T) {
+	t.Parallel()
+
+	b, err := json.Marshal(&Data{QR: "000201"})
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	if string(b) != `{"qr":"000201"}` {
+		t.Errorf("json.Marshal() = %s, want %s", b, `{"qr":"000201"}`)
+	}
+}
+
+func TestErrorJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	b, err := json.Marshal(ErrAccountIDInvalid)
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	want := `{"code":3,"message":"Bakong Account ID is invalid"}`
+	if string(b) != want {
+		t.Errorf("json.Marshal() = %s, want %s", b, want)
+	}
+
+	var got Error
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json.Unmarshal() unexpected error: %v", err)
+	}
+	if !errors.Is(&got, ErrAccountIDInvalid) {
+		t.Errorf("round trip = %v, want %v", &got, ErrAccountIDInvalid)
+	}
+}
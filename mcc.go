@@ -0,0 +1,146 @@
+package khqr
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed mcc.csv
+var mccCSV string
+
+// MerchantCategory describes an ISO 18245 merchant category code.
+type MerchantCategory struct {
+	Code        string `json:"code"`        // 4-digit code, e.g. "5999"
+	Description string `json:"description"` // e.g. "Miscellaneous and Specialty Retail Stores"
+	Group       string `json:"group"`       // ISO 18245 range, e.g. "Miscellaneous Stores"
+}
+
+// mccGroup is an ISO 18245 code range.
+type mccGroup struct {
+	low, high int
+	name      string
+	reserved  bool // every code in the range is reserved for an individual brand
+}
+
+// mccGroups lists the ISO 18245 ranges in ascending order.
+var mccGroups = []mccGroup{
+	{0, 1499, "Agricultural Services", false},
+	{1500, 2999, "Contracted Services", false},
+	{3000, 3299, "Airlines", true},
+	{3300, 3499, "Car Rental", true},
+	{3500, 3999, "Lodging", true},
+	{4000, 4799, "Transportation Services", false},
+	{4800, 4999, "Utility Services", false},
+	{5000, 5599, "Retail Outlet Services", false},
+	{5600, 5699, "Clothing Stores", false},
+	{5700, 7299, "Miscellaneous Stores", false},
+	{7300, 7999, "Business Services", false},
+	{8000, 8999, "Professional Services and Membership Organizations", false},
+	{9000, 9999, "Government Services", false},
+}
+
+// mccCatalog is the parsed catalog, loaded on first use.
+var mccCatalog = sync.OnceValue(func() []MerchantCategory {
+	records, err := csv.NewReader(strings.NewReader(mccCSV)).ReadAll()
+	if err != nil {
+		panic("khqr: invalid embedded MCC catalog: " + err.Error())
+	}
+	catalog := make([]MerchantCategory, 0, len(records)-1)
+	for _, r := range records[1:] {
+		g, _ := lookupMCCGroup(r[0])
+		catalog = append(catalog, MerchantCategory{Code: r[0], Description: r[1], Group: g.name})
+	}
+	return catalog
+})
+
+// mccIndex maps codes to their position in mccCatalog.
+var mccIndex = sync.OnceValue(func() map[string]int {
+	index := make(map[string]int, len(mccCatalog()))
+	for i, mc := range mccCatalog() {
+		index[mc.Code] = i
+	}
+	return index
+})
+
+// normalizeMCC zero-pads a 1-4 digit code to 4 digits.
+// It returns false if the code is not 1-4 digits.
+func normalizeMCC(code string) (string, bool) {
+	if !merchantCategoryCodeRegex.MatchString(code) {
+		return "", false
+	}
+	return strings.Repeat("0", 4-len(code)) + code, true //nolint:mnd // MCC is 4 digits
+}
+
+func lookupMCCGroup(code string) (mccGroup, bool) {
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return mccGroup{}, false
+	}
+	for _, g := range mccGroups {
+		if n >= g.low && n <= g.high {
+			return g, true
+		}
+	}
+	return mccGroup{}, false
+}
+
+// LookupMerchantCategory returns the catalog entry for a merchant category
+// code. Codes shorter than 4 digits are zero-padded. Codes in the airline,
+// car rental and lodging ranges (3000-3999) are reserved for individual
+// brands and resolve to their range name.
+func LookupMerchantCategory(code string) (MerchantCategory, bool) {
+	code, ok := normalizeMCC(code)
+	if !ok {
+		return MerchantCategory{}, false
+	}
+	if i, ok := mccIndex()[code]; ok {
+		return mccCatalog()[i], true
+	}
+	if g, ok := lookupMCCGroup(code); ok && g.reserved {
+		return MerchantCategory{Code: code, Description: g.name, Group: g.name}, true
+	}
+	return MerchantCategory{}, false
+}
+
+// SearchMerchantCategories returns catalog entries whose code, description
+// or group contains query, case-insensitively, in code order.
+func SearchMerchantCategories(query string) []MerchantCategory {
+	query = strings.ToLower(strings.TrimSpace(query))
+	var matches []MerchantCategory
+	for _, mc := range mccCatalog() {
+		if strings.Contains(mc.Code, query) ||
+			strings.Contains(strings.ToLower(mc.Description), query) ||
+			strings.Contains(strings.ToLower(mc.Group), query) {
+			matches = append(matches, mc)
+		}
+	}
+	return matches
+}
+
+// MerchantCategories returns every catalog entry in code order.
+func MerchantCategories() []MerchantCategory {
+	return append([]MerchantCategory(nil), mccCatalog()...)
+}
+
+// ValidateMerchantCategoryCode checks that code is well-formed and assigned
+// in the catalog. Generation only checks the format; call this to reject
+// unassigned codes before registering a merchant.
+func ValidateMerchantCategoryCode(code string) error {
+	if err := validateMerchantCategoryCode(code); err != nil {
+		return err
+	}
+	if _, ok := LookupMerchantCategory(code); !ok {
+		return ErrMerchantCategoryCodeUnassigned
+	}
+	return nil
+}
+
+// merchantCategoryDescription returns the catalog description for code,
+// or an empty string if the code is not assigned.
+func merchantCategoryDescription(code string) string {
+	mc, _ := LookupMerchantCategory(code)
+	return mc.Description
+}
@@ -0,0 +1,159 @@
+package khqr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLookupMerchantCategory(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		code      string
+		wantOK    bool
+		wantDesc  string
+		wantGroup string
+	}{
+		{"default", "5999", true, "Miscellaneous and Specialty Retail Stores", "Miscellaneous Stores"},
+		{"restaurants", "5812", true, "Eating Places and Restaurants", "Miscellaneous Stores"},
+		{"zero_padded", "742", true, "Veterinary Services", "Agricultural Services"},
+		{"government", "9311", true, "Tax Payments", "Government Services"},
+		{"airline_brand", "3001", true, "Airlines", "Airlines"},
+		{"lodging_brand", "3501", true, "Lodging", "Lodging"},
+		{"unassigned", "1234", false, "", ""},
+		{"non_numeric", "abcd", false, "", ""},
+		{"too_long", "59999", false, "", ""},
+		{"empty", "", false, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := LookupMerchantCategory(tt.code)
+			if ok != tt.wantOK {
+				t.Fatalf("LookupMerchantCategory(%q) ok = %v, want %v", tt.code, ok, tt.wantOK)
+			}
+			if got.Description != tt.wantDesc || got.Group != tt.wantGroup {
+				t.Errorf("LookupMerchantCategory(%q) = %+v, want description %q group %q",
+					tt.code, got, tt.wantDesc, tt.wantGroup)
+			}
+		})
+	}
+}
+
+func TestSearchMerchantCategories(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		query     string
+		wantCodes []string
+	}{
+		{"description", "bakeries", []string{"5462"}},
+		{"case_insensitive", "FAST FOOD", []string{"5814"}},
+		{"code_prefix", "581", []string{"5811", "5812", "5813", "5814", "5815", "5816", "5817", "5818"}},
+		{"no_match", "spaceship", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := SearchMerchantCategories(tt.query)
+			if len(got) != len(tt.wantCodes) {
+				t.Fatalf("SearchMerchantCategories(%q) returned %d entries, want %d: %+v",
+					tt.query, len(got), len(tt.wantCodes), got)
+			}
+			for i, mc := range got {
+				if mc.Code != tt.wantCodes[i] {
+					t.Errorf("SearchMerchantCategories(%q)[%d].Code = %q, want %q", tt.query, i, mc.Code, tt.wantCodes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSearchMerchantCategoriesByGroup(t *testing.T) {
+	t.Parallel()
+
+	got := SearchMerchantCategories("government services")
+	if len(got) == 0 {
+		t.Fatal("SearchMerchantCategories() returned no entries for a group name")
+	}
+	for _, mc := range got {
+		if mc.Group != "Government Services" {
+			t.Errorf("entry %s has group %q, want %q", mc.Code, mc.Group, "Government Services")
+		}
+	}
+}
+
+func TestMerchantCategoriesCatalog(t *testing.T) {
+	t.Parallel()
+
+	catalog := MerchantCategories()
+	if len(catalog) == 0 {
+		t.Fatal("MerchantCategories() returned no entries")
+	}
+	for i, mc := range catalog {
+		if len(mc.Code) != 4 || mc.Description == "" || mc.Group == "" {
+			t.Errorf("incomplete catalog entry %+v", mc)
+		}
+		if i > 0 && catalog[i-1].Code >= mc.Code {
+			t.Errorf("catalog not sorted: %q before %q", catalog[i-1].Code, mc.Code)
+		}
+	}
+
+	catalog[0].Description = "changed"
+	if MerchantCategories()[0].Description == "changed" {
+		t.Error("MerchantCategories() returned a slice aliasing the catalog")
+	}
+}
+
+func TestValidateMerchantCategoryCodeCatalog(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{"assigned", "5999", nil},
+		{"assigned_short", "742", nil},
+		{"brand_range", "3300", nil},
+		{"unassigned", "0001", ErrMerchantCategoryCodeUnassigned},
+		{"assigned_retail", "5998", nil},
+		{"nonsense", "1234", ErrMerchantCategoryCodeUnassigned},
+		{"empty", "", ErrMerchantCategoryCodeRequired},
+		{"malformed", "12a", ErrMerchantCategoryCodeInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := ValidateMerchantCategoryCode(tt.code)
+			if !errors.Is(got, tt.wantErr) {
+				t.Errorf("ValidateMerchantCategoryCode(%q) = %v, want %v", tt.code, got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeMerchantCategory(t *testing.T) {
+	t.Parallel()
+
+	data, err := GenerateMerchant(MerchantInfo{
+		BakongAccountID:      "jonhsmith@devb",
+		MerchantName:         "Jonh Smith",
+		MerchantCity:         "Phnom Penh",
+		MerchantID:           "123456",
+		AcquiringBank:        "Dev Bank",
+		MerchantCategoryCode: "5812",
+	})
+	if err != nil {
+		t.Fatalf("GenerateMerchant() unexpected error: %v", err)
+	}
+	decoded, err := Decode(data.QR)
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	if decoded.MerchantCategory != "Eating Places and Restaurants" {
+		t.Errorf("MerchantCategory = %q, want %q", decoded.MerchantCategory, "Eating Places and Restaurants")
+	}
+}
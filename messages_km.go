@@ -0,0 +1,71 @@
+package khqr
+
+// khmerMessages translates every Error.Code into Khmer.
+var khmerMessages = map[int]string{
+	1:  "លេខសម្គាល់គណនីបាគងមិនអាចទុកទទេបានទេ",
+	2:  "ឈ្មោះអាជីវករមិនអាចទុកទទេបានទេ",
+	3:  "លេខសម្គាល់គណនីបាគងមិនត្រឹមត្រូវ",
+	4:  "ចំនួនទឹកប្រាក់មិនត្រឹមត្រូវ",
+	5:  "ប្រភេទអាជីវករមិនអាចទុកទទេបានទេ",
+	6:  "ប្រវែងលេខសម្គាល់គណនីបាគងមិនត្រឹមត្រូវ",
+	7:  "ប្រវែងឈ្មោះអាជីវករមិនត្រឹមត្រូវ",
+	8:  "KHQR ដែលបានផ្តល់មិនត្រឹមត្រូវ",
+	9:  "ប្រភេទរូបិយប័ណ្ណមិនអាចទុកទទេបានទេ",
+	10: "ប្រវែងលេខវិក្កយបត្រមិនត្រឹមត្រូវ",
+	11: "ប្រវែងស្លាកហាងមិនត្រឹមត្រូវ",
+	12: "ប្រវែងស្លាកស្ថានីយមិនត្រឹមត្រូវ",
+	16: "ប្រវែងសូចនាករទម្រង់ទិន្នន័យមិនត្រឹមត្រូវ",
+	17: "ប្រវែងវិធីសាស្ត្រផ្តួចផ្តើមប្រតិបត្តិការមិនត្រឹមត្រូវ",
+	18: "ប្រវែងប្រភេទអាជីវកម្មមិនត្រឹមត្រូវ",
+	19: "ប្រវែងរូបិយប័ណ្ណប្រតិបត្តិការមិនត្រឹមត្រូវ",
+	20: "ប្រវែងលេខកូដប្រទេសមិនត្រឹមត្រូវ",
+	21: "ប្រវែងទីក្រុងរបស់អាជីវករមិនត្រឹមត្រូវ",
+	22: "CRC មិនត្រឹមត្រូវ",
+	23: "សូចនាករទម្រង់ទិន្នន័យមិនអាចទុកទទេបានទេ",
+	24: "CRC មិនអាចទុកទទេបានទេ",
+	25: "ប្រភេទអាជីវកម្មមិនអាចទុកទទេបានទេ",
+	26: "លេខកូដប្រទេសមិនអាចទុកទទេបានទេ",
+	27: "ទីក្រុងរបស់អាជីវករមិនអាចទុកទទេបានទេ",
+	28: "រូបិយប័ណ្ណនេះមិនត្រូវបានគាំទ្រទេ",
+	30: "លេខសម្គាល់អាជីវករមិនអាចទុកទទេបានទេ",
+	31: "ធនាគារទទួលមិនអាចទុកទទេបានទេ",
+	32: "ប្រវែងលេខសម្គាល់អាជីវករមិនត្រឹមត្រូវ",
+	33: "ប្រវែងធនាគារទទួលមិនត្រឹមត្រូវ",
+	34: "ប្រវែងលេខទូរស័ព្ទមិនត្រឹមត្រូវ",
+	36: "ប្រវែងព័ត៌មានគណនីមិនត្រឹមត្រូវ",
+	37: "ភាសាជម្រើសមិនអាចទុកទទេបានទេ",
+	38: "ប្រវែងភាសាជម្រើសមិនត្រឹមត្រូវ",
+	39: "ឈ្មោះអាជីវករជាភាសាជម្រើសមិនអាចទុកទទេបានទេ",
+	40: "ប្រវែងឈ្មោះអាជីវករជាភាសាជម្រើសមិនត្រឹមត្រូវ",
+	41: "ប្រវែងទីក្រុងរបស់អាជីវករជាភាសាជម្រើសមិនត្រឹមត្រូវ",
+	42: "ប្រវែងគោលបំណងនៃប្រតិបត្តិការមិនត្រឹមត្រូវ",
+	43: "ប្រវែងព័ត៌មានគណនី UPI មិនត្រឹមត្រូវ",
+	44: "KHQR មិនគាំទ្រព័ត៌មានគណនី UPI ជាមួយរូបិយប័ណ្ណដុល្លារអាមេរិកទេ",
+	45: "KHQR ឌីណាមិកត្រូវការពេលវេលាផុតកំណត់",
+	46: "KHQR ឌីណាមិកនេះបានផុតកំណត់ហើយ",
+	47: "ចំនួនទឹកប្រាក់របស់ KHQR ឌីណាមិកនេះមិនត្រឹមត្រូវ",
+	48: "វិធីសាស្ត្រផ្តួចផ្តើមប្រតិបត្តិការមិនត្រឹមត្រូវ",
+	49: "ប្រវែងពេលវេលាផុតកំណត់មិនត្រឹមត្រូវ",
+	50: "ពេលវេលាផុតកំណត់បានកន្លងផុតទៅហើយ",
+	51: "លេខកូដប្រភេទអាជីវកម្មមិនត្រឹមត្រូវ",
+	52: "ប្រភេទអាជីវករមិនត្រឹមត្រូវ",
+	53: "ធនាគារក្នុងលេខសម្គាល់គណនីបាគងមិនមែនជាសមាជិកបាគងដែលស្គាល់ទេ",
+	54: "លេខកូដប្រភេទអាជីវកម្មមិនទាន់ត្រូវបានកំណត់",
+	55: "មិនអាចរកបានអត្រាប្តូរប្រាក់",
+	56: "រកមិនឃើញកំណត់ត្រា KHQR ដែលបានចេញ",
+	57: "កូនសោ idempotency នេះត្រូវបានប្រើរួចហើយជាមួយទិន្នន័យផ្សេងគ្នា",
+	58: "ហត្ថលេខា webhook មិនត្រឹមត្រូវ",
+	59: "គណនីបាគងនេះមិនមានទេ",
+	60: "ពេលវេលាផុតកំណត់តម្រូវឱ្យមានចំនួនទឹកប្រាក់ (KHQR ឌីណាមិក)",
+	61: "ការកំណត់រចនាសម្ព័ន្ធអាជីវករមិនត្រឹមត្រូវ",
+	62: "KHQR ដែលបោះពុម្ពត្រូវតែជាប្រភេទស្តាទិក (គ្មានចំនួនទឹកប្រាក់)",
+	63: "ពុម្ពអក្សរនេះមិនមែនជាពុម្ពអក្សរ TrueType ដែលគាំទ្រទេ",
+	64: "ពុម្ពអក្សរមិនមានតួអក្សរខ្លះដែលមានក្នុងអត្ថបទ",
+	65: "KHQR នេះមិនមានហត្ថលេខាទេ",
+	66: "ហត្ថលេខា KHQR មិនត្រឹមត្រូវ",
+	67: "មិនស្គាល់លេខសម្គាល់កូនសោនៃហត្ថលេខា KHQR",
+	68: "លេខសម្គាល់ ឬសោសម្ងាត់នៃកូនសោចុះហត្ថលេខាមិនត្រឹមត្រូវ",
+	69: "មិនអាចចុះឈ្មោះរូបិយប័ណ្ណ KHR និង USD ឡើងវិញបានទេ",
+	70: "ពុម្ពអក្សរគ្មានទម្រង់ជើងអក្សរសម្រាប់អត្ថបទខ្មែរនេះទេ",
+	71: "សោសម្ងាត់ webhook ខ្លីជាង 16 បៃ",
+}
@@ -8,64 +8,64 @@ import (
 // IndividualInfo contains information for generating an individual KHQR code.
 type IndividualInfo struct {
 	// Required fields.
-	BakongAccountID string // must contain "@" (e.g. "user@bank")
-	MerchantName    string // max 25 characters
+	BakongAccountID string `json:"bakong_account_id"` // must contain "@" (e.g. "user@bank")
+	MerchantName    string `json:"merchant_name"`     // max 25 characters
 
 	// Optional fields with defaults.
-	Currency             Currency // defaults to KHR
-	MerchantCity         string   // defaults to "Phnom Penh", max 15 characters
-	MerchantCategoryCode string   // defaults to "5999"
+	Currency             Currency `json:"currency,omitempty"`               // defaults to KHR
+	MerchantCity         string   `json:"merchant_city,omitempty"`          // defaults to "Phnom Penh", max 15 characters
+	MerchantCategoryCode string   `json:"merchant_category_code,omitempty"` // defaults to "5999"
 
 	// Optional fields (dynamic QR).
-	Amount              float64 // 0 means static QR; KHR must be whole, USD up to 2 decimals
-	ExpirationTimestamp int64   // unix ms, required when Amount > 0
+	Amount              float64 `json:"amount,omitempty"`               // 0 means static QR; KHR must be whole, USD up to 2 decimals
+	ExpirationTimestamp int64   `json:"expiration_timestamp,omitempty"` // unix ms, required when Amount > 0
 
 	// Optional fields.
-	AcquiringBank         string // max 32 characters
-	AccountInfo           string // max 32 characters
-	UPIAccountInfo        string // not supported with USD, max 99 characters
-	BillNumber            string // max 25 characters
-	StoreLabel            string // max 25 characters
-	TerminalLabel         string // max 25 characters
-	MobileNumber          string // max 25 characters
-	Purpose               string // max 25 characters
-	AltLanguagePreference string // ISO 639-1 (2 chars); requires AltMerchantName
-	AltMerchantName       string // required when AltLanguagePreference is set, max 25 characters
-	AltMerchantCity       string // max 15 characters
+	AcquiringBank         string `json:"acquiring_bank,omitempty"`          // max 32 characters
+	AccountInfo           string `json:"account_info,omitempty"`            // max 32 characters
+	UPIAccountInfo        string `json:"upi_account_info,omitempty"`        // not supported with USD, max 99 characters
+	BillNumber            string `json:"bill_number,omitempty"`             // max 25 characters
+	StoreLabel            string `json:"store_label,omitempty"`             // max 25 characters
+	TerminalLabel         string `json:"terminal_label,omitempty"`          // max 25 characters
+	MobileNumber          string `json:"mobile_number,omitempty"`           // max 25 characters
+	Purpose               string `json:"purpose,omitempty"`                 // max 25 characters
+	AltLanguagePreference string `json:"alt_language_preference,omitempty"` // ISO 639-1 (2 chars); requires AltMerchantName
+	AltMerchantName       string `json:"alt_merchant_name,omitempty"`       // required when AltLanguagePreference is set, max 25 characters
+	AltMerchantCity       string `json:"alt_merchant_city,omitempty"`       // max 15 characters
 }
 
 // MerchantInfo contains information for generating a merchant KHQR code.
 type MerchantInfo struct {
 	// Required fields.
-	BakongAccountID string // must contain "@" (e.g. "merchant@bank")
-	MerchantName    string // max 25 characters
-	MerchantCity    string // max 15 characters
-	MerchantID      string // max 32 characters
-	AcquiringBank   string // max 32 characters
+	BakongAccountID string `json:"bakong_account_id"` // must contain "@" (e.g. "merchant@bank")
+	MerchantName    string `json:"merchant_name"`     // max 25 characters
+	MerchantCity    string `json:"merchant_city"`     // max 15 characters
+	MerchantID      string `json:"merchant_id"`       // max 32 characters
+	AcquiringBank   string `json:"acquiring_bank"`    // max 32 characters
 
 	// Optional fields with defaults.
-	Currency             Currency // defaults to KHR
-	MerchantCategoryCode string   // defaults to "5999"
+	Currency             Currency `json:"currency,omitempty"`               // defaults to KHR
+	MerchantCategoryCode string   `json:"merchant_category_code,omitempty"` // defaults to "5999"
 
 	// Optional fields (dynamic QR).
-	Amount              float64 // 0 means static QR; KHR must be whole, USD up to 2 decimals
-	ExpirationTimestamp int64   // unix ms, required when Amount > 0
+	Amount              float64 `json:"amount,omitempty"`               // 0 means static QR; KHR must be whole, USD up to 2 decimals
+	ExpirationTimestamp int64   `json:"expiration_timestamp,omitempty"` // unix ms, required when Amount > 0
 
 	// Optional fields.
-	UPIAccountInfo        string // not supported with USD, max 99 characters
-	BillNumber            string // max 25 characters
-	StoreLabel            string // max 25 characters
-	TerminalLabel         string // max 25 characters
-	MobileNumber          string // max 25 characters
-	Purpose               string // max 25 characters
-	AltLanguagePreference string // ISO 639-1 (2 chars); requires AltMerchantName
-	AltMerchantName       string // required when AltLanguagePreference is set, max 25 characters
-	AltMerchantCity       string // max 15 characters
+	UPIAccountInfo        string `json:"upi_account_info,omitempty"`        // not supported with USD, max 99 characters
+	BillNumber            string `json:"bill_number,omitempty"`             // max 25 characters
+	StoreLabel            string `json:"store_label,omitempty"`             // max 25 characters
+	TerminalLabel         string `json:"terminal_label,omitempty"`          // max 25 characters
+	MobileNumber          string `json:"mobile_number,omitempty"`           // max 25 characters
+	Purpose               string `json:"purpose,omitempty"`                 // max 25 characters
+	AltLanguagePreference string `json:"alt_language_preference,omitempty"` // ISO 639-1 (2 chars); requires AltMerchantName
+	AltMerchantName       string `json:"alt_merchant_name,omitempty"`       // required when AltLanguagePreference is set, max 25 characters
+	AltMerchantCity       string `json:"alt_merchant_city,omitempty"`       // max 15 characters
 }
 
 // Data contains the generated QR string.
 type Data struct {
-	QR string
+	QR string `json:"qr"`
 }
 
 // String returns the QR payload string.
@@ -81,29 +81,30 @@ func (d *Data) MD5() string {
 
 // DecodedData contains all decoded fields from a KHQR string.
 type DecodedData struct {
-	PayloadFormatIndicator  string
-	PointOfInitiationMethod string
-	BakongAccountID         string
-	MerchantID              string
-	AccountInfo             string
-	AcquiringBank           string
-	MerchantType            MerchantType
-	TransactionCurrency     string
-	MerchantName            string
-	TransactionAmount       string
-	MerchantCategoryCode    string
-	CountryCode             string
-	MerchantCity            string
-	BillNumber              string
-	StoreLabel              string
-	TerminalLabel           string
-	MobileNumber            string
-	CreationTimestamp       string
-	ExpirationTimestamp     string
-	CRC                     string
-	UPIAccountInfo          string
-	Purpose                 string
-	AltLanguagePreference   string
-	AltMerchantName         string
-	AltMerchantCity         string
+	PayloadFormatIndicator  string       `json:"payload_format_indicator"`
+	PointOfInitiationMethod string       `json:"point_of_initiation_method"`
+	BakongAccountID         string       `json:"bakong_account_id"`
+	MerchantID              string       `json:"merchant_id"`
+	AccountInfo             string       `json:"account_info"`
+	AcquiringBank           string       `json:"acquiring_bank"`
+	MerchantType            MerchantType `json:"merchant_type"`
+	TransactionCurrency     string       `json:"transaction_currency"`
+	MerchantName            string       `json:"merchant_name"`
+	TransactionAmount       string       `json:"transaction_amount"`
+	MerchantCategoryCode    string       `json:"merchant_category_code"`
+	MerchantCategory        string       `json:"merchant_category"` // catalog description of MerchantCategoryCode, empty if unassigned
+	CountryCode             string       `json:"country_code"`
+	MerchantCity            string       `json:"merchant_city"`
+	BillNumber              string       `json:"bill_number"`
+	StoreLabel              string       `json:"store_label"`
+	TerminalLabel           string       `json:"terminal_label"`
+	MobileNumber            string       `json:"mobile_number"`
+	CreationTimestamp       string       `json:"creation_timestamp"`
+	ExpirationTimestamp     string       `json:"expiration_timestamp"`
+	CRC                     string       `json:"crc"`
+	UPIAccountInfo          string       `json:"upi_account_info"`
+	Purpose                 string       `json:"purpose"`
+	AltLanguagePreference   string       `json:"alt_language_preference"`
+	AltMerchantName         string       `json:"alt_merchant_name"`
+	AltMerchantCity         string       `json:"alt_merchant_city"`
 }
@@ -0,0 +1,177 @@
+package khqr
+
+import (
+	"encoding/binary"
+	"slices"
+)
+
+// This file reads the parts of the OpenType layout tables (GSUB, GPOS and
+// GDEF) needed to shape Khmer: single and ligature substitutions, and
+// mark-to-base and mark-to-mark attachment. Offsets come from the font, so
+// every read is bounds-checked and a damaged table reads as empty.
+
+const (
+	gsubSingle    = 1
+	gsubLigature  = 4
+	gsubExtension = 7
+	gposMarkBase  = 4
+	gposMarkMark  = 6
+	gposExtension = 9
+	gdefMarkClass = 3
+)
+
+// otU16 reads the big-endian uint16 at off, or 0 past the end of t.
+func otU16(t []byte, off int) int {
+	if off < 0 || off+2 > len(t) {
+		return 0
+	}
+	return int(binary.BigEndian.Uint16(t[off:]))
+}
+
+// otI16 reads the signed 16-bit value at off.
+func otI16(t []byte, off int) int {
+	return int(int16(otU16(t, off))) //nolint:gosec // signed field
+}
+
+//nolint:mnd // 32-bit offset
+func otU32(t []byte, off int) int {
+	return otU16(t, off)<<16 | otU16(t, off+2)
+}
+
+// otSub returns the table at off within t. A zero offset is NULL.
+func otSub(t []byte, off int) []byte {
+	if off <= 0 || off >= len(t) {
+		return nil
+	}
+	return t[off:]
+}
+
+// otLookups returns the indices of the lookups that features use in the
+// default language system of script, falling back to the DFLT script, in
+// lookup list order.
+//
+//nolint:mnd // table layout from the OpenType specification
+func otLookups(table []byte, script string, features ...string) []int {
+	scripts := otSub(table, otU16(table, 4))
+	featureList := otSub(table, otU16(table, 6))
+	var langSys []byte
+	for _, tag := range []string{script, "DFLT"} {
+		if s := otTagged(scripts, tag); s != nil {
+			if langSys = otSub(s, otU16(s, 0)); langSys == nil && otU16(s, 2) > 0 {
+				langSys = otSub(s, otU16(s, 8)) // first language system
+			}
+			break
+		}
+	}
+	var lookups []int
+	for i := range otU16(langSys, 4) {
+		rec := 2 + otU16(langSys, 6+i*2)*6
+		if rec+6 > len(featureList) || !slices.Contains(features, string(featureList[rec:rec+4])) {
+			continue
+		}
+		feature := otSub(featureList, otU16(featureList, rec+4))
+		for j := range otU16(feature, 2) {
+			if l := otU16(feature, 4+j*2); !slices.Contains(lookups, l) {
+				lookups = append(lookups, l)
+			}
+		}
+	}
+	slices.Sort(lookups)
+	return lookups
+}
+
+// otTagged finds tag in a list of tag and offset records.
+//
+//nolint:mnd // record layout from the OpenType specification
+func otTagged(list []byte, tag string) []byte {
+	for i := range otU16(list, 0) {
+		rec := 2 + i*6
+		if rec+6 <= len(list) && string(list[rec:rec+4]) == tag {
+			return otSub(list, otU16(list, rec+4))
+		}
+	}
+	return nil
+}
+
+// otLookup returns the type and subtables of lookup i, unwrapping
+// extension subtables of type extension.
+//
+//nolint:mnd // table layout from the OpenType specification
+func otLookup(table []byte, i, extension int) (kind int, subtables [][]byte) {
+	list := otSub(table, otU16(table, 8))
+	if i >= otU16(list, 0) {
+		return 0, nil
+	}
+	lookup := otSub(list, otU16(list, 2+i*2))
+	kind = otU16(lookup, 0)
+	declared := kind
+	for j := range otU16(lookup, 4) {
+		sub := otSub(lookup, otU16(lookup, 6+j*2))
+		if declared == extension {
+			kind = otU16(sub, 2)
+			sub = otSub(sub, otU32(sub, 4))
+		}
+		subtables = append(subtables, sub)
+	}
+	return kind, subtables
+}
+
+// otCoverage returns the coverage index of g, or -1 if it is not covered.
+//
+//nolint:mnd // table layout from the OpenType specification
+func otCoverage(t []byte, g uint16) int {
+	switch otU16(t, 0) {
+	case 1:
+		lo, hi := 0, otU16(t, 2) // glyphs are sorted
+		for lo < hi {
+			mid := (lo + hi) / 2
+			switch v := otU16(t, 4+mid*2); {
+			case v == int(g):
+				return mid
+			case v < int(g):
+				lo = mid + 1
+			default:
+				hi = mid
+			}
+		}
+	case 2:
+		for i := range otU16(t, 2) {
+			rec := 4 + i*6
+			if start := otU16(t, rec); int(g) >= start && int(g) <= otU16(t, rec+2) {
+				return otU16(t, rec+4) + int(g) - start
+			}
+		}
+	}
+	return -1
+}
+
+// otClass returns the class of g in a class definition table.
+//
+//nolint:mnd // table layout from the OpenType specification
+func otClass(t []byte, g uint16) int {
+	switch otU16(t, 0) {
+	case 1:
+		if i := int(g) - otU16(t, 2); i >= 0 && i < otU16(t, 4) {
+			return otU16(t, 6+i*2)
+		}
+	case 2:
+		for i := range otU16(t, 2) {
+			rec := 4 + i*6
+			if int(g) >= otU16(t, rec) && int(g) <= otU16(t, rec+2) {
+				return otU16(t, rec+4)
+			}
+		}
+	}
+	return 0
+}
+
+// otAnchor reads the coordinates of an anchor table. Device and contour
+// point refinements of formats 2 and 3 are ignored.
+//
+//nolint:mnd // table layout from the OpenType specification
+func otAnchor(t []byte) (x, y int, ok bool) {
+	if t == nil {
+		return 0, 0, false
+	}
+	return otI16(t, 2), otI16(t, 4), true
+}
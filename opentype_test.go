@@ -0,0 +1,57 @@
+package khqr
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOpenTypeReaders(t *testing.T) {
+	t.Parallel()
+
+	table := func(vs ...int) []byte {
+		var b bytes.Buffer
+		u16(&b, vs...)
+		return b.Bytes()
+	}
+	coverage := []struct {
+		name  string
+		table []byte
+		glyph uint16
+		want  int
+	}{
+		{"list", table(1, 3, 4, 9, 12), 9, 1},
+		{"list_missing", table(1, 3, 4, 9, 12), 10, -1},
+		{"ranges", table(2, 2, 4, 6, 0, 20, 22, 3), 21, 4},
+		{"truncated", table(1, 200, 4), 9, -1},
+		{"empty", nil, 1, -1},
+	}
+	for _, tt := range coverage {
+		if got := otCoverage(tt.table, tt.glyph); got != tt.want {
+			t.Errorf("%s: otCoverage(%d) = %d, want %d", tt.name, tt.glyph, got, tt.want)
+		}
+	}
+
+	classes := []struct {
+		name  string
+		table []byte
+		glyph uint16
+		want  int
+	}{
+		{"array", table(1, 5, 2, 1, 3), 6, 3},
+		{"array_outside", table(1, 5, 2, 1, 3), 7, 0},
+		{"ranges", table(2, 1, 10, 19, 3), 15, 3},
+		{"truncated", table(2, 9), 15, 0},
+	}
+	for _, tt := range classes {
+		if got := otClass(tt.table, tt.glyph); got != tt.want {
+			t.Errorf("%s: otClass(%d) = %d, want %d", tt.name, tt.glyph, got, tt.want)
+		}
+	}
+
+	if got := otLookups(table(1, 0, 60000, 60000, 60000), "khmr", "blwf"); got != nil {
+		t.Errorf("otLookups(bad offsets) = %v, want none", got)
+	}
+	if kind, subs := otLookup(table(1, 0, 0, 0, 10, 5), 3, gsubExtension); kind != 0 || subs != nil {
+		t.Errorf("otLookup(out of range) = %d, %v", kind, subs)
+	}
+}
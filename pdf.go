@@ -0,0 +1,408 @@
+package khqr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// This file is a small PDF 1.4 writer: just enough to place filled
+// rectangles, lines and text on pages, with either the built-in Helvetica
+// font or an embedded TrueType font.
+
+// pdfDoc collects indirect objects and serializes them with a cross-reference
+// table. Object numbers start at 1.
+type pdfDoc struct {
+	objs [][]byte
+}
+
+// reserve allocates an object number to be filled in with set.
+func (d *pdfDoc) reserve() int {
+	d.objs = append(d.objs, nil)
+	return len(d.objs)
+}
+
+func (d *pdfDoc) set(id int, body string) {
+	d.objs[id-1] = []byte(body)
+}
+
+func (d *pdfDoc) add(body string) int {
+	id := d.reserve()
+	d.set(id, body)
+	return id
+}
+
+// addStream adds a Flate-compressed stream. extra is inserted into the
+// stream dictionary.
+func (d *pdfDoc) addStream(extra string, data []byte) int {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	_, _ = zw.Write(data) // writes to a bytes.Buffer cannot fail
+	_ = zw.Close()
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<< /Length %d /Filter /FlateDecode%s >>\nstream\n", z.Len(), extra)
+	b.Write(z.Bytes())
+	b.WriteString("\nendstream")
+	id := d.reserve()
+	d.objs[id-1] = b.Bytes()
+	return id
+}
+
+// writeTo writes the document with root as the catalog.
+func (d *pdfDoc) writeTo(w io.Writer, root int) error {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	offsets := make([]int, len(d.objs))
+	for i, body := range d.objs {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n", i+1)
+		b.Write(body)
+		b.WriteString("\nendobj\n")
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(d.objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(d.objs)+1, root, xref)
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// pdfFont lays out and encodes text for one PDF font resource.
+type pdfFont interface {
+	// width returns the width of s at size points.
+	width(s string, size float64) float64
+	// show returns the text-showing operators that draw s at size points.
+	show(s string, size float64) string
+	// check reports ErrFontGlyphMissing or ErrTextShapingUnsupported if s
+	// cannot be drawn.
+	check(s string) error
+	// embed adds the font objects to d, with the font dictionary at id.
+	embed(d *pdfDoc, id int)
+}
+
+// helveticaWidths are the advance widths of ASCII 32-126 in Helvetica,
+// in thousandths of an em.
+var helveticaWidths = [...]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space - /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 - ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ - O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P - _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` - o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p - ~
+}
+
+// helvetica is the built-in standard font. It needs no embedding but only
+// covers printable ASCII.
+type helvetica struct{}
+
+func (helvetica) width(s string, size float64) float64 {
+	w := 0
+	for _, r := range s {
+		if r >= ' ' && r <= '~' {
+			w += helveticaWidths[r-' ']
+		}
+	}
+	return float64(w) * size / 1000
+}
+
+func (helvetica) show(s string, _ float64) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		if r == '(' || r == ')' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteString(") Tj")
+	return b.String()
+}
+
+func (helvetica) check(s string) error {
+	for _, r := range s {
+		if r < ' ' || r > '~' {
+			return ErrFontGlyphMissing
+		}
+	}
+	return nil
+}
+
+func (helvetica) embed(d *pdfDoc, id int) {
+	d.set(id, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+}
+
+// embeddedFont draws text with a TrueType font embedded as a CIDFontType2
+// with Identity-H encoding, so shaped glyph IDs are written directly.
+type embeddedFont struct {
+	ttf  *trueTypeFont
+	used map[uint16]string // glyphs drawn so far and their text, for widths and ToUnicode
+}
+
+func newEmbeddedFont(ttf *trueTypeFont) *embeddedFont {
+	return &embeddedFont{ttf: ttf, used: map[uint16]string{}}
+}
+
+func (f *embeddedFont) width(s string, size float64) float64 {
+	gs, _ := f.ttf.shape(s)
+	w := 0.0
+	for _, g := range gs {
+		w += g.advance
+	}
+	return w * size / 1000
+}
+
+// show draws the shaped glyphs of s. Glyphs that shaping moved off the pen
+// position are placed with TJ adjustments and a text rise.
+func (f *embeddedFont) show(s string, size float64) string {
+	gs, _ := f.ttf.shape(s)
+	var ops, arr []string // completed operators; TJ operands at the current rise
+	var hex strings.Builder
+	endHex := func() {
+		if hex.Len() > 0 {
+			arr = append(arr, "<"+hex.String()+">")
+			hex.Reset()
+		}
+	}
+	endRun := func() {
+		endHex()
+		switch {
+		case len(arr) == 1 && arr[0][0] == '<':
+			ops = append(ops, arr[0]+" Tj")
+		case len(arr) > 0:
+			ops = append(ops, "["+strings.Join(arr, " ")+"] TJ")
+		}
+		arr = nil
+	}
+	rise, pen, x := 0.0, 0.0, 0.0 // pen is where the viewer's pen is, x where ours is
+	for _, g := range gs {
+		if text, seen := f.used[g.id]; !seen || text == "" {
+			f.used[g.id] = g.text
+		}
+		if g.dy != rise {
+			endRun()
+			ops = append(ops, pdfNum(g.dy*size/1000)+" Ts")
+			rise = g.dy
+		}
+		at := x + g.dx
+		if adj := pdfNum(pen - at); adj != "0" {
+			endHex()
+			arr = append(arr, adj)
+		}
+		fmt.Fprintf(&hex, "%04X", g.id)
+		pen = at + g.advance
+		x += g.advance
+	}
+	endRun()
+	if rise != 0 {
+		ops = append(ops, "0 Ts")
+	}
+	if len(ops) == 0 {
+		return "<> Tj"
+	}
+	return strings.Join(ops, " ")
+}
+
+func (f *embeddedFont) check(s string) error {
+	_, err := f.ttf.shape(s)
+	return err
+}
+
+func (f *embeddedFont) embed(d *pdfDoc, id int) {
+	t := f.ttf
+	file := d.addStream(fmt.Sprintf(" /Length1 %d", len(t.data)), t.data)
+	desc := d.add(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [%d %d %d %d] "+
+		"/ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		t.name, t.scale(t.bbox[0]), t.scale(t.bbox[1]), t.scale(t.bbox[2]), t.scale(t.bbox[3]),
+		t.scale(t.ascent), t.scale(t.descent), t.scale(t.capHeight), file))
+
+	gids := make([]uint16, 0, len(f.used))
+	for g := range f.used {
+		gids = append(gids, g)
+	}
+	slices.Sort(gids)
+	var widths strings.Builder
+	for _, g := range gids {
+		fmt.Fprintf(&widths, " %d [%s]", g, pdfNum(t.advance(g)))
+	}
+	cid := d.add(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+		"/FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [%s ] >>", t.name, desc, widths.String()))
+	// Glyphs inserted by shaping stand for no text of their own.
+	mapped := slices.DeleteFunc(slices.Clone(gids), func(g uint16) bool { return f.used[g] == "" })
+	toUnicode := d.addStream("", f.toUnicode(mapped))
+	d.set(id, fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H "+
+		"/DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>", t.name, cid, toUnicode))
+}
+
+// toUnicode builds the CMap that lets viewers copy and search the text.
+func (f *embeddedFont) toUnicode(gids []uint16) []byte {
+	const perBlock = 100 // limit of entries per bfchar block
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for chunk := range slices.Chunk(gids, perBlock) {
+		fmt.Fprintf(&b, "%d beginbfchar\n", len(chunk))
+		for _, g := range chunk {
+			fmt.Fprintf(&b, "<%04X> <", g)
+			for _, u := range utf16.Encode([]rune(f.used[g])) {
+				fmt.Fprintf(&b, "%04X", u)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CIDFont defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+// isFormatRune reports whether r is an invisible joiner or space that may
+// be dropped when a font has no glyph for it. Khmer text commonly uses
+// zero width spaces to mark word boundaries.
+func isFormatRune(r rune) bool {
+	switch r {
+	case '\u200B', '\u200C', '\u200D', '\u2060', '\uFEFF':
+		return true
+	}
+	return false
+}
+
+// pdfRect is a rectangle in points, origin at the bottom left of the page.
+type pdfRect struct {
+	x, y, w, h float64
+}
+
+func (r pdfRect) inset(dx, dy float64) pdfRect {
+	return pdfRect{r.x + dx, r.y + dy, r.w - 2*dx, r.h - 2*dy}
+}
+
+// pdfCanvas builds a page content stream.
+type pdfCanvas struct {
+	b    bytes.Buffer
+	font pdfFont
+}
+
+// pdfNum formats v with at most two decimals, plenty at 1/72 inch.
+func pdfNum(v float64) string {
+	s := strings.TrimRight(strconv.FormatFloat(v, 'f', 2, 64), "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+func (c *pdfCanvas) op(format string, args ...float64) {
+	parts := make([]any, len(args))
+	for i, a := range args {
+		parts[i] = pdfNum(a)
+	}
+	fmt.Fprintf(&c.b, format+"\n", parts...)
+}
+
+// fill sets the fill color from a 0xRRGGBB value.
+func (c *pdfCanvas) fill(rgb int) {
+	c.op("%s %s %s rg", rgbComponents(rgb)...)
+}
+
+// stroke sets the stroke color and line width.
+func (c *pdfCanvas) stroke(rgb int, width float64) {
+	c.op("%s %s %s RG %s w", append(rgbComponents(rgb), width)...)
+}
+
+//nolint:mnd // 8-bit color channels
+func rgbComponents(rgb int) []float64 {
+	return []float64{float64(rgb>>16&0xFF) / 255, float64(rgb>>8&0xFF) / 255, float64(rgb&0xFF) / 255}
+}
+
+func (c *pdfCanvas) rect(r pdfRect) {
+	c.op("%s %s %s %s re f", r.x, r.y, r.w, r.h)
+}
+
+func (c *pdfCanvas) line(x1, y1, x2, y2 float64) {
+	c.op("%s %s m %s %s l S", x1, y1, x2, y2)
+}
+
+func (c *pdfCanvas) dash(on float64) {
+	if on == 0 {
+		c.b.WriteString("[] 0 d\n")
+		return
+	}
+	c.op("[%s] 0 d", on)
+}
+
+// save pushes the graphics state and applies the transformation matrix
+// [a b c d e f]; restore pops it.
+func (c *pdfCanvas) save(m ...float64) {
+	c.b.WriteString("q\n")
+	if len(m) > 0 {
+		c.op("%s %s %s %s %s %s cm", m...)
+	}
+}
+
+func (c *pdfCanvas) restore() {
+	c.b.WriteString("Q\n")
+}
+
+// text draws s with its baseline starting at x, y.
+func (c *pdfCanvas) text(x, y, size float64, s string) {
+	c.op("BT /F1 %s Tf %s %s Td", size, x, y)
+	c.b.WriteString(c.font.show(s, size))
+	c.b.WriteString(" ET\n")
+}
+
+// centeredText draws s centered on cx, shrinking size until it fits in
+// maxWidth. It returns the size used.
+func (c *pdfCanvas) centeredText(cx, y, size, maxWidth float64, s string) float64 {
+	if w := c.font.width(s, size); w > maxWidth {
+		size *= maxWidth / w
+	}
+	c.text(cx-c.font.width(s, size)/2, y, size, s)
+	return size
+}
+
+// qr draws q with its quiet zone filling the square at x, y with side
+// length side. Adjacent dark modules in a row are merged into one rectangle.
+func (c *pdfCanvas) qr(q *qrCode, x, y, side float64) {
+	const quiet = 4 // quiet zone in modules, required by the standard
+	m := side / float64(q.size+2*quiet)
+	top := y + side - quiet*m
+	for row := range q.size {
+		for col := 0; col < q.size; {
+			if !q.dark(col, row) {
+				col++
+				continue
+			}
+			start := col
+			for col < q.size && q.dark(col, row) {
+				col++
+			}
+			c.rect(pdfRect{x + (quiet+float64(start))*m, top - float64(row+1)*m, float64(col-start) * m, m})
+		}
+	}
+}
+
+// cropMarks draws trim marks outside the corners of r.
+func (c *pdfCanvas) cropMarks(r pdfRect, offset, length float64) {
+	for _, x := range []float64{r.x, r.x + r.w} {
+		for _, y := range []float64{r.y, r.y + r.h} {
+			dx, dy := -1.0, -1.0
+			if x > r.x {
+				dx = 1
+			}
+			if y > r.y {
+				dy = 1
+			}
+			c.line(x+dx*offset, y, x+dx*(offset+length), y)
+			c.line(x, y+dy*offset, x, y+dy*(offset+length))
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package khqr
+
+import "time"
+
+// Profile is a merchant or individual whose fixed fields have been
+// validated and encoded once, for issuing many dynamic codes where only
+// the amount, bill number and expiration change.
+type Profile struct {
+	tmpl *qrTemplate
+}
+
+// NewMerchantProfile validates info and pre-encodes its fixed fields.
+// Amount, ExpirationTimestamp and BillNumber are ignored; they are
+// supplied to Issue.
+func NewMerchantProfile(info MerchantInfo) (*Profile, error) { //nolint:gocritic // fields are cleared on the copy
+	info.Amount, info.ExpirationTimestamp, info.BillNumber = 0, 0, ""
+	info.applyDefaults()
+	if err := info.validate(); err != nil {
+		return nil, err
+	}
+	return &Profile{tmpl: newQRTemplate(info.qrParams())}, nil
+}
+
+// NewIndividualProfile validates info and pre-encodes its fixed fields.
+// Amount, ExpirationTimestamp and BillNumber are ignored; they are
+// supplied to Issue.
+func NewIndividualProfile(info IndividualInfo) (*Profile, error) { //nolint:gocritic // fields are cleared on the copy
+	info.Amount, info.ExpirationTimestamp, info.BillNumber = 0, 0, ""
+	info.applyDefaults()
+	if err := info.validate(); err != nil {
+		return nil, err
+	}
+	return &Profile{tmpl: newQRTemplate(info.qrParams())}, nil
+}
+
+// Currency returns the profile's transaction currency.
+func (p *Profile) Currency() Currency {
+	return p.tmpl.currency
+}
+
+// Issue generates a dynamic code for amount, validating only the fields
+// that vary. The result is identical to GenerateMerchant or
+// GenerateIndividual with the same inputs. Issue is safe for concurrent use.
+func (p *Profile) Issue(amount float64, billNumber string, expiry time.Time) (*Data, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if err := validateAmount(amount, p.tmpl.currency); err != nil {
+		return nil, err
+	}
+	if err := validateOptionalField(billNumber, maxBillNumberLength, ErrBillNumberTooLong); err != nil {
+		return nil, err
+	}
+	var expiration int64
+	if !expiry.IsZero() {
+		expiration = expiry.UnixMilli()
+	}
+	if err := validateTimestamp(expiration, amount); err != nil {
+		return nil, err
+	}
+	return p.tmpl.render(amount, billNumber, expiration), nil
+}
@@ -0,0 +1,152 @@
+package khqr
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var profileMerchant = MerchantInfo{
+	BakongAccountID:       "jonhsmith@devb",
+	MerchantName:          "Jonh Smith",
+	MerchantCity:          "Phnom Penh",
+	MerchantID:            "123456",
+	AcquiringBank:         "Dev Bank",
+	Currency:              USD,
+	StoreLabel:            "BKK-1",
+	TerminalLabel:         "Counter 2",
+	AltLanguagePreference: "km",
+	AltMerchantName:       "ចន ស្មីន",
+}
+
+// withoutCreation clears the fields that differ between two codes
+// generated at different instants.
+func withoutCreation(t *testing.T, qr string) DecodedData {
+	t.Helper()
+	d, err := Decode(qr)
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	d.CreationTimestamp, d.CRC = "", ""
+	return *d
+}
+
+func TestProfileIssueMatchesGenerate(t *testing.T) {
+	t.Parallel()
+
+	expiry := time.Now().Add(5 * time.Minute)
+	tests := []struct {
+		name   string
+		amount float64
+		bill   string
+	}{
+		{"with_bill", 12.5, "INV-1"},
+		{"without_bill", 3, ""},
+	}
+
+	p, err := NewMerchantProfile(profileMerchant)
+	if err != nil {
+		t.Fatalf("NewMerchantProfile() unexpected error: %v", err)
+	}
+	for _, tt := range tests {
+		got, err := p.Issue(tt.amount, tt.bill, expiry)
+		if err != nil {
+			t.Fatalf("%s: Issue() unexpected error: %v", tt.name, err)
+		}
+		if err := Verify(got.QR); err != nil {
+			t.Errorf("%s: Verify(Issue()) = %v", tt.name, err)
+		}
+		info := profileMerchant
+		info.Amount, info.BillNumber, info.ExpirationTimestamp = tt.amount, tt.bill, expiry.UnixMilli()
+		want, err := GenerateMerchant(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if withoutCreation(t, got.QR) != withoutCreation(t, want.QR) {
+			t.Errorf("%s: Issue() = %s\nGenerateMerchant() = %s", tt.name, got.QR, want.QR)
+		}
+	}
+
+	ip, err := NewIndividualProfile(IndividualInfo{BakongAccountID: "jonhsmith@nbcq", MerchantName: "Jonh Smith", MobileNumber: "85512345678"})
+	if err != nil {
+		t.Fatalf("NewIndividualProfile() unexpected error: %v", err)
+	}
+	got, err := ip.Issue(5000, "INV-2", expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := GenerateIndividual(IndividualInfo{
+		BakongAccountID: "jonhsmith@nbcq", MerchantName: "Jonh Smith", MobileNumber: "85512345678",
+		Amount: 5000, BillNumber: "INV-2", ExpirationTimestamp: expiry.UnixMilli(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withoutCreation(t, got.QR) != withoutCreation(t, want.QR) {
+		t.Errorf("individual Issue() = %s\nGenerateIndividual() = %s", got.QR, want.QR)
+	}
+	if ip.Currency() != KHR {
+		t.Errorf("Currency() = %v, want %v", ip.Currency(), KHR)
+	}
+}
+
+func TestProfileErrors(t *testing.T) {
+	t.Parallel()
+
+	bad := profileMerchant
+	bad.MerchantID = ""
+	if _, err := NewMerchantProfile(bad); !errors.Is(err, ErrMerchantIDRequired) {
+		t.Errorf("NewMerchantProfile() error = %v, want %v", err, ErrMerchantIDRequired)
+	}
+
+	p, err := NewMerchantProfile(profileMerchant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	tests := []struct {
+		name    string
+		amount  float64
+		bill    string
+		expiry  time.Time
+		wantErr error
+	}{
+		{"zero_amount", 0, "", future, ErrInvalidAmount},
+		{"negative_amount", -1, "", future, ErrInvalidAmount},
+		{"usd_precision", 1.234, "", future, ErrInvalidAmount},
+		{"bill_too_long", 1, "INV-0123456789-0123456789X", future, ErrBillNumberTooLong},
+		{"no_expiry", 1, "", time.Time{}, ErrExpirationRequired},
+		{"expired", 1, "", time.Now().Add(-time.Minute), ErrExpirationInPast},
+	}
+	for _, tt := range tests {
+		if _, err := p.Issue(tt.amount, tt.bill, tt.expiry); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: Issue() error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func BenchmarkGenerateMerchant(b *testing.B) {
+	info := profileMerchant
+	info.Amount, info.BillNumber = 12.5, "INV-1"
+	info.ExpirationTimestamp = time.Now().Add(time.Hour).UnixMilli()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := GenerateMerchant(info); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProfileIssue(b *testing.B) {
+	p, err := NewMerchantProfile(profileMerchant)
+	if err != nil {
+		b.Fatal(err)
+	}
+	expiry := time.Now().Add(time.Hour)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := p.Issue(12.5, "INV-1", expiry); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,553 @@
+package khqr
+
+// This file is a minimal QR Code (ISO/IEC 18004) encoder used to render KHQR
+// payloads without an image dependency. It supports byte mode only, which is
+// all a KHQR string needs, at every version and error correction level.
+
+// qrLevel is a QR error correction level.
+type qrLevel int
+
+const (
+	qrLevelL qrLevel = iota // ~7% recovery
+	qrLevelM                // ~15% recovery
+	qrLevelQ                // ~25% recovery
+	qrLevelH                // ~30% recovery
+)
+
+// formatBits is the two-bit level indicator stored in the format information.
+func (l qrLevel) formatBits() int {
+	return [...]int{1, 0, 3, 2}[l]
+}
+
+const (
+	qrMinVersion = 1
+	qrMaxVersion = 40
+)
+
+// Error correction codewords per block, indexed by level then version;
+// each row is split after version 20.
+var qrECCPerBlock = [4][41]int{
+	{
+		-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28,
+		28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30,
+	},
+	{
+		-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26,
+		26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28,
+	},
+	{
+		-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30,
+		28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30,
+	},
+	{
+		-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28,
+		30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30,
+	},
+}
+
+// Error correction blocks, indexed by level then version; each row is
+// split after version 20.
+var qrNumBlocks = [4][41]int{
+	{
+		-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8,
+		8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25,
+	},
+	{
+		-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16,
+		17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49,
+	},
+	{
+		-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20,
+		23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68,
+	},
+	{
+		-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25,
+		25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81,
+	},
+}
+
+// qrCode is an encoded QR Code symbol: a square grid of dark and light
+// modules, without the quiet zone.
+type qrCode struct {
+	size     int
+	modules  []bool // row-major, true is dark
+	function []bool // modules reserved for patterns, nil once encoded
+}
+
+// encodeQR encodes data in byte mode at the smallest version that fits at
+// the given level.
+//
+//nolint:mnd // symbol size and mask count from the standard
+func encodeQR(data []byte, level qrLevel) (*qrCode, error) {
+	version := qrMinVersion
+	for ; version <= qrMaxVersion; version++ {
+		if qrDataBits(len(data), version) <= qrDataCodewords(version, level)*8 {
+			break
+		}
+	}
+	if version > qrMaxVersion {
+		return nil, ErrInvalidQR
+	}
+	codewords := qrAddECC(qrDataSegment(data, version, level), version, level)
+
+	q := &qrCode{size: version*4 + 17}
+	q.modules = make([]bool, q.size*q.size)
+	q.function = make([]bool, q.size*q.size)
+	q.drawFunctionPatterns(version)
+	q.drawCodewords(codewords)
+
+	best, bestPenalty := 0, -1
+	for mask := range 8 {
+		q.applyMask(mask)
+		q.drawFormatBits(level, mask)
+		if p := q.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		q.applyMask(mask) // XOR again to undo
+	}
+	q.applyMask(best)
+	q.drawFormatBits(level, best)
+	q.function = nil
+	return q, nil
+}
+
+// dark reports whether the module at column x, row y is dark. Coordinates
+// outside the symbol are light, which makes the quiet zone implicit.
+func (q *qrCode) dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= q.size || y >= q.size {
+		return false
+	}
+	return q.modules[y*q.size+x]
+}
+
+func (q *qrCode) setFunction(x, y int, dark bool) {
+	q.modules[y*q.size+x] = dark
+	q.function[y*q.size+x] = true
+}
+
+//nolint:mnd // pattern coordinates from the standard
+func (q *qrCode) drawFunctionPatterns(version int) {
+	for i := range q.size {
+		q.setFunction(6, i, i%2 == 0)
+		q.setFunction(i, 6, i%2 == 0)
+	}
+	q.drawFinder(3, 3)
+	q.drawFinder(q.size-4, 3)
+	q.drawFinder(3, q.size-4)
+
+	pos := qrAlignmentPositions(version)
+	n := len(pos)
+	for i, y := range pos {
+		for j, x := range pos {
+			if (i == 0 && j == 0) || (i == 0 && j == n-1) || (i == n-1 && j == 0) {
+				continue // overlaps a finder pattern
+			}
+			q.drawAlignment(x, y)
+		}
+	}
+
+	q.drawFormatBits(qrLevelL, 0) // reserve the area; overwritten later
+	q.drawVersion(version)
+}
+
+//nolint:mnd // finder pattern geometry
+func (q *qrCode) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= q.size || y >= q.size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			q.setFunction(x, y, d != 2 && d != 4)
+		}
+	}
+}
+
+func (q *qrCode) drawAlignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			q.setFunction(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+//nolint:mnd // format information layout from the standard
+func (q *qrCode) drawFormatBits(level qrLevel, mask int) {
+	data := level.formatBits()<<3 | mask
+	rem := data
+	for range 10 {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>i)&1 != 0 }
+
+	// Around the top-left finder.
+	for i := range 6 {
+		q.setFunction(8, i, bit(i))
+	}
+	q.setFunction(8, 7, bit(6))
+	q.setFunction(8, 8, bit(7))
+	q.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.setFunction(14-i, 8, bit(i))
+	}
+
+	// Split between the other two finders.
+	for i := range 8 {
+		q.setFunction(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunction(8, q.size-15+i, bit(i))
+	}
+	q.setFunction(8, q.size-8, true) // the dark module
+}
+
+//nolint:mnd // version information layout from the standard
+func (q *qrCode) drawVersion(version int) {
+	if version < 7 {
+		return
+	}
+	rem := version
+	for range 12 {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := version<<12 | rem
+	for i := range 18 {
+		dark := (bits>>i)&1 != 0
+		a, b := q.size-11+i%3, i/3
+		q.setFunction(a, b, dark)
+		q.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords places the data bits in the zigzag order of the standard.
+//
+//nolint:mnd // module placement from the standard
+func (q *qrCode) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := range q.size {
+			y := vert
+			if upward {
+				y = q.size - 1 - vert
+			}
+			for j := range 2 {
+				x := right - j
+				if q.function[y*q.size+x] || i >= len(data)*8 {
+					continue
+				}
+				q.modules[y*q.size+x] = (data[i>>3]>>(7-(i&7)))&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+func (q *qrCode) applyMask(mask int) {
+	for y := range q.size {
+		for x := range q.size {
+			if q.function[y*q.size+x] {
+				continue
+			}
+			if qrMaskBit(mask, x, y) {
+				q.modules[y*q.size+x] = !q.modules[y*q.size+x]
+			}
+		}
+	}
+}
+
+//nolint:mnd // mask pattern formulas from the standard
+func qrMaskBit(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// penalty scores the symbol with the four rules of the standard; the mask
+// with the lowest score is used.
+//
+//nolint:mnd // penalty rules from the standard
+func (q *qrCode) penalty() int {
+	const (
+		n1, n2, n3, n4 = 3, 3, 40, 10
+	)
+	result := 0
+	line := make([]bool, q.size)
+	for _, vertical := range []bool{false, true} {
+		for a := range q.size {
+			for b := range q.size {
+				if vertical {
+					line[b] = q.dark(a, b)
+				} else {
+					line[b] = q.dark(b, a)
+				}
+			}
+			run := 1
+			for b := 1; b <= q.size; b++ {
+				if b < q.size && line[b] == line[b-1] {
+					run++
+					continue
+				}
+				if run >= 5 {
+					result += n1 + run - 5
+				}
+				run = 1
+			}
+			result += n3 * qrFinderLike(line)
+		}
+	}
+
+	dark := 0
+	for y := range q.size {
+		for x := range q.size {
+			c := q.dark(x, y)
+			if c {
+				dark++
+			}
+			if x+1 < q.size && y+1 < q.size && c == q.dark(x+1, y) && c == q.dark(x, y+1) && c == q.dark(x+1, y+1) {
+				result += n2
+			}
+		}
+	}
+	total := q.size * q.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return result + k*n4
+}
+
+// qrFinderLike counts 1:1:3:1:1 patterns with four light modules on either
+// side. Modules beyond the line count as light.
+//
+//nolint:mnd // finder-like pattern width
+func qrFinderLike(line []bool) int {
+	pattern := [...]bool{true, false, true, true, true, false, true}
+	at := func(i int) bool { return i >= 0 && i < len(line) && line[i] }
+	count := 0
+	for i := 0; i+len(pattern) <= len(line); i++ {
+		match := true
+		for j, p := range pattern {
+			if line[i+j] != p {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		before, after := true, true
+		for j := 1; j <= 4; j++ {
+			before = before && !at(i-j)
+			after = after && !at(i+len(pattern)-1+j)
+		}
+		if before {
+			count++
+		}
+		if after {
+			count++
+		}
+	}
+	return count
+}
+
+// qrAlignmentPositions returns the centre coordinates of the alignment
+// patterns along each axis.
+//
+//nolint:mnd // alignment pattern spacing from the standard
+func qrAlignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := 26
+	if version != 32 {
+		step = (version*4 + n*2 + 1) / (n*2 - 2) * 2
+	}
+	pos := make([]int, n)
+	pos[0] = 6
+	for i, p := n-1, version*4+17-7; i >= 1; i, p = i-1, p-step {
+		pos[i] = p
+	}
+	return pos
+}
+
+// qrRawModules is the number of modules available for data and error
+// correction at version.
+//
+//nolint:mnd // module counts from the standard
+func qrRawModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+func qrDataCodewords(version int, level qrLevel) int {
+	return qrRawModules(version)/8 - qrECCPerBlock[level][version]*qrNumBlocks[level][version]
+}
+
+// qrDataBits is the bit length of a byte-mode segment of n bytes.
+//
+//nolint:mnd // mode indicator width
+func qrDataBits(n, version int) int {
+	return 4 + qrCountBits(version) + n*8
+}
+
+//nolint:mnd // character count widths from the standard
+func qrCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// qrDataSegment builds the padded data codewords for a byte-mode segment.
+//
+//nolint:mnd // bit widths and pad codewords from the standard
+func qrDataSegment(data []byte, version int, level qrLevel) []byte {
+	var bb qrBits
+	bb.append(0x4, 4)
+	bb.append(len(data), qrCountBits(version))
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+	capacity := qrDataCodewords(version, level) * 8
+	bb.append(0, min(4, capacity-bb.n))
+	bb.append(0, (8-bb.n%8)%8)
+	for pad := 0xEC; bb.n < capacity; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+	return bb.bytes
+}
+
+// qrBits is an append-only big-endian bit buffer.
+type qrBits struct {
+	bytes []byte
+	n     int
+}
+
+//nolint:mnd // bits per byte
+func (b *qrBits) append(v, width int) {
+	for i := width - 1; i >= 0; i-- {
+		if b.n%8 == 0 {
+			b.bytes = append(b.bytes, 0)
+		}
+		if (v>>i)&1 != 0 {
+			b.bytes[b.n/8] |= 0x80 >> (b.n % 8)
+		}
+		b.n++
+	}
+}
+
+// qrAddECC splits data into blocks, appends Reed-Solomon error correction
+// to each and interleaves the result.
+func qrAddECC(data []byte, version int, level qrLevel) []byte {
+	numBlocks := qrNumBlocks[level][version]
+	eccLen := qrECCPerBlock[level][version]
+	raw := qrRawModules(version) / 8
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+
+	divisor := qrRSDivisor(eccLen)
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range blocks {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		block := make([]byte, 0, shortLen+1)
+		block = append(block, data[k:k+n]...)
+		k += n
+		ecc := qrRSRemainder(block, divisor)
+		if i < numShort {
+			block = append(block, 0) // placeholder to align columns
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	out := make([]byte, 0, raw)
+	for i := range shortLen + 1 {
+		for j, block := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				out = append(out, block[i])
+			}
+		}
+	}
+	return out
+}
+
+// qrRSDivisor returns the Reed-Solomon generator polynomial of the given
+// degree, highest coefficient first with the leading 1 omitted.
+//
+//nolint:mnd // GF(2^8) generator element
+func qrRSDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for range degree {
+		for j := range result {
+			result[j] = qrGFMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = qrGFMul(root, 2)
+	}
+	return result
+}
+
+func qrRSRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= qrGFMul(d, factor)
+		}
+	}
+	return result
+}
+
+// qrGFMul multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+//
+//nolint:mnd // GF(2^8) arithmetic
+func qrGFMul(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,347 @@
+package khqr
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestQRCapacity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		version int
+		level   qrLevel
+		want    int // data codewords, from the ISO/IEC 18004 capacity table
+	}{
+		{1, qrLevelL, 19},
+		{1, qrLevelM, 16},
+		{1, qrLevelH, 9},
+		{7, qrLevelQ, 88},
+		{10, qrLevelM, 216},
+		{40, qrLevelL, 2956},
+		{40, qrLevelH, 1276},
+	}
+	for _, tt := range tests {
+		if got := qrDataCodewords(tt.version, tt.level); got != tt.want {
+			t.Errorf("qrDataCodewords(%d, %d) = %d, want %d", tt.version, tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestQRAlignmentPositions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		version int
+		want    []int
+	}{
+		{1, nil},
+		{2, []int{6, 18}},
+		{7, []int{6, 22, 38}},
+		{32, []int{6, 34, 60, 86, 112, 138}},
+		{40, []int{6, 30, 58, 86, 114, 142, 170}},
+	}
+	for _, tt := range tests {
+		if got := qrAlignmentPositions(tt.version); !slices.Equal(got, tt.want) {
+			t.Errorf("qrAlignmentPositions(%d) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestQRReedSolomon(t *testing.T) {
+	t.Parallel()
+
+	// "HELLO WORLD" at 1-M, the worked example in the standard's annex.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := qrRSRemainder(data, qrRSDivisor(len(want))); !bytes.Equal(got, want) {
+		t.Errorf("qrRSRemainder() = %v, want %v", got, want)
+	}
+}
+
+func TestQRFormatAndVersionBits(t *testing.T) {
+	t.Parallel()
+
+	q, err := encodeQR([]byte(strings.Repeat("k", 120)), qrLevelM) // version 7 at M
+	if err != nil {
+		t.Fatalf("encodeQR() unexpected error: %v", err)
+	}
+	if q.size != 45 {
+		t.Fatalf("size = %d, want 45", q.size)
+	}
+
+	// Version information, read from the bottom-left block, LSB first.
+	v := 0
+	for i := range 18 {
+		if q.dark(i/3, q.size-11+i%3) {
+			v |= 1 << i
+		}
+	}
+	if v != 0x07C94 {
+		t.Errorf("version bits = %018b, want %018b", v, 0x07C94)
+	}
+
+	// Format information next to the top-right finder, LSB first.
+	f := 0
+	for i := range 8 {
+		if q.dark(q.size-1-i, 8) {
+			f |= 1 << i
+		}
+	}
+	for i := 8; i < 15; i++ {
+		if q.dark(8, q.size-15+i) {
+			f |= 1 << i
+		}
+	}
+	f ^= 0x5412
+	if level := f >> 13; level != qrLevelM.formatBits() {
+		t.Errorf("format level bits = %02b, want %02b", level, qrLevelM.formatBits())
+	}
+	if !q.dark(8, q.size-8) {
+		t.Error("dark module is light")
+	}
+}
+
+func TestQRFunctionPatterns(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		n    int
+		size int
+	}{
+		{"v1", 10, 21},
+		{"v2", 20, 25},
+		{"v10", 200, 57},
+		{"v40", 2300, 177},
+	}
+	for _, tt := range tests {
+		q, err := encodeQR(bytes.Repeat([]byte{'x'}, tt.n), qrLevelM)
+		if err != nil {
+			t.Fatalf("%s: encodeQR() unexpected error: %v", tt.name, err)
+		}
+		if q.size != tt.size {
+			t.Errorf("%s: size = %d, want %d", tt.name, q.size, tt.size)
+		}
+		// Finder patterns: dark centre, light ring at distance 2, dark ring at 3.
+		for _, c := range [][2]int{{3, 3}, {q.size - 4, 3}, {3, q.size - 4}} {
+			if !q.dark(c[0], c[1]) || q.dark(c[0]+2, c[1]) || !q.dark(c[0]+3, c[1]) {
+				t.Errorf("%s: bad finder pattern at %v", tt.name, c)
+			}
+		}
+		// Timing pattern alternates between the finders.
+		for i := 8; i < q.size-8; i++ {
+			if q.dark(i, 6) != (i%2 == 0) || q.dark(6, i) != (i%2 == 0) {
+				t.Errorf("%s: bad timing pattern at %d", tt.name, i)
+				break
+			}
+		}
+	}
+
+	if _, err := encodeQR(make([]byte, 2332), qrLevelM); err == nil {
+		t.Error("encodeQR() over capacity: expected error")
+	}
+}
+
+// qrGolden are complete symbols produced by rsc.io/qr/coding, an
+// independent encoder, for the same data, version, level and mask; '#' is
+// a dark module. Version 7 carries the version information blocks.
+var qrGolden = []struct {
+	name  string
+	data  string
+	level qrLevel
+	want  string
+}{
+	{
+		"v1_L_mask3",
+		"hello khqr",
+		qrLevelL,
+		`
+#######.##..#.#######
+#.....#..#..#.#.....#
+#.###.#.#.#.#.#.###.#
+#.###.#.#..#..#.###.#
+#.###.#.###...#.###.#
+#.....#.......#.....#
+#######.#.#.#.#######
+.........##..........
+####..#.#.#..#..###.#
+..#.#.....#.#.#####.#
+...#..#####.#.##...##
+...#...####.#..#.#.#.
+###.#.##...#.####...#
+........#.#...#.#.#..
+#######..##....##....
+#.....#..#.###.#.##.#
+#.###.#....#.#...##..
+#.###.#.#.#..#...###.
+#.###.#.#..#..##..#..
+#.....#.##.##.###...#
+#######.#.####.#..#..
+`,
+	},
+	{
+		"v4_M_mask2",
+		"the quick brown fox jumps over the lazy dog",
+		qrLevelM,
+		`
+#######..#..#.#.#..#####..#######
+#.....#..#.##..##.#..#....#.....#
+#.###.#.#.#...###..#.###..#.###.#
+#.###.#.####...#..##.#.##.#.###.#
+#.###.#.####..###...###...#.###.#
+#.....#.##...#.....#..###.#.....#
+#######.#.#.#.#.#.#.#.#.#.#######
+........##..#...#..#####.........
+#.#####.....##...#.#..###.#####..
+##.#.#.#...#.#..#####..#..#.....#
+############.###.#....#.#.#...##.
+##...#.#######..###....###.####..
+##.#..#.###.#.###...###.#...#...#
+######..#.##...#.##.....#.#..##.#
+.#....#.#..######...#.#....#####.
+#.####.######..#.....#.#.####.#..
+.##...#..#.###.###.......#.##..#.
+###.#..####.#.#.#..#.#.#..#..##.#
+......###..#..###.#..#..#....###.
+##..#........#..#....####.....#..
+##....##..........##.#..##..#..#.
+##..##...##.##.##...####..#...###
+#.##.##...##.##...##.#....#...##.
+#.#..#.#..#.##..#.####.#.###.####
+#.#.#.#.##...##..#.##.#######...#
+........#.#...#.##.##...#...#.###
+#######...#.#..#.#..#.###.#.#.##.
+#.....#.##..####.####..##...###..
+#.###.#.#.#.#####..####.######...
+#.###.#.##..####..#..#..#...##..#
+#.###.#.##..#..####.#.#.#.#...#..
+#.....#........#.....#...#...##..
+#######.##.#####.##...###..#...#.
+`,
+	},
+	{
+		"v5_H_mask3",
+		"the quick brown fox jumps over the lazy dog",
+		qrLevelH,
+		`
+#######....#.#...#..#.#.#.#.#.#######
+#.....#...#.#.#.#.#.#.#.#...#.#.....#
+#.###.#..#.##.##......#..###..#.###.#
+#.###.#..#.#..#.......#.###...#.###.#
+#.###.#.##..#...####.####..##.#.###.#
+#.....#..###.##...#..#..###...#.....#
+#######.#.#.#.#.#.#.#.#.#.#.#.#######
+........###...#..#..#.##..#..........
+..##..########.###..##....#####.#....
+...###.#.#..###..#.###..#...##.#.....
+.#...##..###...#....#.##.##..#.###...
+####.#.########..####..####.#..#..##.
+##..####.##.#.#....###.#.###.##.#####
+##.#.....#####..##....#######.#.##..#
+###.###...#..###.###.##.##..###.#.##.
+#.##....#.##.....##.#.###...#........
+#...#.###.#.######...#..###..#....##.
+...###....#..##.#..###...#.#..#...###
+....#######.#####.....#.##.#..#######
+.#.#...###.##.#...##..##..####.###..#
+#.#..##.#######.###.####....##.##...#
+..#.#....#.##.###.#...#..#..#.....#..
+##.#.##.#..#..#..##.##.###..######...
+#.##...#..###..##.####...##...#..##..
+..#.#.###..#.##....#...#####.##.#.#..
+.##.....##..##.######.######..###...#
+.#.####.##..#.#..#.##.##.......#..##.
+#.##......#######.#.##.#..##.####....
+..#...#.#.##.#.##.#..#...########.###
+........###...##...###.###.##...#...#
+#######.#..#..##.##.#...#...#.#.##.##
+#.....#..##.#####.##.#.##.###...##.#.
+#.###.#....##.#....###....#.#####....
+#.###.#.###.#...#.##....#...#####.#..
+#.###.#.##...#.#.###.#.##.###.######.
+#.....#..#.#...#.#.#...###.....#.##..
+#######..##.#.#.###.###..##...#...###
+`,
+	},
+	{
+		"v7_Q_mask6",
+		"khqr payment khqr payment khqr payment khqr payment khqr payment khqr payment kh",
+		qrLevelQ,
+		`
+#######....#........#.##..###.#.##..#.#######
+#.....#.#.###...#.#....##........#.#..#.....#
+#.###.#..##.##..##.###..#.#..###.#.#..#.###.#
+#.###.#.#.##..#..###.#.##..#####.#.##.#.###.#
+#.###.#.###.##.....#######....#...###.#.###.#
+#.....#....#.###.#.##...#...##.##.....#.....#
+#######.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#######
+........#..#.#..##.##...##..##.#.###.........
+.#.####.#.#.#.###..#######..#....#.#.##.##.#.
+.###....#.#.#...#....###.###.###.########.##.
+...#..##.####........#...#.##.....#.##.#.#.##
+#..#.#.##.#..###.#.#.........##.#.###....##..
+..##..#####.##..#.##.#.#.#..########.#..##.#.
+....##.#..##.#.#..##.#.####..#####.#####..##.
+#####.####...#...#..#.#.#####....#..###.#.#..
+.....#...##...#.#.........#.......#.#.#..###.
+#...###..#.#.##.#.##.####.##.#####....#..#...
+.#.....#.#.##........#..##..#.##.##.##.###..#
+.##.#.###....####....#.###..#...##.##..#..#.#
+.#..#..#####...###.##.######..##....###.#####
+###########.##..#.#.#####..####...#.#####..#.
+#...#...###..##...###...#.###.#######...#..#.
+##..#.#.###..##....##.#.####..##..###.#.#..##
+.####...#.#..##...#.#...#.....#.#..##...#.##.
+.#.######.#..#.#.#.######.###.#.#.########.##
+.#.....####..##..#####.#....###.##....##.....
+.##.###.#.#.#..####..###.####..###..#..##.#..
+..#.#..##...#...##.##.#.####.#..####.#..###.#
+.#.######..##.######.#.####..#..#..#.#.##...#
+.##.##.#.#..##.##...###.##..#.#..###.#..#####
+###..##.#...##..#.#...###.##...#.#.##.##..#.#
+...##...#.....###.#####...#.####.#.#.#...##.#
+.#..#####....#...#...#.###..#.#..##...#..#.#.
+.#.#.#.#####...#..##..####..#.#.#.#.#..##.#..
+....#.#.#...###..##...#...##.#..###.###..####
+.####...#..##..##..##....##..#.##....##.#.###
+#..##.####.#....###.#####..##...#..######..#.
+........##.#..###.###...#.#..###.#..#...#.##.
+#######..###......#.#.#.#.#.#...##..#.#.###..
+#.....#.#...#.##...##...##.#..#...#.#...###.#
+#.###.#.###...#.#.#.#####.#...###..######...#
+#.###.#.#.......##.#..###.....##.##....#...#.
+#.###.#...#.##...###.#.#.##.........##..#.#.#
+#.....#.#####..###...##..#...#.#..#.###..####
+#######..####..##.####.##.#.......#.####.....
+`,
+	},
+}
+
+func TestQRGolden(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range qrGolden {
+		q, err := encodeQR([]byte(tt.data), tt.level)
+		if err != nil {
+			t.Fatalf("%s: encodeQR() unexpected error: %v", tt.name, err)
+		}
+		var b strings.Builder
+		for y := range q.size {
+			b.WriteByte('\n')
+			for x := range q.size {
+				if q.dark(x, y) {
+					b.WriteByte('#')
+				} else {
+					b.WriteByte('.')
+				}
+			}
+		}
+		b.WriteByte('\n')
+		if got := b.String(); got != tt.want {
+			t.Errorf("%s: encodeQR() =%s\nwant%s", tt.name, got, tt.want)
+		}
+	}
+}
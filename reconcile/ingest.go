@@ -0,0 +1,259 @@
+package reconcile
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	khqr "github.com/ishinvin/go-khqr"
+)
+
+// csvTable reads a CSV export with a header row, resolving columns by
+// name case-insensitively.
+type csvTable struct {
+	r    *csv.Reader
+	cols map[string]int
+	line int
+}
+
+func newCSVTable(r io.Reader, required ...string) (*csvTable, error) {
+	t := &csvTable{r: csv.NewReader(r), cols: map[string]int{}, line: 1}
+	t.r.FieldsPerRecord = -1
+	header, err := t.r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reconcile: reading CSV header: %w", err)
+	}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		t.cols[name] = i
+	}
+	for _, name := range required {
+		if _, ok := t.cols[name]; !ok {
+			return nil, fmt.Errorf("reconcile: CSV is missing column %q", name)
+		}
+	}
+	return t, nil
+}
+
+// next returns the next row, or io.EOF.
+func (t *csvTable) next() ([]string, error) {
+	row, err := t.r.Read()
+	t.line++
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("reconcile: CSV line %d: %w", t.line, err)
+	}
+	return row, err
+}
+
+// get returns the first present column among names, trimmed.
+func (t *csvTable) get(row []string, names ...string) string {
+	for _, name := range names {
+		if i, ok := t.cols[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+	}
+	return ""
+}
+
+func (t *csvTable) errorf(format string, args ...any) error {
+	return fmt.Errorf("reconcile: CSV line %d: "+format, append([]any{t.line}, args...)...)
+}
+
+// ReadIssuedCSV reads issued codes from CSV with the columns md5,
+// bill_number, amount, currency, issued_at (or created_at) and expires_at.
+// Only md5 is required. Currencies may be alphabetic or numeric codes;
+// times may be RFC 3339, "2006-01-02 15:04:05" (UTC) or Unix milliseconds.
+func ReadIssuedCSV(r io.Reader) ([]Issued, error) {
+	t, err := newCSVTable(r, "md5")
+	if err != nil {
+		return nil, err
+	}
+	var out []Issued
+	for {
+		row, err := t.next()
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		code := Issued{MD5: t.get(row, "md5"), BillNumber: t.get(row, "bill_number")}
+		if code.Amount, err = parseAmount(t.get(row, "amount")); err != nil {
+			return nil, t.errorf("amount: %w", err)
+		}
+		if code.Currency, err = parseCurrency(t.get(row, "currency")); err != nil {
+			return nil, t.errorf("currency: %w", err)
+		}
+		if code.IssuedAt, err = parseTime(t.get(row, "issued_at", "created_at")); err != nil {
+			return nil, t.errorf("issued_at: %w", err)
+		}
+		if code.ExpiresAt, err = parseTime(t.get(row, "expires_at")); err != nil {
+			return nil, t.errorf("expires_at: %w", err)
+		}
+		out = append(out, code)
+	}
+}
+
+// ReadTransactionsCSV reads transactions from CSV with the columns hash,
+// md5, bill_number, from_account_id, amount, currency and paid_at. amount,
+// currency and paid_at are required. Values are parsed as in ReadIssuedCSV.
+func ReadTransactionsCSV(r io.Reader) ([]Transaction, error) {
+	t, err := newCSVTable(r, "amount", "currency", "paid_at")
+	if err != nil {
+		return nil, err
+	}
+	var out []Transaction
+	for {
+		row, err := t.next()
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		tx := Transaction{
+			Hash:          t.get(row, "hash"),
+			MD5:           t.get(row, "md5"),
+			BillNumber:    t.get(row, "bill_number"),
+			FromAccountID: t.get(row, "from_account_id"),
+		}
+		if tx.Amount, err = parseAmount(t.get(row, "amount")); err != nil {
+			return nil, t.errorf("amount: %w", err)
+		}
+		if tx.Currency, err = parseCurrency(t.get(row, "currency")); err != nil {
+			return nil, t.errorf("currency: %w", err)
+		}
+		if tx.PaidAt, err = parseTime(t.get(row, "paid_at")); err != nil {
+			return nil, t.errorf("paid_at: %w", err)
+		}
+		out = append(out, tx)
+	}
+}
+
+// issuedJSON accepts both Issued objects and khqr.Record lines written by
+// khqr.FileStore.
+type issuedJSON struct {
+	Issued
+	Individual *khqr.IndividualInfo `json:"individual"`
+	Merchant   *khqr.MerchantInfo   `json:"merchant"`
+	CreatedAt  time.Time            `json:"created_at"`
+}
+
+// ReadIssuedJSON reads issued codes from a JSON array or from JSON Lines.
+// Each value is either an Issued object or a khqr.Record, so the file kept
+// by khqr.FileStore can be read directly; when an MD5 appears more than
+// once the last value wins.
+func ReadIssuedJSON(r io.Reader) ([]Issued, error) {
+	values, err := readJSON[issuedJSON](r)
+	if err != nil {
+		return nil, err
+	}
+	var out []Issued
+	seen := map[string]int{}
+	for i := range values {
+		v := &values[i]
+		code := v.Issued
+		if v.Individual != nil || v.Merchant != nil {
+			code = FromRecord(&khqr.Record{
+				MD5: v.MD5, Individual: v.Individual, Merchant: v.Merchant, CreatedAt: v.CreatedAt, ExpiresAt: v.ExpiresAt,
+			})
+		}
+		if code.IssuedAt.IsZero() {
+			code.IssuedAt = v.CreatedAt
+		}
+		if j, ok := seen[code.MD5]; ok && code.MD5 != "" {
+			out[j] = code
+			continue
+		}
+		seen[code.MD5] = len(out)
+		out = append(out, code)
+	}
+	return out, nil
+}
+
+// ReadTransactionsJSON reads transactions from a JSON array or from JSON
+// Lines of Transaction objects.
+func ReadTransactionsJSON(r io.Reader) ([]Transaction, error) {
+	return readJSON[Transaction](r)
+}
+
+// readJSON decodes a JSON array of T, or a stream of T values.
+func readJSON[T any](r io.Reader) ([]T, error) {
+	br := bufio.NewReader(r)
+	first, err := peekNonSpace(br)
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reconcile: reading JSON: %w", err)
+	}
+	dec := json.NewDecoder(br)
+	if first == '[' {
+		var out []T
+		if err := dec.Decode(&out); err != nil {
+			return nil, fmt.Errorf("reconcile: decoding JSON: %w", err)
+		}
+		return out, nil
+	}
+	var out []T
+	for n := 1; ; n++ {
+		var v T
+		err := dec.Decode(&v)
+		if errors.Is(err, io.EOF) {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reconcile: decoding JSON value %d: %w", n, err)
+		}
+		out = append(out, v)
+	}
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, br.UnreadByte()
+	}
+}
+
+func parseAmount(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+}
+
+func parseCurrency(s string) (khqr.Currency, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return khqr.ParseCurrency(s)
+}
+
+// parseTime parses RFC 3339, "2006-01-02 15:04:05" in UTC, or Unix
+// milliseconds. An empty string is the zero time.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateTime, s)
+}
@@ -0,0 +1,182 @@
+package reconcile
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	khqr "github.com/ishinvin/go-khqr"
+)
+
+func TestReadIssuedCSV(t *testing.T) {
+	t.Parallel()
+
+	in := "\ufeffMD5,Bill_Number,Amount,Currency,Created_At,Expires_At\n" +
+		"a,INV-1,\"1,250.50\",USD,2026-10-19T09:00:00Z,1792400400000\n" +
+		"b,,0,116,2026-10-19 09:00:00,\n"
+	got, err := ReadIssuedCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ReadIssuedCSV() unexpected error: %v", err)
+	}
+	want := []Issued{
+		{MD5: "a", BillNumber: "INV-1", Amount: 1250.5, Currency: khqr.USD, IssuedAt: t0, ExpiresAt: time.UnixMilli(1792400400000)},
+		{MD5: "b", Currency: khqr.KHR, IssuedAt: t0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ReadIssuedCSV() = %d rows, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].IssuedAt.Equal(want[i].IssuedAt) || !got[i].ExpiresAt.Equal(want[i].ExpiresAt) {
+			t.Errorf("row %d times = %v %v, want %v %v", i, got[i].IssuedAt, got[i].ExpiresAt, want[i].IssuedAt, want[i].ExpiresAt)
+		}
+		got[i].IssuedAt, got[i].ExpiresAt, want[i].IssuedAt, want[i].ExpiresAt = time.Time{}, time.Time{}, time.Time{}, time.Time{}
+		if got[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		read func(string) error
+		in   string
+		want string
+	}{
+		{"issued_missing_md5", readIssuedCSV, "bill_number\nINV-1\n", `missing column "md5"`},
+		{"issued_bad_amount", readIssuedCSV, "md5,amount\na,ten\n", "line 2: amount"},
+		{"issued_bad_currency", readIssuedCSV, "md5,currency\na,EUR\n", "line 2: currency"},
+		{"tx_missing_paid_at", readTransactionsCSV, "amount,currency\n1,USD\n", `missing column "paid_at"`},
+		{"tx_bad_time", readTransactionsCSV, "amount,currency,paid_at\n1,USD,yesterday\n", "line 2: paid_at"},
+		{"empty", readTransactionsCSV, "", "header"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.read(tt.in)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func readIssuedCSV(s string) error {
+	_, err := ReadIssuedCSV(strings.NewReader(s))
+	return err
+}
+
+func readTransactionsCSV(s string) error {
+	_, err := ReadTransactionsCSV(strings.NewReader(s))
+	return err
+}
+
+func TestReadTransactionsCSV(t *testing.T) {
+	t.Parallel()
+
+	in := "hash,md5,bill_number,from_account_id,amount,currency,paid_at\n" +
+		"h1,a,INV-1,payer@aclb,10,USD,2026-10-19T09:00:00Z\n"
+	got, err := ReadTransactionsCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ReadTransactionsCSV() unexpected error: %v", err)
+	}
+	want := Transaction{Hash: "h1", MD5: "a", BillNumber: "INV-1", FromAccountID: "payer@aclb", Amount: 10, Currency: khqr.USD, PaidAt: t0}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("ReadTransactionsCSV() = %+v, want [%+v]", got, want)
+	}
+}
+
+func TestReadTransactionsJSON(t *testing.T) {
+	t.Parallel()
+
+	for name, in := range map[string]string{
+		"array": `[{"hash":"h1","amount":10,"currency":"USD","paid_at":"2026-10-19T09:00:00Z"},
+			{"hash":"h2","amount":4000,"currency":116,"paid_at":"2026-10-19T09:00:00Z"}]`,
+		"lines": "{\"hash\":\"h1\",\"amount\":10,\"currency\":\"USD\",\"paid_at\":\"2026-10-19T09:00:00Z\"}\n" +
+			"{\"hash\":\"h2\",\"amount\":4000,\"currency\":\"KHR\",\"paid_at\":\"2026-10-19T09:00:00Z\"}\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ReadTransactionsJSON(strings.NewReader(in))
+			if err != nil {
+				t.Fatalf("ReadTransactionsJSON() unexpected error: %v", err)
+			}
+			if len(got) != 2 || got[0].Currency != khqr.USD || got[1].Currency != khqr.KHR || !got[1].PaidAt.Equal(t0) {
+				t.Errorf("ReadTransactionsJSON() = %+v", got)
+			}
+		})
+	}
+
+	if _, err := ReadTransactionsJSON(strings.NewReader(`{"amount":"ten"}`)); err == nil {
+		t.Error("ReadTransactionsJSON() with invalid value expected error, got nil")
+	}
+	if got, err := ReadTransactionsJSON(strings.NewReader("  \n")); err != nil || got != nil {
+		t.Errorf("ReadTransactionsJSON(empty) = %v, %v, want nil, nil", got, err)
+	}
+}
+
+func TestReadIssuedJSONFromFileStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "issued.jsonl")
+	store, err := khqr.OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := khqr.NewGenerator(khqr.WithStore(store))
+	data, err := g.GenerateIndividual(ctx, khqr.IndividualInfo{
+		BakongAccountID:     "jonhsmith@nbcq",
+		MerchantName:        "Jonh Smith",
+		Currency:            khqr.USD,
+		Amount:              2.5,
+		BillNumber:          "INV-1",
+		ExpirationTimestamp: time.Now().Add(time.Minute).UnixMilli(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A status update appends a second line for the same code.
+	if err := store.UpdateStatus(ctx, data.MD5(), khqr.StatusPaid); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := ReadIssuedJSON(f)
+	if err != nil {
+		t.Fatalf("ReadIssuedJSON() unexpected error: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("ReadIssuedJSON() = %d codes, want 1", len(got))
+	}
+	if c := got[0]; c.MD5 != data.MD5() || c.BillNumber != "INV-1" || c.Amount != 2.5 || c.Currency != khqr.USD ||
+		c.IssuedAt.IsZero() || c.ExpiresAt.IsZero() {
+		t.Errorf("ReadIssuedJSON() = %+v", c)
+	}
+}
+
+func TestReadIssuedJSON(t *testing.T) {
+	t.Parallel()
+
+	in := `[{"md5":"a","bill_number":"INV-1","amount":3,"currency":"USD","issued_at":"2026-10-19T09:00:00Z"}]`
+	got, err := ReadIssuedJSON(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ReadIssuedJSON() unexpected error: %v", err)
+	}
+	want := Issued{MD5: "a", BillNumber: "INV-1", Amount: 3, Currency: khqr.USD, IssuedAt: t0}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("ReadIssuedJSON() = %+v, want [%+v]", got, want)
+	}
+}
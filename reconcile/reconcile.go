@@ -0,0 +1,287 @@
+// Package reconcile matches Bakong transactions against issued KHQR codes.
+//
+// Issued codes and transactions are loaded from CSV or JSON exports (see
+// ReadIssuedCSV, ReadTransactionsJSON and friends) or built directly, then
+// passed to Reconcile. Transactions are matched to codes by MD5, falling
+// back to bill number and then to a unique amount within the code's
+// validity window. Every match and every leftover item is reported with
+// flags describing what needs attention.
+package reconcile
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	khqr "github.com/ishinvin/go-khqr"
+)
+
+// Issued is a KHQR code the business generated.
+type Issued struct {
+	MD5        string        `json:"md5"`
+	BillNumber string        `json:"bill_number,omitempty"`
+	Amount     float64       `json:"amount"` // 0 for static codes
+	Currency   khqr.Currency `json:"currency,omitzero"`
+	IssuedAt   time.Time     `json:"issued_at,omitzero"`
+	ExpiresAt  time.Time     `json:"expires_at,omitzero"` // zero for static codes
+}
+
+// Static reports whether the code is static and may be paid any number of
+// times for any amount.
+func (i *Issued) Static() bool {
+	return i.Amount == 0
+}
+
+// FromRecord converts an audit record kept by a khqr.Store.
+func FromRecord(r *khqr.Record) Issued {
+	amount, currency := r.Amount()
+	return Issued{
+		MD5:        r.MD5,
+		BillNumber: r.BillNumber(),
+		Amount:     amount,
+		Currency:   currency,
+		IssuedAt:   r.CreatedAt,
+		ExpiresAt:  r.ExpiresAt,
+	}
+}
+
+// Transaction is a payment taken from a Bakong transaction export.
+type Transaction struct {
+	Hash          string        `json:"hash,omitempty"` // Bakong transaction hash
+	MD5           string        `json:"md5,omitempty"`  // MD5 of the paid QR string, if exported
+	BillNumber    string        `json:"bill_number,omitempty"`
+	FromAccountID string        `json:"from_account_id,omitempty"`
+	Amount        float64       `json:"amount"`
+	Currency      khqr.Currency `json:"currency,omitzero"`
+	PaidAt        time.Time     `json:"paid_at"`
+}
+
+// MatchKey names the key a transaction was matched on.
+type MatchKey string
+
+const (
+	MatchMD5        MatchKey = "md5"
+	MatchBillNumber MatchKey = "bill_number"
+	MatchAmount     MatchKey = "amount" // unique code with the same amount and currency open at PaidAt
+)
+
+// Flag marks something about an item that needs attention.
+type Flag string
+
+const (
+	FlagUnderpaid            Flag = "underpaid"             // paid less than the code's amount
+	FlagOverpaid             Flag = "overpaid"              // paid more than the code's amount
+	FlagCurrencyMismatch     Flag = "currency_mismatch"     // paid in a different currency
+	FlagDuplicate            Flag = "duplicate"             // a dynamic code was already paid
+	FlagExpiredThenPaid      Flag = "expired_then_paid"     // paid at or after the code expired
+	FlagUnmatchedTransaction Flag = "unmatched_transaction" // no issued code found
+	FlagUnpaid               Flag = "unpaid"                // dynamic code with no payment
+)
+
+// Item is one line of a Report: a matched pair, an unmatched transaction
+// or an unpaid code.
+type Item struct {
+	Issued      *Issued      `json:"issued,omitempty"`
+	Transaction *Transaction `json:"transaction,omitempty"`
+	MatchedBy   MatchKey     `json:"matched_by,omitempty"`
+	Flags       []Flag       `json:"flags,omitempty"`
+}
+
+// OK reports whether the item needs no attention.
+func (it *Item) OK() bool {
+	return len(it.Flags) == 0
+}
+
+// Has reports whether the item carries flag f.
+func (it *Item) Has(f Flag) bool {
+	return slices.Contains(it.Flags, f)
+}
+
+// Summary counts the items in a Report.
+type Summary struct {
+	Issued       int          `json:"issued"`
+	Transactions int          `json:"transactions"`
+	Matched      int          `json:"matched"`
+	OK           int          `json:"ok"`
+	Flags        map[Flag]int `json:"flags,omitempty"`
+}
+
+// Report is the result of Reconcile. Matched transactions come first in
+// payment order, followed by unpaid codes in issue order.
+type Report struct {
+	Items   []Item  `json:"items"`
+	Summary Summary `json:"summary"`
+}
+
+// Reconcile matches txs against issued. Transactions are considered in
+// PaidAt order, so when a dynamic code is paid more than once the earliest
+// payment is the clean one and later ones are flagged FlagDuplicate.
+func Reconcile(issued []Issued, txs []Transaction) *Report {
+	m := newMatcher(issued)
+
+	sorted := slices.Clone(txs)
+	slices.SortStableFunc(sorted, func(a, b Transaction) int { return a.PaidAt.Compare(b.PaidAt) })
+
+	rep := &Report{Summary: Summary{Issued: len(issued), Transactions: len(txs), Flags: map[Flag]int{}}}
+	for i := range sorted {
+		tx := &sorted[i]
+		item := Item{Transaction: tx}
+		if idx, key := m.match(tx); idx >= 0 {
+			code := &m.issued[idx]
+			item.Issued, item.MatchedBy = code, key
+			item.Flags = check(code, tx, m.paid[idx])
+			m.paid[idx]++
+			rep.Summary.Matched++
+		} else {
+			item.Flags = []Flag{FlagUnmatchedTransaction}
+		}
+		rep.add(item)
+	}
+	for i := range m.issued {
+		if code := &m.issued[i]; m.paid[i] == 0 && !code.Static() {
+			rep.add(Item{Issued: code, Flags: []Flag{FlagUnpaid}})
+		}
+	}
+	return rep
+}
+
+func (r *Report) add(it Item) {
+	r.Items = append(r.Items, it)
+	if it.OK() {
+		r.Summary.OK++
+	}
+	for _, f := range it.Flags {
+		r.Summary.Flags[f]++
+	}
+}
+
+// check flags problems with tx as a payment for code, which has already
+// been paid prior times.
+func check(code *Issued, tx *Transaction, prior int) []Flag {
+	var flags []Flag
+	if code.Static() {
+		if tx.Currency != code.Currency {
+			flags = append(flags, FlagCurrencyMismatch)
+		}
+		return flags
+	}
+	if prior > 0 {
+		flags = append(flags, FlagDuplicate)
+	}
+	if !code.ExpiresAt.IsZero() && !tx.PaidAt.Before(code.ExpiresAt) {
+		flags = append(flags, FlagExpiredThenPaid)
+	}
+	if tx.Currency != code.Currency {
+		return append(flags, FlagCurrencyMismatch)
+	}
+	switch cmpAmount(tx.Amount, code.Amount, code.Currency) {
+	case -1:
+		flags = append(flags, FlagUnderpaid)
+	case 1:
+		flags = append(flags, FlagOverpaid)
+	}
+	return flags
+}
+
+// cmpAmount compares amounts in currency c, treating differences smaller
+// than half a minor unit as equal.
+func cmpAmount(a, b float64, c khqr.Currency) int {
+	units := 2
+	if info, ok := khqr.LookupCurrency(c); ok {
+		units = info.MinorUnits
+	}
+	eps := math.Pow10(-units) / 2 //nolint:mnd // half a minor unit
+	switch {
+	case a < b-eps:
+		return -1
+	case a > b+eps:
+		return 1
+	}
+	return 0
+}
+
+// matcher indexes issued codes for lookup by MD5 and bill number.
+type matcher struct {
+	issued []Issued
+	paid   []int // payments matched to each code so far
+	byMD5  map[string]int
+	byBill map[string][]int
+}
+
+func newMatcher(issued []Issued) *matcher {
+	m := &matcher{
+		issued: slices.Clone(issued),
+		paid:   make([]int, len(issued)),
+		byMD5:  map[string]int{},
+		byBill: map[string][]int{},
+	}
+	for i := range m.issued {
+		code := &m.issued[i]
+		if code.MD5 != "" {
+			m.byMD5[code.MD5] = i
+		}
+		if code.BillNumber != "" {
+			m.byBill[code.BillNumber] = append(m.byBill[code.BillNumber], i)
+		}
+	}
+	return m
+}
+
+// match returns the index of the code tx pays and the key it matched on,
+// or -1 if there is none.
+func (m *matcher) match(tx *Transaction) (int, MatchKey) {
+	if i, ok := m.byMD5[tx.MD5]; ok && tx.MD5 != "" {
+		return i, MatchMD5
+	}
+	if candidates := m.byBill[tx.BillNumber]; tx.BillNumber != "" && len(candidates) > 0 {
+		return m.bestByBill(tx, candidates), MatchBillNumber
+	}
+	if i := m.uniqueByAmount(tx); i >= 0 {
+		return i, MatchAmount
+	}
+	return -1, ""
+}
+
+// bestByBill picks among codes sharing a bill number, preferring unpaid
+// codes, then codes open when tx was paid, then an exact amount, then the
+// most recently issued.
+func (m *matcher) bestByBill(tx *Transaction, candidates []int) int {
+	score := func(i int) int {
+		code := &m.issued[i]
+		s := 0
+		if m.paid[i] == 0 {
+			s += 4 //nolint:mnd // preference weight
+		}
+		if code.ExpiresAt.IsZero() || tx.PaidAt.Before(code.ExpiresAt) {
+			s += 2
+		}
+		if tx.Currency == code.Currency && cmpAmount(tx.Amount, code.Amount, code.Currency) == 0 {
+			s++
+		}
+		return s
+	}
+	return slices.MaxFunc(candidates, func(a, b int) int {
+		return cmp.Or(cmp.Compare(score(a), score(b)), m.issued[a].IssuedAt.Compare(m.issued[b].IssuedAt))
+	})
+}
+
+// uniqueByAmount returns the only unpaid dynamic code with tx's amount and
+// currency that was open when tx was paid, or -1 if there is not exactly one.
+func (m *matcher) uniqueByAmount(tx *Transaction) int {
+	found := -1
+	for i := range m.issued {
+		code := &m.issued[i]
+		if code.Static() || m.paid[i] > 0 || code.Currency != tx.Currency ||
+			cmpAmount(tx.Amount, code.Amount, code.Currency) != 0 ||
+			tx.PaidAt.Before(code.IssuedAt) ||
+			(!code.ExpiresAt.IsZero() && !tx.PaidAt.Before(code.ExpiresAt)) {
+			continue
+		}
+		if found >= 0 {
+			return -1
+		}
+		found = i
+	}
+	return found
+}
@@ -0,0 +1,251 @@
+package reconcile
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	khqr "github.com/ishinvin/go-khqr"
+)
+
+var t0 = time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+func dynamic(md5, bill string, amount float64, currency khqr.Currency, issued time.Duration) Issued {
+	return Issued{
+		MD5: md5, BillNumber: bill, Amount: amount, Currency: currency,
+		IssuedAt: t0.Add(issued), ExpiresAt: t0.Add(issued + 10*time.Minute),
+	}
+}
+
+func paid(md5, bill string, amount float64, currency khqr.Currency, at time.Duration) Transaction {
+	return Transaction{Hash: "tx-" + md5 + bill, MD5: md5, BillNumber: bill, Amount: amount, Currency: currency, PaidAt: t0.Add(at)}
+}
+
+// find returns the item for the code with md5 and transaction hash; an
+// empty hash selects the item without a transaction.
+func find(t *testing.T, rep *Report, md5, hash string) *Item {
+	t.Helper()
+	for i := range rep.Items {
+		it := &rep.Items[i]
+		var gotMD5, gotHash string
+		if it.Issued != nil {
+			gotMD5 = it.Issued.MD5
+		}
+		if it.Transaction != nil {
+			gotHash = it.Transaction.Hash
+		}
+		if gotMD5 == md5 && gotHash == hash {
+			return it
+		}
+	}
+	t.Fatalf("no item for md5=%q hash=%q", md5, hash)
+	return nil
+}
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+
+	issued := []Issued{
+		dynamic("a", "INV-1", 10, khqr.USD, 0),
+		dynamic("b", "INV-2", 20, khqr.USD, 0),
+		dynamic("c", "INV-3", 40000, khqr.KHR, 0),
+		dynamic("d", "INV-4", 5, khqr.USD, 0),
+		dynamic("e", "INV-5", 7.5, khqr.USD, 0),
+		dynamic("f", "", 12.34, khqr.USD, 0),
+		dynamic("g", "INV-7", 1, khqr.USD, 0),
+		{MD5: "s", Currency: khqr.KHR, IssuedAt: t0}, // static
+		{MD5: "s2", Currency: khqr.USD, IssuedAt: t0},
+	}
+	txs := []Transaction{
+		paid("a", "", 10, khqr.USD, time.Minute),       // clean, by MD5
+		paid("", "INV-2", 19.5, khqr.USD, time.Minute), // underpaid, by bill
+		paid("c", "", 40000, khqr.KHR, 11*time.Minute), // expired then paid
+		paid("d", "", 5, khqr.USD, time.Minute),        // clean
+		paid("d", "", 5, khqr.USD, 2*time.Minute),      // duplicate
+		paid("e", "", 7.5, khqr.KHR, time.Minute),      // currency mismatch
+		paid("", "", 12.34, khqr.USD, 3*time.Minute),   // by unique amount
+		paid("", "INV-9", 3, khqr.USD, time.Minute),    // unknown bill
+		paid("s", "", 1000, khqr.KHR, time.Minute),     // static, any amount
+		paid("s", "", 5000, khqr.KHR, 2*time.Minute),   // static, paid again
+		paid("x", "", 10.001, khqr.USD, time.Minute),   // unknown md5, no open code for the amount
+		paid("", "", 99, khqr.USD, time.Minute),        // no match at all
+	}
+
+	rep := Reconcile(issued, txs)
+
+	tests := []struct {
+		md5, hash string
+		matched   MatchKey
+		flags     []Flag
+	}{
+		{"a", "tx-a", MatchMD5, nil},
+		{"b", "tx-INV-2", MatchBillNumber, []Flag{FlagUnderpaid}},
+		{"c", "tx-c", MatchMD5, []Flag{FlagExpiredThenPaid}},
+		{"e", "tx-e", MatchMD5, []Flag{FlagCurrencyMismatch}},
+		{"f", "tx-", MatchAmount, nil},
+		{"", "tx-INV-9", "", []Flag{FlagUnmatchedTransaction}},
+		{"g", "", "", []Flag{FlagUnpaid}},
+	}
+	for _, tt := range tests {
+		it := find(t, rep, tt.md5, tt.hash)
+		if it.MatchedBy != tt.matched || !slices.Equal(it.Flags, tt.flags) {
+			t.Errorf("item md5=%q hash=%q = %s %v, want %s %v", tt.md5, tt.hash, it.MatchedBy, it.Flags, tt.matched, tt.flags)
+		}
+	}
+
+	var dFlags [][]Flag
+	var sCount int
+	for _, it := range rep.Items {
+		if it.Issued != nil && it.Issued.MD5 == "d" {
+			dFlags = append(dFlags, it.Flags)
+		}
+		if it.Issued != nil && it.Issued.MD5 == "s" {
+			sCount++
+			if !it.OK() {
+				t.Errorf("static payment flagged %v", it.Flags)
+			}
+		}
+		if it.Issued != nil && it.Issued.MD5 == "s2" {
+			t.Errorf("unpaid static code reported: %+v", it)
+		}
+	}
+	if len(dFlags) != 2 || dFlags[0] != nil || !slices.Equal(dFlags[1], []Flag{FlagDuplicate}) {
+		t.Errorf("repeated payments of d flags = %v, want [[] [duplicate]]", dFlags)
+	}
+	if sCount != 2 {
+		t.Errorf("static code matched %d payments, want 2", sCount)
+	}
+
+	want := Summary{
+		Issued: 9, Transactions: 12, Matched: 9, OK: 5,
+		Flags: map[Flag]int{
+			FlagUnderpaid: 1, FlagExpiredThenPaid: 1, FlagDuplicate: 1, FlagCurrencyMismatch: 1,
+			FlagUnmatchedTransaction: 3, FlagUnpaid: 1,
+		},
+	}
+	got := rep.Summary
+	if got.Issued != want.Issued || got.Transactions != want.Transactions || got.Matched != want.Matched || got.OK != want.OK {
+		t.Errorf("Summary = %+v, want %+v", got, want)
+	}
+	for f, n := range want.Flags {
+		if got.Flags[f] != n {
+			t.Errorf("Summary.Flags[%s] = %d, want %d", f, got.Flags[f], n)
+		}
+	}
+}
+
+func TestReconcileAmbiguousAmount(t *testing.T) {
+	t.Parallel()
+
+	issued := []Issued{dynamic("a", "", 5, khqr.USD, 0), dynamic("b", "", 5, khqr.USD, 0)}
+	rep := Reconcile(issued, []Transaction{paid("", "", 5, khqr.USD, time.Minute)})
+	if it := find(t, rep, "", "tx-"); !it.Has(FlagUnmatchedTransaction) {
+		t.Errorf("ambiguous amount matched: %+v", it)
+	}
+}
+
+func TestReconcileBillNumberPrefersOpenCode(t *testing.T) {
+	t.Parallel()
+
+	// The invoice was reissued after the first code expired.
+	issued := []Issued{
+		dynamic("old", "INV-1", 5, khqr.USD, 0),
+		dynamic("new", "INV-1", 5, khqr.USD, 15*time.Minute),
+	}
+	rep := Reconcile(issued, []Transaction{paid("", "INV-1", 5, khqr.USD, 20*time.Minute)})
+	if it := find(t, rep, "new", "tx-INV-1"); !it.OK() {
+		t.Errorf("payment matched to reissued code flagged %v", it.Flags)
+	}
+	if it := find(t, rep, "old", ""); !it.Has(FlagUnpaid) {
+		t.Errorf("expired code flags = %v, want unpaid", it.Flags)
+	}
+}
+
+func TestReconcileFromRecord(t *testing.T) {
+	t.Parallel()
+
+	r := &khqr.Record{
+		MD5:       "abc",
+		Merchant:  &khqr.MerchantInfo{BillNumber: "INV-1", Amount: 3, Currency: khqr.USD},
+		CreatedAt: t0,
+		ExpiresAt: t0.Add(time.Minute),
+	}
+	got := FromRecord(r)
+	want := Issued{MD5: "abc", BillNumber: "INV-1", Amount: 3, Currency: khqr.USD, IssuedAt: t0, ExpiresAt: t0.Add(time.Minute)}
+	if got != want {
+		t.Errorf("FromRecord() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReportOutput(t *testing.T) {
+	t.Parallel()
+
+	rep := Reconcile(
+		[]Issued{dynamic("a", "INV-1", 10, khqr.USD, 0), dynamic("b", "INV-2", 2, khqr.USD, 0)},
+		[]Transaction{paid("a", "", 9, khqr.USD, time.Minute)},
+	)
+
+	var buf bytes.Buffer
+	if err := rep.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV() unexpected error: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+	if len(rows) != 3 || !slices.Equal(rows[0], csvHeader) {
+		t.Fatalf("CSV rows = %v", rows)
+	}
+	wantRow := []string{
+		"attention", "underpaid", "md5", "a", "INV-1", "10", "USD", "2026-10-19T09:10:00Z",
+		"tx-a", "9", "USD", "2026-10-19T09:01:00Z", "",
+	}
+	if !slices.Equal(rows[1], wantRow) {
+		t.Errorf("CSV row = %v, want %v", rows[1], wantRow)
+	}
+	if rows[2][1] != "unpaid" || rows[2][8] != "" {
+		t.Errorf("unpaid row = %v", rows[2])
+	}
+
+	buf.Reset()
+	if err := rep.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText() unexpected error: %v", err)
+	}
+	for _, want := range []string{"Matched:       1", "underpaid", "unpaid", "md5=b bill=INV-2"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("WriteText() missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	b, err := json.Marshal(rep)
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	if !strings.Contains(string(b), `"flags":{"underpaid":1,"unpaid":1}`) {
+		t.Errorf("json.Marshal() = %s", b)
+	}
+}
+
+func TestReportJSONWithoutCurrency(t *testing.T) {
+	t.Parallel()
+
+	issued, err := ReadIssuedCSV(strings.NewReader("md5,amount,currency\na,0,\n"))
+	if err != nil {
+		t.Fatalf("ReadIssuedCSV() unexpected error: %v", err)
+	}
+	txs, err := ReadTransactionsCSV(strings.NewReader("md5,amount,currency,paid_at\na,5,,2026-10-19T09:00:00Z\n"))
+	if err != nil {
+		t.Fatalf("ReadTransactionsCSV() unexpected error: %v", err)
+	}
+	b, err := json.Marshal(Reconcile(issued, txs))
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	if strings.Contains(string(b), `"currency"`) {
+		t.Errorf("json.Marshal() = %s, want no currency fields", b)
+	}
+}
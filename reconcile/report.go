@@ -0,0 +1,118 @@
+package reconcile
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	khqr "github.com/ishinvin/go-khqr"
+)
+
+// csvHeader is the column layout written by WriteCSV.
+var csvHeader = []string{
+	"status", "flags", "matched_by", "md5", "bill_number", "issued_amount", "issued_currency",
+	"expires_at", "hash", "paid_amount", "paid_currency", "paid_at", "from_account_id",
+}
+
+// WriteCSV writes one row per item. status is "ok" or "attention"; flags
+// are separated by semicolons. Times are RFC 3339.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for i := range r.Items {
+		if err := cw.Write(r.Items[i].csvRow()); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (it *Item) csvRow() []string {
+	row := make([]string, len(csvHeader))
+	row[0] = "ok"
+	if !it.OK() {
+		row[0] = "attention"
+	}
+	flags := make([]string, len(it.Flags))
+	for i, f := range it.Flags {
+		flags[i] = string(f)
+	}
+	row[1] = strings.Join(flags, ";")
+	row[2] = string(it.MatchedBy)
+	if code := it.Issued; code != nil {
+		row[3], row[4] = code.MD5, code.BillNumber
+		row[5], row[6] = formatAmount(code.Amount), formatCurrency(code.Currency)
+		row[7] = formatTime(code.ExpiresAt)
+	}
+	if tx := it.Transaction; tx != nil {
+		if row[3] == "" {
+			row[3], row[4] = tx.MD5, tx.BillNumber
+		}
+		row[8] = tx.Hash
+		row[9], row[10] = formatAmount(tx.Amount), formatCurrency(tx.Currency)
+		row[11], row[12] = formatTime(tx.PaidAt), tx.FromAccountID
+	}
+	return row
+}
+
+// WriteText writes a human-readable summary followed by the items that
+// need attention.
+func (r *Report) WriteText(w io.Writer) error {
+	s := &r.Summary
+	var b strings.Builder
+	fmt.Fprintf(&b, "Issued codes:  %d\nTransactions:  %d\nMatched:       %d\nOK:            %d\n",
+		s.Issued, s.Transactions, s.Matched, s.OK)
+	for _, f := range flagOrder {
+		if n := s.Flags[f]; n > 0 {
+			fmt.Fprintf(&b, "  %-22s %d\n", f, n)
+		}
+	}
+	for i := range r.Items {
+		it := &r.Items[i]
+		if it.OK() {
+			continue
+		}
+		row := it.csvRow()
+		fmt.Fprintf(&b, "%s md5=%s bill=%s issued=%s %s paid=%s %s at %s\n",
+			row[1], dash(row[3]), dash(row[4]), dash(row[5]), row[6], dash(row[9]), row[10], dash(row[11]))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// flagOrder is the order flags are listed in WriteText.
+var flagOrder = []Flag{
+	FlagUnmatchedTransaction, FlagUnpaid, FlagUnderpaid, FlagOverpaid,
+	FlagCurrencyMismatch, FlagDuplicate, FlagExpiredThenPaid,
+}
+
+func formatAmount(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatCurrency(c khqr.Currency) string {
+	if c == 0 {
+		return ""
+	}
+	return c.String()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
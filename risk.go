@@ -0,0 +1,437 @@
+package khqr
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// RiskThreshold is the report score at or above which a code should not be
+// paid without a human looking at it.
+const RiskThreshold = 50
+
+// DefaultMaxExpiryWindow is the longest a dynamic code may stay valid
+// before AnalyzeRisk flags it.
+const DefaultMaxExpiryWindow = 24 * time.Hour
+
+// Finding scores. A report's score is their sum, capped at 100.
+const (
+	scoreAccountMismatch = 80
+	scoreConfusableName  = 60
+	scoreUnexpectedBank  = 50
+	scoreDuplicateTag    = 50
+	scoreNameMismatch    = 40
+	scoreFieldMismatch   = 30
+	scoreUnknownBank     = 30
+	scoreMixedScript     = 30
+	scoreExpiryWindow    = 30
+	scoreInvisibleChars  = 20
+	scoreExpiryMinor     = 20
+	scoreCityMismatch    = 10
+	maxRiskScore         = 100
+)
+
+// clockSkew is how far in the future a creation timestamp may be before it
+// is flagged.
+const clockSkew = 5 * time.Minute
+
+// RiskCheck identifies the heuristic behind a RiskFinding.
+type RiskCheck string
+
+const (
+	RiskMerchantMismatch RiskCheck = "merchant_mismatch" // differs from the expected merchant
+	RiskConfusableName   RiskCheck = "confusable_name"   // name imitates another or hides characters
+	RiskUnexpectedBank   RiskCheck = "unexpected_bank"   // account on a bank outside the allowed or known set
+	RiskExpiryWindow     RiskCheck = "expiry_window"     // timestamps inconsistent or unusually long-lived
+	RiskDuplicateTag     RiskCheck = "duplicate_tag"     // tag repeated, so decoders may disagree
+)
+
+// RiskFinding is one suspicious property of a code.
+type RiskFinding struct {
+	Check  RiskCheck `json:"check"`
+	Path   string    `json:"path"`  // tag path as in Explain, e.g. "29.00"
+	Score  int       `json:"score"` // 1-100, higher is more suspicious
+	Detail string    `json:"detail"`
+}
+
+// RiskReport is the result of AnalyzeRisk.
+type RiskReport struct {
+	Score    int           `json:"score"`    // sum of finding scores, capped at 100
+	Findings []RiskFinding `json:"findings"` // highest score first
+}
+
+// Risky reports whether the score reaches RiskThreshold.
+func (r *RiskReport) Risky() bool {
+	return r.Score >= RiskThreshold
+}
+
+func (r *RiskReport) addf(check RiskCheck, path string, score int, format string, args ...any) {
+	r.Findings = append(r.Findings, RiskFinding{Check: check, Path: path, Score: score, Detail: fmt.Sprintf(format, args...)})
+}
+
+// RiskOption configures AnalyzeRisk.
+type RiskOption func(*riskConfig)
+
+type riskConfig struct {
+	expected  *riskExpectation
+	banks     map[string]bool // nil means any registered bank
+	maxWindow time.Duration
+	now       time.Time
+}
+
+// riskExpectation is the subset of MerchantInfo and IndividualInfo that a
+// decoded code is compared against. Empty fields are not compared.
+type riskExpectation struct {
+	merchantType  MerchantType
+	accountID     string
+	name, altName string
+	city          string
+	merchantID    string
+	acquiringBank string
+	currency      Currency
+}
+
+// WithExpectedMerchant compares the code against the merchant it is
+// supposed to pay. Only non-empty fields of info are compared.
+func WithExpectedMerchant(info MerchantInfo) RiskOption { //nolint:gocritic // value param matches GenerateMerchant
+	return func(c *riskConfig) {
+		c.expected = &riskExpectation{
+			merchantType: Merchant, accountID: info.BakongAccountID,
+			name: info.MerchantName, altName: info.AltMerchantName, city: info.MerchantCity,
+			merchantID: info.MerchantID, acquiringBank: info.AcquiringBank, currency: info.Currency,
+		}
+	}
+}
+
+// WithExpectedIndividual compares the code against the individual it is
+// supposed to pay. Only non-empty fields of info are compared.
+func WithExpectedIndividual(info IndividualInfo) RiskOption { //nolint:gocritic // value param matches GenerateIndividual
+	return func(c *riskConfig) {
+		c.expected = &riskExpectation{
+			merchantType: Individual, accountID: info.BakongAccountID,
+			name: info.MerchantName, altName: info.AltMerchantName, city: info.MerchantCity,
+			acquiringBank: info.AcquiringBank, currency: info.Currency,
+		}
+	}
+}
+
+// WithAllowedBanks flags accounts whose bank suffix is not one of codes.
+// By default any bank in the registry is accepted.
+func WithAllowedBanks(codes ...string) RiskOption {
+	return func(c *riskConfig) {
+		c.banks = make(map[string]bool, len(codes))
+		for _, code := range codes {
+			c.banks[strings.ToLower(strings.TrimSpace(code))] = true
+		}
+	}
+}
+
+// WithMaxExpiryWindow sets how long a dynamic code may stay valid before
+// it is flagged (default DefaultMaxExpiryWindow).
+func WithMaxExpiryWindow(d time.Duration) RiskOption {
+	return func(c *riskConfig) { c.maxWindow = d }
+}
+
+// WithRiskTime evaluates timestamps as of t instead of the current time,
+// for analysing codes captured earlier.
+func WithRiskTime(t time.Time) RiskOption {
+	return func(c *riskConfig) { c.now = t }
+}
+
+// AnalyzeRisk scores how likely data is to be a tampered or fraudulent
+// code, such as a sticker pasted over a merchant's own. It never fails;
+// fields that do not pass validation are simply judged as they are.
+// Duplicated tags are lost once a payload is decoded; use AnalyzeRiskQR to
+// check for them too.
+func AnalyzeRisk(data *DecodedData, opts ...RiskOption) *RiskReport {
+	cfg := riskConfig{maxWindow: DefaultMaxExpiryWindow, now: time.Now()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	r := &RiskReport{}
+	if cfg.expected != nil {
+		r.checkExpected(data, cfg.expected)
+	} else {
+		r.checkName(tagMerchantName, data.MerchantName)
+		r.checkName(tagLanguageTemplate+"."+subtagMerchantNameAlt, data.AltMerchantName)
+	}
+	r.checkBank(data, cfg.banks)
+	r.checkExpiry(data, &cfg)
+	return r.finish()
+}
+
+// AnalyzeRiskQR decodes qr and analyzes it like AnalyzeRisk, also flagging
+// repeated tags. It returns an error only if qr cannot be decoded.
+func AnalyzeRiskQR(qr string, opts ...RiskOption) (*RiskReport, error) {
+	data, err := decode(qr)
+	if err != nil {
+		return nil, err
+	}
+	r := AnalyzeRisk(data, opts...)
+	nodes, _ := parseTLVTree(strings.TrimSpace(qr), "", khqrTags)
+	r.checkDuplicates(nodes)
+	return r.finish(), nil
+}
+
+// finish orders the findings and recomputes the score.
+func (r *RiskReport) finish() *RiskReport {
+	slices.SortStableFunc(r.Findings, func(a, b RiskFinding) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), strings.Compare(a.Path, b.Path))
+	})
+	r.Score = 0
+	for _, f := range r.Findings {
+		r.Score += f.Score
+	}
+	r.Score = min(r.Score, maxRiskScore)
+	return r
+}
+
+func (r *RiskReport) checkExpected(data *DecodedData, e *riskExpectation) {
+	tmpl := tagIndividualAccount
+	if data.MerchantType == Merchant {
+		tmpl = tagMerchantAccount
+	}
+	if data.MerchantType != e.merchantType {
+		r.addf(RiskMerchantMismatch, tmpl, scoreFieldMismatch, "%s account, expected %s", data.MerchantType, e.merchantType)
+	}
+	if e.accountID != "" && !strings.EqualFold(data.BakongAccountID, e.accountID) {
+		r.addf(RiskMerchantMismatch, tmpl+"."+subtagGlobalID, scoreAccountMismatch,
+			"pays %q, expected %q", data.BakongAccountID, e.accountID)
+	}
+	if e.merchantID != "" && data.MerchantID != e.merchantID {
+		r.addf(RiskMerchantMismatch, tmpl+"."+subtagMerchantID, scoreFieldMismatch, "merchant ID %q, expected %q", data.MerchantID, e.merchantID)
+	}
+	if e.acquiringBank != "" && !strings.EqualFold(data.AcquiringBank, e.acquiringBank) {
+		r.addf(RiskMerchantMismatch, tmpl+"."+subtagAcquiringBank, scoreFieldMismatch,
+			"acquiring bank %q, expected %q", data.AcquiringBank, e.acquiringBank)
+	}
+	if e.currency != 0 && data.TransactionCurrency != formatCurrency(e.currency) {
+		r.addf(RiskMerchantMismatch, tagCurrency, scoreFieldMismatch, "currency %s, expected %s", data.TransactionCurrency, formatCurrency(e.currency))
+	}
+	if e.city != "" && !strings.EqualFold(strings.TrimSpace(data.MerchantCity), strings.TrimSpace(e.city)) {
+		r.addf(RiskMerchantMismatch, tagMerchantCity, scoreCityMismatch, "city %q, expected %q", data.MerchantCity, e.city)
+	}
+	r.compareName(tagMerchantName, data.MerchantName, e.name)
+	r.compareName(tagLanguageTemplate+"."+subtagMerchantNameAlt, data.AltMerchantName, e.altName)
+}
+
+// compareName flags a name that differs from want, scoring a near miss
+// higher than an outright mismatch because it suggests imitation.
+func (r *RiskReport) compareName(path, got, want string) {
+	if want == "" {
+		r.checkName(path, got)
+		return
+	}
+	if foldName(got) == foldName(want) {
+		return
+	}
+	g, w := nameSkeleton(got), nameSkeleton(want)
+	if g == w || (len(w) >= 5 && editDistance(g, w) <= 1) { //nolint:mnd // short names are too easy to hit by chance
+		r.addf(RiskConfusableName, path, scoreConfusableName, "%q looks like but is not %q", got, want)
+		return
+	}
+	r.addf(RiskMerchantMismatch, path, scoreNameMismatch, "name %q, expected %q", got, want)
+}
+
+// checkName flags names that hide characters or mix scripts inside a word,
+// which genuine merchant names rarely do.
+func (r *RiskReport) checkName(path, name string) {
+	if strings.ContainsFunc(name, isInvisibleRune) {
+		r.addf(RiskConfusableName, path, scoreInvisibleChars, "%q contains invisible characters", name)
+	}
+	for word := range strings.FieldsSeq(name) {
+		if scripts := wordScripts(word); len(scripts) > 1 {
+			r.addf(RiskConfusableName, path, scoreMixedScript, "%q mixes %s letters", word, strings.Join(scripts, " and "))
+			return
+		}
+	}
+}
+
+func (r *RiskReport) checkBank(data *DecodedData, allowed map[string]bool) {
+	_, bank, ok := strings.Cut(data.BakongAccountID, "@")
+	if !ok {
+		return
+	}
+	path := tagIndividualAccount + "." + subtagGlobalID
+	if data.MerchantType == Merchant {
+		path = tagMerchantAccount + "." + subtagGlobalID
+	}
+	switch {
+	case allowed != nil && !allowed[strings.ToLower(bank)]:
+		r.addf(RiskUnexpectedBank, path, scoreUnexpectedBank, "bank %q is not one of the allowed banks", bank)
+	case allowed == nil:
+		if _, known := LookupBank(bank); !known {
+			r.addf(RiskUnexpectedBank, path, scoreUnknownBank, "bank %q is not a registered Bakong participant", bank)
+		}
+	}
+}
+
+func (r *RiskReport) checkExpiry(data *DecodedData, cfg *riskConfig) {
+	createdPath := tagTimestamp + "." + subtagCreationTimestamp
+	expiresPath := tagTimestamp + "." + subtagExpirationTimestamp
+	created, okCreated := parseRiskTimestamp(r, createdPath, data.CreationTimestamp)
+	expires, okExpires := parseRiskTimestamp(r, expiresPath, data.ExpirationTimestamp)
+
+	if data.PointOfInitiationMethod == staticQR {
+		if data.ExpirationTimestamp != "" {
+			r.addf(RiskExpiryWindow, expiresPath, scoreExpiryMinor, "static code carries an expiration timestamp")
+		}
+		return
+	}
+	if data.ExpirationTimestamp == "" {
+		r.addf(RiskExpiryWindow, expiresPath, scoreExpiryWindow, "dynamic code has no expiration")
+	}
+	if okCreated && created.After(cfg.now.Add(clockSkew)) {
+		r.addf(RiskExpiryWindow, createdPath, scoreExpiryWindow, "created in the future at %s", created.Format(time.RFC3339))
+	}
+	if !okExpires {
+		return
+	}
+	if expires.Before(cfg.now) {
+		r.addf(RiskExpiryWindow, expiresPath, scoreExpiryMinor, "expired at %s", expires.Format(time.RFC3339))
+	}
+	if !okCreated {
+		return
+	}
+	switch window := expires.Sub(created); {
+	case window < 0:
+		r.addf(RiskExpiryWindow, expiresPath, scoreExpiryWindow, "expires before it was created")
+	case window > cfg.maxWindow:
+		r.addf(RiskExpiryWindow, expiresPath, scoreExpiryWindow, "valid for %s, longer than %s", window, cfg.maxWindow)
+	}
+}
+
+// parseRiskTimestamp parses a millisecond timestamp, flagging values that
+// are present but malformed.
+func parseRiskTimestamp(r *RiskReport, path, s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || len(s) != 13 { //nolint:mnd // timestamp string length
+		r.addf(RiskExpiryWindow, path, scoreExpiryWindow, "%q is not a valid timestamp", s)
+		return time.Time{}, false
+	}
+	return time.UnixMilli(ms).UTC(), true
+}
+
+// checkDuplicates flags every repeat of a tag within the same template.
+func (r *RiskReport) checkDuplicates(nodes []tlvNode) {
+	seen := map[string]bool{}
+	for _, n := range nodes {
+		if seen[n.tag] {
+			r.addf(RiskDuplicateTag, n.path, scoreDuplicateTag, "tag repeated; decoders may read either value")
+		}
+		seen[n.tag] = true
+		r.checkDuplicates(n.children)
+	}
+}
+
+// isInvisibleRune reports runes that render as nothing and can make two
+// different names look identical.
+func isInvisibleRune(r rune) bool {
+	return isFormatRune(r) || r == '\u00AD' || r == '\u17B4' || r == '\u17B5'
+}
+
+// riskScripts are the scripts a name is checked against. Digits and
+// punctuation belong to none and never count as mixing.
+var riskScripts = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"Latin", unicode.Latin},
+	{"Khmer", unicode.Khmer},
+	{"Cyrillic", unicode.Cyrillic},
+	{"Greek", unicode.Greek},
+}
+
+// wordScripts returns the scripts used in word, in riskScripts order.
+func wordScripts(word string) []string {
+	var names []string
+	for _, s := range riskScripts {
+		if strings.ContainsFunc(word, func(r rune) bool { return unicode.Is(s.table, r) }) {
+			names = append(names, s.name)
+		}
+	}
+	return names
+}
+
+// foldName normalizes case and spacing only.
+func foldName(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// confusableRunes maps characters to the Latin or Khmer character they are
+// commonly mistaken for.
+var confusableRunes = map[rune]rune{
+	// Cyrillic
+	'\u0430': 'a', '\u0441': 'c', '\u0501': 'd', '\u0435': 'e', '\u04BB': 'h', '\u0456': 'i', '\u0458': 'j', '\u043A': 'k',
+	'\u043E': 'o', '\u0440': 'p', '\u051B': 'q', '\u0455': 's', '\u0443': 'y', '\u0445': 'x', '\u051D': 'w',
+	// Greek
+	'\u03B1': 'a', '\u03B9': 'i', '\u03BA': 'k', '\u03BD': 'v', '\u03BF': 'o', '\u03C1': 'p', '\u03C5': 'u', '\u03C7': 'x',
+	// Digits and symbols
+	'0': 'o', '1': 'l', '|': 'l', '$': 's',
+	// Khmer: deprecated and lookalike letters, and the Khmer digit zero
+	'\u17A3': '\u17A2', // KHMER INDEPENDENT VOWEL QAQ -> KHMER LETTER QA
+	'\u17B2': '\u17B1', // KHMER INDEPENDENT VOWEL QOO TYPE TWO -> TYPE ONE
+	'\u17C8': '\u17C7', // KHMER SIGN YUUKALEAPINTU -> REAHMUK
+	'\u17E0': 'o',      // KHMER DIGIT ZERO
+	// Khmer letters, digits and signs that pass for Latin in a short name
+	'\u1794': 'u', // KHMER LETTER BA
+	'\u178F': 'n', // KHMER LETTER TA
+	'\u1798': 'w', // KHMER LETTER MO
+	'\u17E3': 'm', // KHMER DIGIT THREE
+	'\u17D6': ':', // KHMER SIGN CAMNUC PII KUUH
+}
+
+// confusableSequences replaces multi-character lookalikes after
+// confusableRunes has been applied.
+var confusableSequences = strings.NewReplacer("rn", "m", "vv", "w", "\u17A4", "\u17A2\u17B6")
+
+// nameSkeleton reduces a name to a form in which lookalike names compare
+// equal: lowercase, without spaces or invisible characters, with
+// confusable characters replaced.
+func nameSkeleton(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || isInvisibleRune(r) {
+			return -1
+		}
+		r = unicode.ToLower(r)
+		if c, ok := confusableRunes[r]; ok {
+			return c
+		}
+		return r
+	}, s)
+	return confusableSequences.Replace(s)
+}
+
+// editDistance is the number of single-rune insertions, deletions,
+// substitutions or adjacent swaps that turn a into b.
+func editDistance(a, b string) int {
+	x, y := []rune(a), []rune(b)
+	prev2 := make([]int, len(y)+1)
+	prev := make([]int, len(y)+1)
+	cur := make([]int, len(y)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		cur[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && x[i-1] == y[j-2] && x[i-2] == y[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(y)]
+}
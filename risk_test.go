@@ -0,0 +1,277 @@
+package khqr
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeRisk(t *testing.T) {
+	t.Parallel()
+
+	now := time.UnixMilli(1_800_000_000_000)
+	ts := func(d time.Duration) string { return formatTimestamp(now.Add(d).UnixMilli()) }
+	expect := []RiskOption{WithExpectedMerchant(profileMerchant), WithAllowedBanks("devb"), WithRiskTime(now)}
+
+	tests := []struct {
+		name   string
+		mutate func(d *DecodedData)
+		opts   []RiskOption
+		want   []string // "check path score"
+	}{
+		{"genuine", func(*DecodedData) {}, expect, nil},
+		{
+			"case_and_spacing",
+			func(d *DecodedData) { d.MerchantName, d.MerchantCity = "JONH  SMITH", "phnom penh" },
+			expect,
+			nil,
+		},
+		{
+			"account_swapped",
+			func(d *DecodedData) { d.BakongAccountID = "scammer@devb" },
+			expect,
+			[]string{"merchant_mismatch 30.00 80"},
+		},
+		{
+			"individual_sticker",
+			func(d *DecodedData) { d.MerchantType, d.MerchantID, d.AcquiringBank = Individual, "", "" },
+			expect,
+			[]string{"merchant_mismatch 29 30", "merchant_mismatch 29.01 30", "merchant_mismatch 29.02 30"},
+		},
+		{
+			"homoglyph_name",
+			func(d *DecodedData) { d.MerchantName = "J\u043Enh Sm1th" },
+			expect,
+			[]string{"confusable_name 59 60"},
+		},
+		{
+			"invisible_in_name",
+			func(d *DecodedData) { d.MerchantName = "Jonh\u200B Smith" },
+			expect,
+			[]string{"confusable_name 59 60"},
+		},
+		{
+			"typo_name",
+			func(d *DecodedData) { d.MerchantName = "John Smith" },
+			expect,
+			[]string{"confusable_name 59 60"},
+		},
+		{
+			"khmer_homoglyph_name",
+			func(d *DecodedData) { d.MerchantName = "Jo\u178Fh S\u17E3ith" },
+			expect,
+			[]string{"confusable_name 59 60"},
+		},
+		{
+			"khmer_lookalike",
+			func(d *DecodedData) { d.AltMerchantName = strings.Replace(d.AltMerchantName, "ស", "ស\u17B4", 1) },
+			expect,
+			[]string{"confusable_name 64.01 60"},
+		},
+		{
+			"different_name",
+			func(d *DecodedData) { d.MerchantName, d.MerchantCity = "Coffee Corner", "Siem Reap" },
+			expect,
+			[]string{"merchant_mismatch 59 40", "merchant_mismatch 60 10"},
+		},
+		{
+			"no_profile_mixed_script",
+			func(d *DecodedData) { d.MerchantName, d.AltMerchantName = "Jonh Sm\u0456th", "Smith ចន" },
+			[]RiskOption{WithRiskTime(now)},
+			[]string{"unexpected_bank 30.00 30", "confusable_name 59 30"},
+		},
+		{
+			"no_profile_invisible",
+			func(d *DecodedData) { d.BakongAccountID, d.MerchantName = "jonhsmith@aclb", "Jonh\u00ADSmith" },
+			[]RiskOption{WithRiskTime(now)},
+			[]string{"confusable_name 59 20"},
+		},
+		{
+			"bank_not_allowed",
+			func(d *DecodedData) { d.BakongAccountID = "jonhsmith@wing" },
+			[]RiskOption{WithAllowedBanks("ABAA", "aclb"), WithRiskTime(now)},
+			[]string{"unexpected_bank 30.00 50"},
+		},
+		{
+			"dynamic_ok",
+			func(d *DecodedData) {
+				d.PointOfInitiationMethod, d.CreationTimestamp, d.ExpirationTimestamp = dynamicQR, ts(-time.Minute), ts(time.Hour)
+			},
+			expect,
+			nil,
+		},
+		{
+			"dynamic_long_lived_expired",
+			func(d *DecodedData) {
+				d.PointOfInitiationMethod, d.CreationTimestamp, d.ExpirationTimestamp = dynamicQR, ts(-90*24*time.Hour), ts(-time.Hour)
+			},
+			expect,
+			[]string{"expiry_window 99.01 30", "expiry_window 99.01 20"},
+		},
+		{
+			"dynamic_reversed_future",
+			func(d *DecodedData) {
+				d.PointOfInitiationMethod, d.CreationTimestamp, d.ExpirationTimestamp = dynamicQR, ts(2*time.Hour), ts(time.Hour)
+			},
+			expect,
+			[]string{"expiry_window 99.00 30", "expiry_window 99.01 30"},
+		},
+		{
+			"dynamic_missing_expiry",
+			func(d *DecodedData) { d.PointOfInitiationMethod, d.CreationTimestamp = dynamicQR, "17000" },
+			expect,
+			[]string{"expiry_window 99.00 30", "expiry_window 99.01 30"},
+		},
+		{
+			"window_option",
+			func(d *DecodedData) {
+				d.PointOfInitiationMethod, d.CreationTimestamp, d.ExpirationTimestamp = dynamicQR, ts(0), ts(time.Hour)
+			},
+			append(slices.Clone(expect), WithMaxExpiryWindow(10*time.Minute)),
+			[]string{"expiry_window 99.01 30"},
+		},
+		{
+			"static_with_expiry",
+			func(d *DecodedData) { d.ExpirationTimestamp = ts(time.Hour) },
+			expect,
+			[]string{"expiry_window 99.01 20"},
+		},
+	}
+	for _, tt := range tests {
+		data := decodedProfileMerchant(t)
+		tt.mutate(data)
+		r := AnalyzeRisk(data, tt.opts...)
+		var got []string
+		total := 0
+		for _, f := range r.Findings {
+			got = append(got, fmt.Sprintf("%s %s %d", f.Check, f.Path, f.Score))
+			total += f.Score
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: findings = %q, want %q (%+v)", tt.name, got, tt.want, r.Findings)
+		}
+		if r.Score != min(total, maxRiskScore) || r.Risky() != (r.Score >= RiskThreshold) {
+			t.Errorf("%s: Score = %d, Risky = %v for findings totalling %d", tt.name, r.Score, r.Risky(), total)
+		}
+	}
+}
+
+func TestAnalyzeRiskQR(t *testing.T) {
+	t.Parallel()
+
+	data, err := GenerateMerchant(profileMerchant)
+	if err != nil {
+		t.Fatalf("GenerateMerchant() unexpected error: %v", err)
+	}
+	r, err := AnalyzeRiskQR(data.QR, WithExpectedMerchant(profileMerchant), WithAllowedBanks("devb"))
+	if err != nil || len(r.Findings) != 0 {
+		t.Fatalf("AnalyzeRiskQR(genuine) = %+v, %v; want no findings", r, err)
+	}
+
+	// A second account template after the genuine one: decoders that keep
+	// the last value pay the fraudster.
+	qr := strings.TrimSuffix(data.QR, data.QR[len(data.QR)-8:])
+	qr = withCRC(qr + "3038" + "0012scammer@devb" + "0106123456" + "0208Dev Bank")
+	r, err = AnalyzeRiskQR(qr, WithExpectedMerchant(profileMerchant), WithAllowedBanks("devb"))
+	if err != nil {
+		t.Fatalf("AnalyzeRiskQR(duplicate) unexpected error: %v", err)
+	}
+	var checks []string
+	for _, f := range r.Findings {
+		checks = append(checks, fmt.Sprintf("%s %s", f.Check, f.Path))
+	}
+	want := []string{"merchant_mismatch 30.00", "duplicate_tag 30"}
+	if !slices.Equal(checks, want) || !r.Risky() || r.Score != maxRiskScore {
+		t.Errorf("AnalyzeRiskQR(duplicate) = %q score %d, want %q", checks, r.Score, want)
+	}
+
+	if _, err := AnalyzeRiskQR("0002010"); err == nil {
+		t.Error("AnalyzeRiskQR(malformed) expected error")
+	}
+}
+
+func TestAnalyzeRiskBakongWallet(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		account string
+		flagged bool
+	}{
+		{"ishin_vin@bkrt", false},
+		{"ishin_vin@aclb", false},
+		{"ishin_vin@bkrtt", true},
+	} {
+		data, err := GenerateIndividual(IndividualInfo{BakongAccountID: tt.account, MerchantName: "Ishin Vin"})
+		if err != nil {
+			t.Fatalf("GenerateIndividual(%s) unexpected error: %v", tt.account, err)
+		}
+		r, err := AnalyzeRiskQR(data.QR)
+		if err != nil {
+			t.Fatalf("AnalyzeRiskQR(%s) unexpected error: %v", tt.account, err)
+		}
+		flagged := slices.ContainsFunc(r.Findings, func(f RiskFinding) bool { return f.Check == RiskUnexpectedBank })
+		if flagged != tt.flagged {
+			t.Errorf("AnalyzeRiskQR(%s) bank finding = %v, want %v (findings %+v)", tt.account, flagged, tt.flagged, r.Findings)
+		}
+	}
+}
+
+func TestNameSkeleton(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"Jonh Smith", "J\u043Enh Smith", true},
+		{"Coffee", "C0ffee", true},
+		{"Modern Mart", "Rnodern Mart", true},
+		{"អាម", "\u17A4ម", true},
+		{"អម", "\u17A3ម", true},
+		{"Jonh Smith", "Jo\u178Fh S\u17E3ith", true},
+		{"Lucky Mart", "L\u1794cky Mart", true},
+		{"Sweet 10:30", "S\u1798eet 10\u17D630", true},
+		{"Coffee", "Coffea", false},
+	}
+	for _, tt := range tests {
+		if got := nameSkeleton(tt.a) == nameSkeleton(tt.b); got != tt.want {
+			t.Errorf("nameSkeleton(%q) == nameSkeleton(%q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"jonh", "john", 1},
+		{"kitten", "sitting", 3},
+		{"ចន", "នច", 1},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// decodedProfileMerchant decodes a static code generated for profileMerchant.
+func decodedProfileMerchant(t *testing.T) *DecodedData {
+	t.Helper()
+	data, err := GenerateMerchant(profileMerchant)
+	if err != nil {
+		t.Fatalf("GenerateMerchant() unexpected error: %v", err)
+	}
+	d, err := Decode(data.QR)
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+	return d
+}
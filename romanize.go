@@ -0,0 +1,286 @@
+package khqr
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// khmerConsonant is the romanization of a Khmer consonant and the series
+// that selects how the vowels after it are read.
+type khmerConsonant struct {
+	latin  string
+	second bool // second ("o") series; first series reads the inherent vowel as "a"
+}
+
+// khmerConsonants follows UNGEGN. QA carries no sound of its own.
+var khmerConsonants = map[rune]khmerConsonant{
+	'ក': {"k", false}, 'ខ': {"kh", false}, 'គ': {"k", true}, 'ឃ': {"kh", true}, 'ង': {"ng", true},
+	'ច': {"ch", false}, 'ឆ': {"chh", false}, 'ជ': {"ch", true}, 'ឈ': {"chh", true}, 'ញ': {"nh", true},
+	'ដ': {"d", false}, 'ឋ': {"th", false}, 'ឌ': {"d", true}, 'ឍ': {"th", true}, 'ណ': {"n", false},
+	'ត': {"t", false}, 'ថ': {"th", false}, 'ទ': {"t", true}, 'ធ': {"th", true}, 'ន': {"n", true},
+	'ប': {"b", false}, 'ផ': {"ph", false}, 'ព': {"p", true}, 'ភ': {"ph", true}, 'ម': {"m", true},
+	'យ': {"y", true}, 'រ': {"r", true}, 'ល': {"l", true}, 'វ': {"v", true}, 'ឝ': {"sh", false},
+	'ឞ': {"ss", false}, 'ស': {"s", false}, 'ហ': {"h", false}, 'ឡ': {"l", false}, 'អ': {"", false},
+}
+
+// khmerSonorants are the second-series consonants that leave the series of
+// a cluster to the consonant above them; any other subscript sets it.
+const khmerSonorants = "ងញនមយរលវ"
+
+// khmerIndependentVowels are the vowels written as letters of their own.
+var khmerIndependentVowels = map[rune]string{
+	'ឥ': "e", 'ឦ': "ei", 'ឧ': "o", 'ឩ': "ou", 'ឪ': "ov", 'ឫ': "roe", 'ឬ': "roe",
+	'ឭ': "loe", 'ឮ': "loe", 'ឯ': "e", 'ឰ': "ai", 'ឱ': "ao", 'ឲ': "ao", 'ឳ': "au",
+}
+
+// khmerVowels maps a dependent vowel, with the nikahit or reahmuk that can
+// follow it, to its first- and second-series romanization. The empty key
+// is the inherent vowel.
+var khmerVowels = map[string][2]string{
+	"":             {"a", "o"},
+	"\u17B6":       {"a", "ea"},    // AA
+	"\u17B7":       {"e", "i"},     // I
+	"\u17B8":       {"ei", "i"},    // II
+	"\u17B9":       {"oe", "oe"},   // Y
+	"\u17BA":       {"oe", "oe"},   // YY
+	"\u17BB":       {"o", "u"},     // U
+	"\u17BC":       {"o", "u"},     // UU
+	"\u17BD":       {"uo", "uo"},   // UA
+	"\u17BE":       {"aeu", "eu"},  // OE
+	"\u17BF":       {"eoe", "eoe"}, // YA
+	"\u17C0":       {"ie", "ie"},   // IE
+	"\u17C1":       {"e", "e"},     // E
+	"\u17C2":       {"e", "e"},     // AE
+	"\u17C3":       {"ai", "ey"},   // AI
+	"\u17C4":       {"ao", "ou"},   // OO
+	"\u17C5":       {"au", "ou"},   // AU
+	"\u17C6":       {"am", "um"},   // NIKAHIT
+	"\u17B6\u17C6": {"am", "oam"},
+	"\u17BB\u17C6": {"om", "um"},
+	"\u17C7":       {"ah", "eah"}, // REAHMUK
+	"\u17B7\u17C7": {"eh", "ih"},
+	"\u17BB\u17C7": {"oh", "uh"},
+	"\u17C1\u17C7": {"eh", "eh"},
+	"\u17C4\u17C7": {"aoh", "uoh"},
+}
+
+const (
+	khmerReahmuk     = '\u17C7'
+	khmerBantoc      = '\u17CB' // shortens the vowel; marks a final consonant
+	khmerToandakhiat = '\u17CD' // silences the consonant it is written on
+	khmerBa          = '\u1794'
+)
+
+// khmerFinalSigns are the signs that close a syllable themselves.
+const khmerFinalSigns = string(khmerNikahit) + string(khmerReahmuk)
+
+// Romanize transliterates Khmer text into plain Latin letters using UNGEGN
+// romanization without diacritics, e.g. "ភ្នំពេញ" to "Phnumpenh". Each
+// Khmer word is capitalized. Words are only separated where s has spaces,
+// since Khmer writes a phrase without them. Text in other scripts is kept.
+func Romanize(s string) string {
+	var b strings.Builder
+	in := []rune(NormalizeText(s))
+	for i := 0; i < len(in); {
+		r := in[i]
+		switch {
+		case isKhmerBase(r) || r == khmerCoeng:
+			j := i
+			for j < len(in) && isKhmerLetterOrMark(in[j]) {
+				j++
+			}
+			word := romanizeKhmerWord(in[i:j])
+			if prev, _ := utf8.DecodeLastRuneInString(b.String()); !unicode.IsLetter(prev) {
+				word = capitalize(word)
+			}
+			b.WriteString(word)
+			i = j
+			continue
+		case r >= '០' && r <= '៩':
+			b.WriteRune('0' + r - '០')
+		case r == '។' || r == '៕':
+			b.WriteByte('.')
+		case r == '៖':
+			b.WriteByte(':')
+		case r >= khmerFirstConsonant && r <= '\u17FF', r >= '\u19E0' && r <= '\u19FF':
+			// other Khmer signs and symbols have no Latin form
+		default:
+			b.WriteRune(r)
+		}
+		i++
+	}
+	return b.String()
+}
+
+func isKhmerLetterOrMark(r rune) bool {
+	return isKhmerBase(r) || r == khmerCoeng || khmerMarkRank(r) != 0
+}
+
+func capitalize(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
+
+// romanizeKhmerWord romanizes a run of Khmer letters and marks one
+// syllable at a time: a consonant cluster or independent vowel, its vowel
+// and, when the next consonant closes the syllable, that final consonant.
+func romanizeKhmerWord(in []rune) string {
+	var b strings.Builder
+	for i := 0; i < len(in); {
+		var hasVowel, closed bool
+		if v, ok := khmerIndependentVowels[in[i]]; ok {
+			b.WriteString(v)
+			hasVowel = true
+			i++
+		} else {
+			latin, second, next := khmerCluster(in, i)
+			key, shift, next := khmerVowelAt(in, next)
+			if shift != 0 {
+				second = shift == khmerTriisap
+			}
+			v, ok := khmerVowels[key]
+			if !ok {
+				v = khmerVowels[strings.TrimRight(key, khmerFinalSigns)]
+				v[0] += khmerSignLatin(key)
+				v[1] += khmerSignLatin(key)
+			}
+			b.WriteString(latin)
+			if second {
+				b.WriteString(v[1])
+			} else {
+				b.WriteString(v[0])
+			}
+			hasVowel = key != ""
+			closed = strings.ContainsAny(key, khmerFinalSigns)
+			i = next
+		}
+		final, next := khmerFinal(in, i, hasVowel, closed)
+		b.WriteString(final)
+		i = next
+	}
+	return b.String()
+}
+
+// khmerSignLatin romanizes a trailing nikahit or reahmuk the vowel table
+// has no combined entry for.
+func khmerSignLatin(key string) string {
+	switch {
+	case strings.HasSuffix(key, string(khmerNikahit)):
+		return "m"
+	case strings.HasSuffix(key, string(khmerReahmuk)):
+		return "h"
+	}
+	return ""
+}
+
+// khmerCluster reads a consonant and its subscripts starting at i. A
+// leading coeng, left when the consonant above it closed the previous
+// syllable, is skipped.
+func khmerCluster(in []rune, i int) (latin string, second bool, next int) {
+	if in[i] == khmerCoeng {
+		i++
+	}
+	if i >= len(in) {
+		return "", false, i
+	}
+	c, ok := khmerConsonants[in[i]]
+	if !ok {
+		return "", false, i + 1
+	}
+	latin, second = c.latin, c.second
+	if in[i] == khmerBa && i+2 < len(in) && in[i+1] == khmerCoeng && isKhmerBase(in[i+2]) {
+		latin = "p" // BA is read "p" before a subscript
+	}
+	i++
+	for i+1 < len(in) && in[i] == khmerCoeng {
+		sub, ok := khmerConsonants[in[i+1]]
+		if !ok {
+			break
+		}
+		latin += sub.latin
+		if !strings.ContainsRune(khmerSonorants, in[i+1]) {
+			second = sub.second
+		}
+		i += 2
+	}
+	return latin, second, i
+}
+
+// khmerVowelAt reads an optional register shifter, dependent vowel and
+// nikahit or reahmuk starting at i.
+func khmerVowelAt(in []rune, i int) (key string, shift rune, next int) {
+	if i < len(in) && (in[i] == khmerMuusikatoan || in[i] == khmerTriisap) {
+		shift = in[i]
+		i++
+	}
+	start := i
+	if i < len(in) && in[i] >= khmerVowelAA && in[i] <= khmerVowelAU {
+		i++
+	}
+	if i < len(in) && (in[i] == khmerNikahit || in[i] == khmerReahmuk) {
+		i++
+	}
+	for i < len(in) && khmerMarkRank(in[i]) == khmerRankSign && in[i] != khmerToandakhiat {
+		i++ // bantoc and other signs without a Latin form
+	}
+	return string(in[start:i]), shift, i
+}
+
+// khmerFinal decides whether the consonant at i closes the syllable before
+// it and returns its romanization. Khmer does not mark syllable
+// boundaries, so this follows the spelling: a consonant is final when it
+// carries a bantoc, or has no vowel of its own and is not needed to start
+// the next syllable.
+func khmerFinal(in []rune, i int, hasVowel, closed bool) (latin string, next int) {
+	if i >= len(in) {
+		return "", i
+	}
+	c, ok := khmerConsonants[in[i]]
+	if !ok {
+		return "", i
+	}
+	j := i + 1
+	for j+1 < len(in) && in[j] == khmerCoeng && isKhmerBase(in[j+1]) {
+		j += 2
+	}
+	subscripts := j > i+1
+	var after rune
+	if j < len(in) {
+		after = in[j]
+	}
+	switch {
+	case after == khmerToandakhiat:
+		return "", j + 1
+	case after == khmerBantoc:
+		latin, _, _ = khmerCluster(in, i)
+		return latin, j + 1
+	case khmerMarkRank(after) != 0 || closed:
+		return "", i
+	case subscripts && !hasVowel:
+		return c.latin, i + 1 // the subscripts start the next syllable
+	case subscripts && after == 0:
+		latin, _, _ = khmerCluster(in, i)
+		return latin, j
+	case subscripts:
+		return "", i
+	}
+	return c.latin, j
+}
+
+// romanizedMerchantName fills a missing MerchantName from a Khmer
+// AltMerchantName, cut at a word boundary to fit maxMerchantNameLength.
+func romanizedMerchantName(name, preference, alt string) string {
+	if strings.TrimSpace(name) != "" || !strings.EqualFold(strings.TrimSpace(preference), "km") {
+		return name
+	}
+	s := strings.Join(strings.Fields(Romanize(alt)), " ")
+	if utf8.RuneCountInString(s) <= maxMerchantNameLength {
+		return s
+	}
+	cut := TruncateText(s, maxMerchantNameLength)
+	if i := strings.LastIndexByte(cut, ' '); i > 0 && s[len(cut)] != ' ' {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut)
+}
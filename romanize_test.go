@@ -0,0 +1,103 @@
+package khqr
+
+import (
+	"errors"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRomanize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"Jonh Smith", "Jonh Smith"},
+		{"ចន ស្មីន", "Chan Smein"},
+		{"កម្ពុជា", "Kampuchea"},
+		{"ខ្មែរ", "Khmer"},
+		{"ភ្នំពេញ", "Phnumpenh"},
+		{"សៀមរាប", "Siemreab"},
+		{"បាត់ដំបង", "Batdambang"},
+		{"កំពត", "Kampot"},
+		{"តាកែវ", "Takev"},
+		{"អង្គរ", "Angkor"},
+		{"ផ្សារធំថ្មី", "Phsarthumthmei"},
+		{"ហាង កាហ្វេ", "Hang Kahve"},
+		{"ហេង ហេង", "Heng Heng"},
+		{"ធនាគារ", "Thoneakear"},
+		{"ស្ពាន", "Spean"},
+		{"ព្រះ", "Preah"},
+		{"ស្រី ពេជ្រ", "Srei Pechr"},
+		{"ប្រាក់", "Prak"},
+		{"សួស្តី", "Suostei"},
+		{"ឯក", "Ek"},
+		{"គីមស៊ាន", "Kimsean"},
+		{"ម៉ាក់ស៊ីម", "Maksim"},
+		{"ហាង ABC ១២", "Hang ABC 12"},
+		{"ចន\u200Bស្មីន", "Chansmein"},
+		{"ស្រ្តី", "Strei"},
+	}
+	for _, tt := range tests {
+		if got := Romanize(tt.in); got != tt.want {
+			t.Errorf("Romanize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateRomanizesMerchantName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		merchant   string
+		preference string
+		alt        string
+		want       string
+		wantErr    error
+	}{
+		{"filled", "", "km", "ផ្សារធំថ្មី", "Phsarthumthmei", nil},
+		{"preference_case", "", "KM", "ចន ស្មីន", "Chan Smein", nil},
+		{"kept", "Central Market", "km", "ផ្សារធំថ្មី", "Central Market", nil},
+		{"word_boundary", "", "km", "ហាងឆ្ងាញ់ ផ្សារធំថ្មី", "Hangchhnganh", nil},
+		{"hard_cut", "", "km", "ផ្សារធំថ្មីភ្នំពេញកម្ពុជា", "Phsarthumthmeiphnumpenhka", nil},
+		{"other_language", "", "zh", "中文名", "", ErrMerchantNameRequired},
+		{"no_alt", "", "", "", "", ErrMerchantNameRequired},
+	}
+	for _, tt := range tests {
+		info := MerchantInfo{
+			BakongAccountID:       "jonhsmith@devb",
+			MerchantName:          tt.merchant,
+			MerchantCity:          "Phnom Penh",
+			MerchantID:            "123456",
+			AcquiringBank:         "Dev Bank",
+			AltLanguagePreference: tt.preference,
+			AltMerchantName:       tt.alt,
+		}
+		data, err := GenerateMerchant(info)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: GenerateMerchant() err = %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		decoded, err := Decode(data.QR)
+		if err != nil {
+			t.Fatalf("%s: Decode() unexpected error: %v", tt.name, err)
+		}
+		if decoded.MerchantName != tt.want || utf8.RuneCountInString(decoded.MerchantName) > maxMerchantNameLength {
+			t.Errorf("%s: MerchantName = %q, want %q", tt.name, decoded.MerchantName, tt.want)
+		}
+	}
+
+	individual := IndividualInfo{BakongAccountID: "jonhsmith@devb", AltLanguagePreference: "km", AltMerchantName: "ចន ស្មីន"}
+	data, err := GenerateIndividual(individual)
+	if err != nil {
+		t.Fatalf("GenerateIndividual() unexpected error: %v", err)
+	}
+	if decoded, err := Decode(data.QR); err != nil || decoded.MerchantName != "Chan Smein" {
+		t.Errorf("GenerateIndividual() MerchantName = %q (%v), want %q", decoded.MerchantName, err, "Chan Smein")
+	}
+}
@@ -0,0 +1,391 @@
+// Package session tracks dynamic KHQR payments from generation until they
+// are paid, expire or are cancelled.
+//
+// A Manager generates the code, stores a Session keyed by the code's MD5
+// hash, and polls a StatusChecker with exponential backoff until the
+// payment is found or ExpirationTimestamp passes. State changes are
+// delivered to an optional callback and to per-session channels.
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	khqr "github.com/ishinvin/go-khqr"
+)
+
+// State is the lifecycle state of a payment session.
+type State int
+
+const (
+	Pending   State = iota // waiting for payment
+	Paid                   // payment confirmed by the checker
+	Expired                // ExpirationTimestamp passed without payment
+	Cancelled              // cancelled by the caller
+)
+
+// String returns the lowercase state name.
+func (s State) String() string {
+	switch s {
+	case Pending:
+		return "pending"
+	case Paid:
+		return "paid"
+	case Expired:
+		return "expired"
+	case Cancelled:
+		return "cancelled"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the state as its name.
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a state name.
+func (s *State) UnmarshalText(text []byte) error {
+	for _, st := range []State{Pending, Paid, Expired, Cancelled} {
+		if st.String() == string(text) {
+			*s = st
+			return nil
+		}
+	}
+	return fmt.Errorf("session: unknown state %q", text)
+}
+
+// Terminal reports whether no further transitions are possible.
+func (s State) Terminal() bool {
+	return s != Pending
+}
+
+// Errors returned by the Manager and Store implementations.
+var (
+	ErrNotFound = errors.New("session: not found")
+	ErrFinished = errors.New("session: already finished")
+	ErrStatic   = errors.New("session: static KHQR codes cannot be tracked")
+)
+
+// Payment is a confirmed transaction reported by a StatusChecker.
+type Payment struct {
+	Hash          string        `json:"hash,omitempty"`
+	FromAccountID string        `json:"from_account_id,omitempty"`
+	ToAccountID   string        `json:"to_account_id,omitempty"`
+	Amount        float64       `json:"amount"`
+	Currency      khqr.Currency `json:"currency"`
+	PaidAt        time.Time     `json:"paid_at"`
+}
+
+// Session is the tracked state of one dynamic KHQR code.
+type Session struct {
+	ID         string     `json:"id"` // MD5 of the QR string
+	Data       *khqr.Data `json:"data"`
+	BillNumber string     `json:"bill_number,omitempty"`
+	State      State      `json:"state"`
+	Payment    *Payment   `json:"payment,omitempty"`
+	LastError  string     `json:"last_error,omitempty"` // most recent checker error, if any
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// StatusChecker looks up whether the code with the given MD5 has been paid.
+// It returns a nil Payment while the code is unpaid.
+type StatusChecker interface {
+	CheckPayment(ctx context.Context, md5 string) (*Payment, error)
+}
+
+// CheckerFunc adapts a function to the StatusChecker interface.
+type CheckerFunc func(ctx context.Context, md5 string) (*Payment, error)
+
+// CheckPayment implements StatusChecker.
+func (f CheckerFunc) CheckPayment(ctx context.Context, md5 string) (*Payment, error) {
+	return f(ctx, md5)
+}
+
+// Event reports a state change or a failed status check.
+type Event struct {
+	Session Session
+	Err     error // non-nil for a failed check; Session.State is unchanged
+}
+
+// Backoff controls the polling interval. Each unpaid check multiplies the
+// interval by Multiplier up to Max.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+}
+
+// DefaultBackoff polls after 1s, growing by 1.5x up to 10s.
+var DefaultBackoff = Backoff{Initial: time.Second, Max: 10 * time.Second, Multiplier: 1.5}
+
+func (b Backoff) next(d time.Duration) time.Duration {
+	if d <= 0 {
+		return b.Initial
+	}
+	d = time.Duration(float64(d) * b.Multiplier)
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+	return d
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithStore sets the session store. Defaults to a MemoryStore.
+func WithStore(s Store) Option {
+	return func(m *Manager) { m.store = s }
+}
+
+// WithBackoff sets the polling backoff. Defaults to DefaultBackoff.
+func WithBackoff(b Backoff) Option {
+	return func(m *Manager) { m.backoff = b }
+}
+
+// WithCallback registers fn to receive every event, including failed
+// checks. It is called from the polling goroutine and should not block.
+func WithCallback(fn func(Event)) Option {
+	return func(m *Manager) { m.callback = fn }
+}
+
+// Manager creates payment sessions and polls them until they finish.
+type Manager struct {
+	checker  StatusChecker
+	store    Store
+	backoff  Backoff
+	callback func(Event)
+
+	ctx    context.Context // bounds the lifetime of polling goroutines
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu          sync.Mutex
+	pollers     map[string]context.CancelFunc
+	subscribers map[string][]chan Event
+}
+
+// NewManager returns a Manager that polls checker for payment status.
+func NewManager(checker StatusChecker, opts ...Option) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		checker:     checker,
+		store:       NewMemoryStore(),
+		backoff:     DefaultBackoff,
+		ctx:         ctx,
+		cancel:      cancel,
+		pollers:     map[string]context.CancelFunc{},
+		subscribers: map[string][]chan Event{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// CreateIndividual generates a dynamic individual code and starts tracking it.
+//
+//nolint:gocritic // info is passed by value to mirror khqr.GenerateIndividual
+func (m *Manager) CreateIndividual(ctx context.Context, info khqr.IndividualInfo) (*Session, error) {
+	if info.Amount <= 0 {
+		return nil, ErrStatic
+	}
+	data, err := khqr.GenerateIndividual(info)
+	if err != nil {
+		return nil, err
+	}
+	return m.start(ctx, data, info.BillNumber, info.ExpirationTimestamp)
+}
+
+// CreateMerchant generates a dynamic merchant code and starts tracking it.
+//
+//nolint:gocritic // info is passed by value to mirror khqr.GenerateMerchant
+func (m *Manager) CreateMerchant(ctx context.Context, info khqr.MerchantInfo) (*Session, error) {
+	if info.Amount <= 0 {
+		return nil, ErrStatic
+	}
+	data, err := khqr.GenerateMerchant(info)
+	if err != nil {
+		return nil, err
+	}
+	return m.start(ctx, data, info.BillNumber, info.ExpirationTimestamp)
+}
+
+func (m *Manager) start(ctx context.Context, data *khqr.Data, billNumber string, expiration int64) (*Session, error) {
+	now := time.Now()
+	s := &Session{
+		ID:         data.MD5(),
+		Data:       data,
+		BillNumber: billNumber,
+		State:      Pending,
+		CreatedAt:  now,
+		ExpiresAt:  time.UnixMilli(expiration),
+		UpdatedAt:  now,
+	}
+	if err := m.store.Save(ctx, s); err != nil {
+		return nil, err
+	}
+
+	pollCtx, cancel := context.WithCancel(m.ctx)
+	m.mu.Lock()
+	m.pollers[s.ID] = cancel
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer cancel()
+		m.poll(pollCtx, s.ID, s.ExpiresAt)
+	}()
+
+	cp := *s
+	return &cp, nil
+}
+
+// poll checks the payment status until the session finishes or ctx ends.
+func (m *Manager) poll(ctx context.Context, id string, expiresAt time.Time) {
+	var interval time.Duration
+	for {
+		payment, err := m.checker.CheckPayment(ctx, id)
+		if ctx.Err() != nil {
+			return
+		}
+		switch {
+		case err != nil:
+			m.recordError(ctx, id, err)
+		case payment != nil:
+			_ = m.finish(ctx, id, Paid, payment)
+			return
+		}
+
+		interval = m.backoff.next(interval)
+		wait := interval
+		if remaining := time.Until(expiresAt); remaining < wait {
+			wait = remaining
+		}
+		if wait <= 0 {
+			_ = m.finish(ctx, id, Expired, nil)
+			return
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// recordError stores a failed check on the session and notifies the callback.
+func (m *Manager) recordError(ctx context.Context, id string, checkErr error) {
+	m.mu.Lock()
+	s, err := m.store.Get(ctx, id)
+	if err == nil && s.State == Pending {
+		s.LastError = checkErr.Error()
+		s.UpdatedAt = time.Now()
+		err = m.store.Save(ctx, s)
+	}
+	m.mu.Unlock()
+	if err == nil && m.callback != nil {
+		m.callback(Event{Session: *s, Err: checkErr})
+	}
+}
+
+// finish moves a pending session to a terminal state and notifies listeners.
+func (m *Manager) finish(ctx context.Context, id string, state State, payment *Payment) error {
+	m.mu.Lock()
+	s, err := m.store.Get(ctx, id)
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	if s.State.Terminal() {
+		m.mu.Unlock()
+		return ErrFinished
+	}
+	s.State = state
+	s.Payment = payment
+	s.UpdatedAt = time.Now()
+	if err := m.store.Save(ctx, s); err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	subs := m.subscribers[id]
+	delete(m.subscribers, id)
+	if cancel, ok := m.pollers[id]; ok {
+		delete(m.pollers, id)
+		cancel()
+	}
+	m.mu.Unlock()
+
+	ev := Event{Session: *s}
+	for _, ch := range subs {
+		ch <- ev
+		close(ch)
+	}
+	if m.callback != nil {
+		m.callback(ev)
+	}
+	return nil
+}
+
+// Get returns a snapshot of the session with the given ID.
+func (m *Manager) Get(ctx context.Context, id string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.store.Get(ctx, id)
+}
+
+// Cancel stops polling and marks a pending session as cancelled.
+func (m *Manager) Cancel(ctx context.Context, id string) error {
+	return m.finish(ctx, id, Cancelled, nil)
+}
+
+// Subscribe returns a channel that receives the session's terminal event
+// and is then closed. If the session has already finished, the channel
+// delivers its current state immediately.
+func (m *Manager) Subscribe(ctx context.Context, id string) (<-chan Event, error) {
+	ch := make(chan Event, 1)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s.State.Terminal() {
+		ch <- Event{Session: *s}
+		close(ch)
+		return ch, nil
+	}
+	m.subscribers[id] = append(m.subscribers[id], ch)
+	return ch, nil
+}
+
+// Wait blocks until the session finishes or ctx is done.
+func (m *Manager) Wait(ctx context.Context, id string) (*Session, error) {
+	ch, err := m.Subscribe(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case ev := <-ch:
+		return &ev.Session, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close stops all polling goroutines and waits for them to exit. Pending
+// sessions remain pending in the store.
+func (m *Manager) Close() {
+	m.cancel()
+	m.wg.Wait()
+}
@@ -0,0 +1,312 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	khqr "github.com/ishinvin/go-khqr"
+)
+
+var testBackoff = Backoff{Initial: 5 * time.Millisecond, Max: 20 * time.Millisecond, Multiplier: 2}
+
+// fakeChecker reports a payment for an MD5 once it has been marked paid,
+// and can be told to fail checks.
+type fakeChecker struct {
+	mu     sync.Mutex
+	paid   map[string]*Payment
+	fail   error
+	checks atomic.Int32
+}
+
+func newFakeChecker() *fakeChecker {
+	return &fakeChecker{paid: map[string]*Payment{}}
+}
+
+func (f *fakeChecker) CheckPayment(_ context.Context, md5 string) (*Payment, error) {
+	f.checks.Add(1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail != nil {
+		return nil, f.fail
+	}
+	return f.paid[md5], nil
+}
+
+func (f *fakeChecker) markPaid(md5 string, p *Payment) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paid[md5] = p
+}
+
+func (f *fakeChecker) setFail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fail = err
+}
+
+func individualInfo(expiresIn time.Duration) khqr.IndividualInfo {
+	return khqr.IndividualInfo{
+		BakongAccountID:     "jonhsmith@nbcq",
+		MerchantName:        "Jonh Smith",
+		Currency:            khqr.USD,
+		Amount:              1.5,
+		BillNumber:          "INV-001",
+		ExpirationTimestamp: time.Now().Add(expiresIn).UnixMilli(),
+	}
+}
+
+func waitCtx(t *testing.T) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestSessionPaid(t *testing.T) {
+	t.Parallel()
+
+	checker := newFakeChecker()
+	var events []Event
+	var mu sync.Mutex
+	m := NewManager(checker, WithBackoff(testBackoff), WithCallback(func(ev Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, ev)
+	}))
+	t.Cleanup(m.Close)
+
+	ctx := waitCtx(t)
+	s, err := m.CreateIndividual(ctx, individualInfo(time.Minute))
+	if err != nil {
+		t.Fatalf("CreateIndividual() unexpected error: %v", err)
+	}
+	if s.State != Pending || s.ID != s.Data.MD5() || s.BillNumber != "INV-001" {
+		t.Fatalf("CreateIndividual() = %+v, want pending session keyed by MD5", s)
+	}
+
+	ch, err := m.Subscribe(ctx, s.ID)
+	if err != nil {
+		t.Fatalf("Subscribe() unexpected error: %v", err)
+	}
+	checker.markPaid(s.ID, &Payment{Hash: "abc", Amount: 1.5, Currency: khqr.USD, PaidAt: time.Now()})
+
+	select {
+	case ev := <-ch:
+		if ev.Session.State != Paid || ev.Session.Payment == nil || ev.Session.Payment.Hash != "abc" {
+			t.Errorf("event = %+v, want paid with payment", ev.Session)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for paid event")
+	}
+	if _, ok := <-ch; ok {
+		t.Error("subscription channel not closed after terminal event")
+	}
+
+	got, err := m.Get(ctx, s.ID)
+	if err != nil || got.State != Paid {
+		t.Errorf("Get() = %+v, %v, want paid", got, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 || events[0].Session.State != Paid {
+		t.Errorf("callback events = %+v, want one paid event", events)
+	}
+}
+
+func TestSessionExpired(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(newFakeChecker(), WithBackoff(testBackoff))
+	t.Cleanup(m.Close)
+
+	ctx := waitCtx(t)
+	s, err := m.CreateMerchant(ctx, khqr.MerchantInfo{
+		BakongAccountID:     "jonhsmith@devb",
+		MerchantName:        "Jonh Smith",
+		MerchantCity:        "Phnom Penh",
+		MerchantID:          "123456",
+		AcquiringBank:       "Dev Bank",
+		Amount:              5000,
+		ExpirationTimestamp: time.Now().Add(100 * time.Millisecond).UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("CreateMerchant() unexpected error: %v", err)
+	}
+
+	got, err := m.Wait(ctx, s.ID)
+	if err != nil {
+		t.Fatalf("Wait() unexpected error: %v", err)
+	}
+	if got.State != Expired {
+		t.Errorf("Wait() state = %v, want %v", got.State, Expired)
+	}
+	if time.Now().Before(s.ExpiresAt) {
+		t.Errorf("expired before ExpiresAt %v", s.ExpiresAt)
+	}
+}
+
+func TestSessionCancelled(t *testing.T) {
+	t.Parallel()
+
+	checker := newFakeChecker()
+	m := NewManager(checker, WithBackoff(testBackoff))
+	t.Cleanup(m.Close)
+
+	ctx := waitCtx(t)
+	s, err := m.CreateIndividual(ctx, individualInfo(time.Minute))
+	if err != nil {
+		t.Fatalf("CreateIndividual() unexpected error: %v", err)
+	}
+	if err := m.Cancel(ctx, s.ID); err != nil {
+		t.Fatalf("Cancel() unexpected error: %v", err)
+	}
+	if err := m.Cancel(ctx, s.ID); !errors.Is(err, ErrFinished) {
+		t.Errorf("second Cancel() error = %v, want %v", err, ErrFinished)
+	}
+
+	got, err := m.Wait(ctx, s.ID)
+	if err != nil || got.State != Cancelled {
+		t.Fatalf("Wait() = %+v, %v, want cancelled", got, err)
+	}
+
+	// A payment reported after cancellation must not change the state.
+	checker.markPaid(s.ID, &Payment{Amount: 1.5})
+	time.Sleep(30 * time.Millisecond)
+	if got, _ := m.Get(ctx, s.ID); got.State != Cancelled {
+		t.Errorf("state after late payment = %v, want %v", got.State, Cancelled)
+	}
+}
+
+func TestSessionCheckerErrors(t *testing.T) {
+	t.Parallel()
+
+	checker := newFakeChecker()
+	checker.setFail(errors.New("bakong unavailable"))
+
+	errCh := make(chan Event, 16)
+	m := NewManager(checker, WithBackoff(testBackoff), WithCallback(func(ev Event) {
+		if ev.Err != nil {
+			select {
+			case errCh <- ev:
+			default:
+			}
+		}
+	}))
+	t.Cleanup(m.Close)
+
+	ctx := waitCtx(t)
+	s, err := m.CreateIndividual(ctx, individualInfo(time.Minute))
+	if err != nil {
+		t.Fatalf("CreateIndividual() unexpected error: %v", err)
+	}
+
+	select {
+	case ev := <-errCh:
+		if ev.Session.State != Pending || ev.Session.LastError != "bakong unavailable" {
+			t.Errorf("error event = %+v, want pending with LastError", ev.Session)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for error event")
+	}
+
+	// Polling continues after errors and picks up the payment.
+	checker.setFail(nil)
+	checker.markPaid(s.ID, &Payment{Amount: 1.5})
+	got, err := m.Wait(ctx, s.ID)
+	if err != nil || got.State != Paid {
+		t.Errorf("Wait() = %+v, %v, want paid", got, err)
+	}
+}
+
+func TestSessionBackoff(t *testing.T) {
+	t.Parallel()
+
+	checker := newFakeChecker()
+	m := NewManager(checker, WithBackoff(Backoff{Initial: 10 * time.Millisecond, Max: 40 * time.Millisecond, Multiplier: 2}))
+	t.Cleanup(m.Close)
+
+	ctx := waitCtx(t)
+	if _, err := m.CreateIndividual(ctx, individualInfo(time.Minute)); err != nil {
+		t.Fatalf("CreateIndividual() unexpected error: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	m.Close()
+
+	// Intervals 10, 20, 40, 40, 40... allow at most ~7 checks in 200ms;
+	// without backoff there would be 20.
+	if n := checker.checks.Load(); n < 2 || n > 8 {
+		t.Errorf("checker called %d times in 200ms, want between 2 and 8", n)
+	}
+}
+
+func TestSubscribeFinished(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(newFakeChecker(), WithBackoff(testBackoff))
+	t.Cleanup(m.Close)
+
+	ctx := waitCtx(t)
+	s, err := m.CreateIndividual(ctx, individualInfo(time.Minute))
+	if err != nil {
+		t.Fatalf("CreateIndividual() unexpected error: %v", err)
+	}
+	if err := m.Cancel(ctx, s.ID); err != nil {
+		t.Fatalf("Cancel() unexpected error: %v", err)
+	}
+
+	ch, err := m.Subscribe(ctx, s.ID)
+	if err != nil {
+		t.Fatalf("Subscribe() unexpected error: %v", err)
+	}
+	if ev := <-ch; ev.Session.State != Cancelled {
+		t.Errorf("Subscribe() on finished session = %v, want %v", ev.Session.State, Cancelled)
+	}
+	if _, err := m.Subscribe(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Subscribe(missing) error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestCreateErrors(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager(newFakeChecker())
+	t.Cleanup(m.Close)
+	ctx := waitCtx(t)
+
+	static := individualInfo(time.Minute)
+	static.Amount = 0
+	if _, err := m.CreateIndividual(ctx, static); !errors.Is(err, ErrStatic) {
+		t.Errorf("CreateIndividual(static) error = %v, want %v", err, ErrStatic)
+	}
+
+	invalid := individualInfo(time.Minute)
+	invalid.BakongAccountID = "invalid"
+	if _, err := m.CreateIndividual(ctx, invalid); !errors.Is(err, khqr.ErrAccountIDInvalid) {
+		t.Errorf("CreateIndividual(invalid) error = %v, want %v", err, khqr.ErrAccountIDInvalid)
+	}
+}
+
+func TestStateJSON(t *testing.T) {
+	t.Parallel()
+
+	for _, st := range []State{Pending, Paid, Expired, Cancelled} {
+		b, err := json.Marshal(st)
+		if err != nil {
+			t.Fatalf("json.Marshal(%v) unexpected error: %v", st, err)
+		}
+		var got State
+		if err := json.Unmarshal(b, &got); err != nil || got != st {
+			t.Errorf("round trip %s = %v, %v, want %v", b, got, err, st)
+		}
+	}
+	var got State
+	if err := json.Unmarshal([]byte(`"refunded"`), &got); err == nil {
+		t.Error("json.Unmarshal(unknown state) expected error")
+	}
+}
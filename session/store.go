@@ -0,0 +1,44 @@
+package session
+
+import (
+	"context"
+	"sync"
+)
+
+// Store persists sessions keyed by ID.
+type Store interface {
+	// Save inserts or replaces a session.
+	Save(ctx context.Context, s *Session) error
+	// Get returns a copy of the session, or ErrNotFound.
+	Get(ctx context.Context, id string) (*Session, error)
+}
+
+// MemoryStore is a Store backed by a map. It is safe for concurrent use.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	sessions map[string]Session
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: map[string]Session{}}
+}
+
+// Save implements Store.
+func (m *MemoryStore) Save(_ context.Context, s *Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[s.ID] = *s
+	return nil
+}
+
+// Get implements Store.
+func (m *MemoryStore) Get(_ context.Context, id string) (*Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &s, nil
+}
@@ -0,0 +1,254 @@
+package khqr
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Text is shaped one cluster (see textClusters) at a time. Khmer clusters
+// follow a reduced form of the OpenType Khmer shaping model: the syllable
+// is put in visual order, the font's GSUB features replace coeng sequences
+// with subscript forms, and its GPOS features attach marks to their base.
+// Contextual substitutions and kerning are not applied.
+
+const (
+	khmerVowelE      = '\u17C1' // first vowel written before the base
+	khmerVowelAI     = '\u17C3' // last vowel written before the base
+	khmerSplitVowels = "\u17BE\u17BF\u17C0\u17C4\u17C5"
+)
+
+// OpenType features applied to Khmer, GSUB in the two stages of the
+// shaping model.
+var (
+	khmerBasicFeatures        = []string{"ccmp", "pref", "blwf", "abvf", "pstf", "cfar"}
+	khmerPresentationFeatures = []string{"pres", "abvs", "blws", "psts", "clig"}
+	khmerPositionFeatures     = []string{"abvm", "blwm", "mark", "mkmk"}
+)
+
+// shapedGlyph is a glyph placed by shape. Distances are in thousandths of
+// an em.
+type shapedGlyph struct {
+	id      uint16
+	text    string // characters the glyph stands for, for ToUnicode
+	advance float64
+	dx, dy  float64 // offset from the pen position, set by mark attachment
+}
+
+// shape maps s to positioned glyphs. It returns ErrFontGlyphMissing if the
+// font lacks a character, and ErrTextShapingUnsupported if it has no
+// subscript form for a Khmer consonant, which would otherwise print as a
+// visible coeng beside a full-size letter.
+func (f *trueTypeFont) shape(s string) ([]shapedGlyph, error) {
+	var out []shapedGlyph
+	for _, cluster := range textClusters(s) {
+		khmer := strings.ContainsFunc(cluster, isKhmerRune)
+		runes := []rune(cluster)
+		var texts []string
+		if khmer {
+			runes, texts = khmerVisualOrder([]rune(reorderKhmer(cluster)))
+		}
+		gs := make([]shapedGlyph, 0, len(runes))
+		for i, r := range runes {
+			id, ok := f.glyph(r)
+			if !ok {
+				if isFormatRune(r) {
+					continue
+				}
+				return nil, ErrFontGlyphMissing
+			}
+			text := string(r)
+			if texts != nil {
+				text = texts[i]
+			}
+			gs = append(gs, shapedGlyph{id: id, text: text, advance: f.advance(id)})
+		}
+		if khmer {
+			gs = f.substitute(gs, f.gsubBasic)
+			gs = f.substitute(gs, f.gsubPresentation)
+			if f.unshapedCoeng(gs) {
+				return nil, ErrTextShapingUnsupported
+			}
+			f.attachMarks(gs)
+		}
+		out = append(out, gs...)
+	}
+	return out, nil
+}
+
+func isKhmerRune(r rune) bool {
+	return r >= khmerFirstConsonant && r <= '\u17FF'
+}
+
+// khmerVisualOrder puts a syllable in the order it is drawn: split vowels
+// are decomposed into a pre-base E and their remaining part, then the
+// pre-base vowels and a subscript ro move in front of the base. texts holds
+// the characters each rune stands for; the inserted E stands for none, so
+// copied text keeps the split vowel whole.
+func khmerVisualOrder(in []rune) (runes []rune, texts []string) {
+	var pre, ro, rest []rune
+	var preText, roText, restText []string
+	for i := 0; i < len(in); i++ {
+		switch r := in[i]; {
+		case r == khmerCoeng && i+1 < len(in) && in[i+1] == khmerRo:
+			ro = append(ro, r, khmerRo)
+			roText = append(roText, string(r), string(khmerRo))
+			i++
+		case r >= khmerVowelE && r <= khmerVowelAI:
+			pre = append(pre, r)
+			preText = append(preText, string(r))
+		case strings.ContainsRune(khmerSplitVowels, r):
+			pre = append(pre, khmerVowelE)
+			preText = append(preText, "")
+			rest = append(rest, r)
+			restText = append(restText, string(r))
+		default:
+			rest = append(rest, r)
+			restText = append(restText, string(r))
+		}
+	}
+	return slices.Concat(pre, ro, rest), slices.Concat(preText, roText, restText)
+}
+
+// substitute applies GSUB lookups to the glyphs of one cluster.
+func (f *trueTypeFont) substitute(gs []shapedGlyph, lookups []int) []shapedGlyph {
+	for _, l := range lookups {
+		kind, subtables := otLookup(f.gsub, l, gsubExtension)
+		for i := 0; i < len(gs); i++ {
+			for _, sub := range subtables {
+				var ok bool
+				if gs, ok = f.substituteAt(kind, sub, gs, i); ok {
+					break
+				}
+			}
+		}
+	}
+	return gs
+}
+
+// substituteAt applies a single or ligature substitution subtable at i.
+//
+//nolint:mnd // table layout from the OpenType specification
+func (f *trueTypeFont) substituteAt(kind int, sub []byte, gs []shapedGlyph, i int) ([]shapedGlyph, bool) {
+	cov := otCoverage(otSub(sub, otU16(sub, 2)), gs[i].id)
+	switch format := otU16(sub, 0); {
+	case cov < 0:
+	case kind == gsubSingle && format == 1:
+		return f.replace(gs, i, 1, (int(gs[i].id)+otU16(sub, 4))&0xFFFF)
+	case kind == gsubSingle && format == 2 && cov < otU16(sub, 4):
+		return f.replace(gs, i, 1, otU16(sub, 6+cov*2))
+	case kind == gsubLigature && format == 1 && cov < otU16(sub, 4):
+		set := otSub(sub, otU16(sub, 6+cov*2))
+		for j := range otU16(set, 0) {
+			lig := otSub(set, otU16(set, 2+j*2))
+			n := otU16(lig, 2) // components, including the covered one
+			if n == 0 || i+n > len(gs) {
+				continue
+			}
+			match := true
+			for k := 1; k < n && match; k++ {
+				match = int(gs[i+k].id) == otU16(lig, 2+k*2)
+			}
+			if match {
+				return f.replace(gs, i, n, otU16(lig, 0))
+			}
+		}
+	}
+	return gs, false
+}
+
+// replace puts glyph id in place of the n glyphs at i, which it stands for.
+func (f *trueTypeFont) replace(gs []shapedGlyph, i, n, id int) ([]shapedGlyph, bool) {
+	if id <= 0 || id >= len(f.advances) {
+		return gs, false
+	}
+	var text strings.Builder
+	for _, g := range gs[i : i+n] {
+		text.WriteString(g.text)
+	}
+	g := uint16(id) //nolint:gosec // bounded by the glyph count
+	return slices.Replace(gs, i, i+n, shapedGlyph{id: g, text: text.String(), advance: f.advance(g)}), true
+}
+
+// unshapedCoeng reports whether a coeng is still followed by the nominal
+// glyph of a consonant, meaning the font has no subscript form for it.
+func (f *trueTypeFont) unshapedCoeng(gs []shapedGlyph) bool {
+	for i := 0; i+1 < len(gs); i++ {
+		if gs[i].text != string(khmerCoeng) {
+			continue
+		}
+		r, _ := utf8.DecodeRuneInString(gs[i+1].text)
+		if id, ok := f.glyph(r); ok && id == gs[i+1].id && isKhmerBase(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// attachMarks positions the marks of one cluster with the font's
+// mark-to-base and mark-to-mark anchors. Without them marks are drawn
+// where the font's own metrics put them.
+func (f *trueTypeFont) attachMarks(gs []shapedGlyph) {
+	pen := make([]float64, len(gs))
+	for i := 1; i < len(gs); i++ {
+		pen[i] = pen[i-1] + gs[i-1].advance
+	}
+	for _, l := range f.gposLookups {
+		kind, subtables := otLookup(f.gpos, l, gposExtension)
+		if kind != gposMarkBase && kind != gposMarkMark {
+			continue
+		}
+		for i := 1; i < len(gs); i++ {
+			for _, sub := range subtables {
+				if f.attachAt(kind, sub, gs, pen, i) {
+					break
+				}
+			}
+		}
+	}
+}
+
+// attachAt moves the mark at i so that its anchor meets the matching
+// anchor of the base before it, or of the mark before it for mark-to-mark.
+//
+//nolint:mnd // table layout from the OpenType specification
+func (f *trueTypeFont) attachAt(kind int, sub []byte, gs []shapedGlyph, pen []float64, i int) bool {
+	markCoverage := otSub(sub, otU16(sub, 2))
+	mark := otCoverage(markCoverage, gs[i].id)
+	if otU16(sub, 0) != 1 || mark < 0 {
+		return false
+	}
+	j := i - 1
+	for kind == gposMarkBase && j >= 0 && f.isMark(gs[j].id, markCoverage) {
+		j--
+	}
+	if j < 0 {
+		return false
+	}
+	base := otCoverage(otSub(sub, otU16(sub, 4)), gs[j].id)
+	classes := otU16(sub, 6)
+	marks, bases := otSub(sub, otU16(sub, 8)), otSub(sub, otU16(sub, 10))
+	if base < 0 || mark >= otU16(marks, 0) || base >= otU16(bases, 0) {
+		return false
+	}
+	class := otU16(marks, 2+mark*4)
+	mx, my, markOK := otAnchor(otSub(marks, otU16(marks, 4+mark*4)))
+	bx, by, baseOK := otAnchor(otSub(bases, otU16(bases, 2+(base*classes+class)*2)))
+	if class >= classes || !markOK || !baseOK {
+		return false
+	}
+	gs[i].dx = pen[j] + gs[j].dx + f.em(bx-mx) - pen[i]
+	gs[i].dy = gs[j].dy + f.em(by-my)
+	return true
+}
+
+// isMark reports whether g is a mark, by its GDEF class or, in fonts
+// without one, by the mark coverage of the lookup being applied.
+//
+//nolint:mnd // table layout from the OpenType specification
+func (f *trueTypeFont) isMark(g uint16, markCoverage []byte) bool {
+	if classes := otSub(f.gdef, otU16(f.gdef, 4)); classes != nil {
+		return otClass(classes, g) == gdefMarkClass
+	}
+	return otCoverage(markCoverage, g) >= 0
+}
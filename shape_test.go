@@ -0,0 +1,187 @@
+package khqr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testLookup is one lookup of a test layout table, enabled by feature.
+type testLookup struct {
+	feature string
+	kind    int
+	sub     []byte
+}
+
+// testLayoutTable builds a GSUB or GPOS table whose khmr script enables
+// each lookup through its own feature.
+func testLayoutTable(lookups ...testLookup) []byte {
+	n := len(lookups)
+	scriptList := 10
+	featureList := scriptList + 18 + 2*n
+	lookupList := featureList + 2 + 12*n
+
+	var b bytes.Buffer
+	u16(&b, 1, 0, scriptList, featureList, lookupList)
+	u16(&b, 1)
+	b.WriteString("khmr")
+	u16(&b, 8, 4, 0, 0, 0xFFFF, n)
+	for i := range n {
+		u16(&b, i)
+	}
+	u16(&b, n)
+	for i, l := range lookups {
+		b.WriteString(l.feature)
+		u16(&b, 2+6*n+6*i)
+	}
+	for i := range n {
+		u16(&b, 0, 1, i)
+	}
+	u16(&b, n)
+	off := 2 + 2*n
+	for _, l := range lookups {
+		u16(&b, off)
+		off += 8 + len(l.sub)
+	}
+	for _, l := range lookups {
+		u16(&b, l.kind, 0, 1, 8)
+		b.Write(l.sub)
+	}
+	return b.Bytes()
+}
+
+// shapingTestFont builds testFont with Khmer layout tables: a blwf ligature
+// of coeng and MO into a subscript form mapped at U+E000, GDEF marking that
+// form and vowel sign II as marks, and a mark feature attaching II 250
+// units right of and 700 units above its consonant.
+func shapingTestFont(runes ...rune) []byte {
+	runes = slices.Compact(slices.Sorted(slices.Values(append(runes, '\uE000'))))
+	id := func(r rune) int { return slices.Index(runes, r) + 1 }
+	var bases []int
+	for i, r := range runes {
+		if isKhmerBase(r) {
+			bases = append(bases, i+1)
+		}
+	}
+
+	var gsub, gpos, gdef bytes.Buffer
+	u16(&gsub, 1, 8, 1, 14, 1, 1, id(khmerCoeng), 1, 4, id('\uE000'), 2, id('ម'))
+
+	baseCoverage := 18
+	markArray := baseCoverage + 4 + 2*len(bases)
+	u16(&gpos, 1, 12, baseCoverage, 1, markArray, markArray+12)
+	u16(&gpos, 1, 1, id('\u17B8'))
+	u16(&gpos, 1, len(bases))
+	u16(&gpos, bases...)
+	u16(&gpos, 1, 0, 6, 1, 0, 0)
+	u16(&gpos, len(bases))
+	for range bases {
+		u16(&gpos, 2+2*len(bases))
+	}
+	u16(&gpos, 1, 250, 700)
+
+	u16(&gdef, 1, 0, 12, 0, 0, 0, 1, 1, len(runes))
+	for _, r := range runes {
+		class := 1
+		if r == '\uE000' || r == '\u17B8' {
+			class = gdefMarkClass
+		}
+		u16(&gdef, class)
+	}
+
+	return testFontTables(map[string][]byte{
+		"GSUB": testLayoutTable(testLookup{"blwf", gsubLigature, gsub.Bytes()}),
+		"GPOS": testLayoutTable(testLookup{"mark", gposMarkBase, gpos.Bytes()}),
+		"GDEF": gdef.Bytes(),
+	}, runes...)
+}
+
+func TestShape(t *testing.T) {
+	t.Parallel()
+
+	f, err := parseTrueType(shapingTestFont([]rune("សមន A្ីេើ")...))
+	if err != nil {
+		t.Fatalf("parseTrueType() unexpected error: %v", err)
+	}
+	g := func(r rune, text string, dx, dy float64) shapedGlyph {
+		id, _ := f.glyph(r)
+		return shapedGlyph{id: id, text: text, advance: 500, dx: dx, dy: dy}
+	}
+
+	tests := []struct {
+		name string
+		in   string
+		want []shapedGlyph
+	}{
+		{"latin", "A \u200BA", []shapedGlyph{g('A', "A", 0, 0), g(' ', " ", 0, 0), g('A', "A", 0, 0)}},
+		{"subscript", "ស្មន", []shapedGlyph{g('ស', "ស", 0, 0), g('\uE000', "្ម", 0, 0), g('ន', "ន", 0, 0)}},
+		{
+			// II is attached to SA over the subscript, which is a mark.
+			"mark", "ស្មី",
+			[]shapedGlyph{g('ស', "ស", 0, 0), g('\uE000', "្ម", 0, 0), g('\u17B8', "\u17B8", -750, 700)},
+		},
+		{"pre_base_vowel", "សេ", []shapedGlyph{g('\u17C1', "\u17C1", 0, 0), g('ស', "ស", 0, 0)}},
+		{
+			// The E drawn for OE stands for no text of its own.
+			"split_vowel", "សើ",
+			[]shapedGlyph{g('\u17C1', "", 0, 0), g('ស', "ស", 0, 0), g('\u17BE', "\u17BE", 0, 0)},
+		},
+	}
+	for _, tt := range tests {
+		got, err := f.shape(tt.in)
+		if err != nil {
+			t.Errorf("%s: shape() unexpected error: %v", tt.name, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: shape(%+q)\n got %+v\nwant %+v", tt.name, tt.in, got, tt.want)
+		}
+	}
+
+	for in, want := range map[string]error{"ស្ន": ErrTextShapingUnsupported, "B": ErrFontGlyphMissing} {
+		if _, err := f.shape(in); !errors.Is(err, want) {
+			t.Errorf("shape(%+q) err = %v, want %v", in, err, want)
+		}
+	}
+	plain, _ := parseTrueType(testFont([]rune("សម្")...))
+	if _, err := plain.shape("ស្ម"); !errors.Is(err, ErrTextShapingUnsupported) {
+		t.Errorf("font without GSUB: err = %v, want ErrTextShapingUnsupported", err)
+	}
+}
+
+func TestEmbeddedFontShow(t *testing.T) {
+	t.Parallel()
+
+	ttf, err := parseTrueType(shapingTestFont([]rune("សមន្ី")...))
+	if err != nil {
+		t.Fatalf("parseTrueType() unexpected error: %v", err)
+	}
+	f := newEmbeddedFont(ttf)
+	id := func(r rune) uint16 {
+		g, _ := ttf.glyph(r)
+		return g
+	}
+
+	if got, want := f.show("សន", 10), fmt.Sprintf("<%04X%04X> Tj", id('ស'), id('ន')); got != want {
+		t.Errorf("show(unmarked) = %q, want %q", got, want)
+	}
+	// II is raised 7 points and moved back over SA, then the pen returns
+	// to the end of the subscript for NO.
+	want := fmt.Sprintf("<%04X%04X> Tj 7 Ts [750 <%04X>] TJ 0 Ts [-750 <%04X>] TJ",
+		id('ស'), id('\uE000'), id('\u17B8'), id('ន'))
+	if got := f.show("ស្មីន", 10); got != want {
+		t.Errorf("show(marked) = %q, want %q", got, want)
+	}
+	if got := f.used[id('\uE000')]; got != "្ម" {
+		t.Errorf("subscript text = %+q, want the coeng sequence", got)
+	}
+	if w := f.width("ស្មីន", 10); w != 20 {
+		t.Errorf("width() = %v, want 20", w)
+	}
+	if !strings.Contains(string(f.toUnicode([]uint16{id('\uE000')})), "<17D21798>") {
+		t.Error("ToUnicode does not map the subscript form to its coeng sequence")
+	}
+}
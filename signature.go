@@ -0,0 +1,170 @@
+package khqr
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// The signature travels in an EMV unreserved template (tags 80-98; KHQR
+// uses 99 for timestamps), which wallets skip. Subtag 00 is the globally
+// unique identifier EMV requires so the template is not mistaken for
+// another issuer's.
+const (
+	tagSignature          = "80"
+	subtagSignatureGUID   = "00"
+	subtagSignatureKeyID  = "01"
+	subtagSignatureMAC    = "02"
+	signatureGUID         = "go-khqr.hmac"
+	signatureMACBytes     = 16 // HMAC-SHA256 truncated to 128 bits
+	maxSigningKeyIDLength = 8
+	minSigningSecretBytes = 16
+)
+
+// SigningKey is a secret shared between the backend that issues codes and
+// the devices that verify them. ID is written into every signed code so
+// verifiers can pick the right secret while keys are rotated.
+type SigningKey struct {
+	ID     string // 1-8 letters, digits, '-' or '_'
+	Secret []byte // at least 16 bytes
+}
+
+func (k *SigningKey) validate() error {
+	if len(k.Secret) < minSigningSecretBytes || k.ID == "" || len(k.ID) > maxSigningKeyIDLength {
+		return ErrSigningKeyInvalid
+	}
+	for _, r := range k.ID {
+		if !isKeyIDRune(r) {
+			return ErrSigningKeyInvalid
+		}
+	}
+	return nil
+}
+
+func isKeyIDRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_'
+}
+
+// Sign adds a truncated HMAC-SHA256 of qr to it, keyed by key, and
+// recomputes the CRC. An existing signature is replaced; a tag 80 holding
+// another issuer's template returns ErrInvalidQR rather than being
+// duplicated or dropped. The signed code still scans in any Bakong wallet;
+// only VerifySignature reads the signature.
+func Sign(qr string, key SigningKey) (string, error) {
+	if err := key.validate(); err != nil {
+		return "", err
+	}
+	body, err := unsignedBody(qr)
+	if err != nil {
+		return "", err
+	}
+	if body, err = withoutSignature(body); err != nil {
+		return "", err
+	}
+	tmpl := encodeTLV(subtagSignatureGUID, signatureGUID) +
+		encodeTLV(subtagSignatureKeyID, key.ID) +
+		encodeTLV(subtagSignatureMAC, signatureMAC(key, body))
+	body += encodeTLV(tagSignature, tmpl) + tagCRC + "04"
+	return body + crc16Hex(body), nil
+}
+
+// VerifySignature checks the CRC of qr and then its signature against
+// keys, chosen by the key ID in the code. Pass every key that may still
+// have codes in circulation. It does not validate the other fields; use
+// Verify for that.
+func VerifySignature(qr string, keys ...SigningKey) error {
+	body, err := unsignedBody(qr)
+	if err != nil {
+		return err
+	}
+	start, keyID, mac, ok := findSignature(body)
+	if !ok {
+		return ErrSignatureMissing
+	}
+	for i := range keys {
+		if keys[i].ID != keyID {
+			continue
+		}
+		want := signatureMAC(keys[i], body[:start])
+		if !hmac.Equal([]byte(strings.ToLower(mac)), []byte(want)) {
+			return ErrSignatureInvalid
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrSignatureKeyUnknown, keyID)
+}
+
+// WithSigningKey signs every code the Generator issues with key. The
+// signed code is what gets recorded and returned. The secret is copied.
+// An invalid key makes every Generate call fail with ErrSigningKeyInvalid
+// before anything is recorded.
+func WithSigningKey(key SigningKey) GeneratorOption {
+	key.Secret = bytes.Clone(key.Secret)
+	return func(g *Generator) { g.signingKey = &key }
+}
+
+// unsignedBody checks the CRC of qr and returns it without the CRC tag.
+func unsignedBody(qr string) (string, error) {
+	qr = strings.TrimSpace(qr)
+	if err := verifyCRC(qr); err != nil {
+		return "", err
+	}
+	return qr[:len(qr)-8], nil
+}
+
+// withoutSignature returns body with any go-khqr signature template
+// removed, wherever it appears.
+func withoutSignature(body string) (string, error) {
+	entries, err := parseTLV(body)
+	if err != nil {
+		return "", ErrInvalidQR
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		if e.Tag != tagSignature {
+			b.WriteString(encodeTLV(e.Tag, e.Value))
+			continue
+		}
+		var guid string
+		if err := decodeSubtags(e.Value, map[string]*string{subtagSignatureGUID: &guid}); err != nil || guid != signatureGUID {
+			return "", fmt.Errorf("%w: tag %s holds another template", ErrInvalidQR, tagSignature)
+		}
+	}
+	return b.String(), nil
+}
+
+// findSignature locates the signature template, which must be the last
+// entry before the CRC so that it covers everything else. It returns the
+// byte offset of the template within body.
+func findSignature(body string) (start int, keyID, mac string, ok bool) {
+	entries, err := parseTLV(body)
+	if err != nil || len(entries) == 0 {
+		return 0, "", "", false
+	}
+	last := entries[len(entries)-1]
+	if last.Tag != tagSignature {
+		return 0, "", "", false
+	}
+	var guid string
+	if err := decodeSubtags(last.Value, map[string]*string{
+		subtagSignatureGUID:  &guid,
+		subtagSignatureKeyID: &keyID,
+		subtagSignatureMAC:   &mac,
+	}); err != nil || guid != signatureGUID {
+		return 0, "", "", false
+	}
+	return len(body) - len(encodeTLV(last.Tag, last.Value)), keyID, mac, true
+}
+
+// signatureMAC is the hex HMAC over the signed part of the payload and the
+// key ID, so a signature cannot be moved to another key.
+func signatureMAC(key SigningKey, body string) string {
+	mac := hmac.New(sha256.New, key.Secret)
+	mac.Write([]byte(body))
+	mac.Write([]byte{0})
+	mac.Write([]byte(key.ID))
+	return hex.EncodeToString(mac.Sum(nil)[:signatureMACBytes])
+}
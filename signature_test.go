@@ -0,0 +1,194 @@
+package khqr
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	signingKeyV1 = SigningKey{ID: "v1", Secret: []byte("0123456789abcdef")}
+	signingKeyV2 = SigningKey{ID: "v2", Secret: []byte("fedcba9876543210")}
+)
+
+// signedTestCode returns a dynamic code and the same code signed with
+// signingKeyV1.
+func signedTestCode(t *testing.T) (plain, signed string) {
+	t.Helper()
+	data, err := GenerateIndividual(IndividualInfo{
+		BakongAccountID:     "john_smith@devb",
+		MerchantName:        "John Smith",
+		Currency:            USD,
+		Amount:              5,
+		ExpirationTimestamp: time.Now().Add(time.Hour).UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("GenerateIndividual() unexpected error: %v", err)
+	}
+	signed, err = Sign(data.QR, signingKeyV1)
+	if err != nil {
+		t.Fatalf("Sign() unexpected error: %v", err)
+	}
+	return data.QR, signed
+}
+
+// recrc replaces the CRC of qr with a correct one.
+func recrc(qr string) string {
+	return withCRC(qr[:len(qr)-8])
+}
+
+func TestSignScannable(t *testing.T) {
+	t.Parallel()
+
+	plain, signed := signedTestCode(t)
+	if err := Verify(signed); err != nil {
+		t.Fatalf("Verify(signed) unexpected error: %v", err)
+	}
+	a, _ := Decode(plain)
+	b, _ := Decode(signed)
+	a.CRC, b.CRC = "", ""
+	if *a != *b {
+		t.Errorf("Decode(signed) = %+v, want %+v", *b, *a)
+	}
+	if !strings.HasPrefix(signed, plain[:len(plain)-8]+"80") {
+		t.Errorf("signature template not appended before CRC: %s", signed)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	t.Parallel()
+
+	plain, signed := signedTestCode(t)
+	resigned, err := Sign(signed, signingKeyV2)
+	if err != nil {
+		t.Fatalf("Sign(resign) unexpected error: %v", err)
+	}
+	body := signed[:len(signed)-8]
+	tmplStart, keyID, _, ok := findSignature(body)
+	if !ok || keyID != signingKeyV1.ID {
+		t.Fatalf("findSignature() = %q, %v; want key %q", keyID, ok, signingKeyV1.ID)
+	}
+	badCRC := "0000"
+	if strings.HasSuffix(signed, badCRC) {
+		badCRC = "FFFF"
+	}
+
+	tests := []struct {
+		name string
+		qr   string
+		keys []SigningKey
+		want error
+	}{
+		{"valid", signed, []SigningKey{signingKeyV1}, nil},
+		{"rotation", signed, []SigningKey{signingKeyV2, signingKeyV1}, nil},
+		{"resigned", resigned, []SigningKey{signingKeyV2, signingKeyV1}, nil},
+		{"resigned_old_key_only", resigned, []SigningKey{signingKeyV1}, ErrSignatureKeyUnknown},
+		{"unknown_key", signed, []SigningKey{signingKeyV2}, ErrSignatureKeyUnknown},
+		{"wrong_secret", signed, []SigningKey{{ID: "v1", Secret: signingKeyV2.Secret}}, ErrSignatureInvalid},
+		{"amount_tampered", recrc(strings.Replace(signed, "54015", "54019", 1)), []SigningKey{signingKeyV1}, ErrSignatureInvalid},
+		{"key_id_swapped", recrc(strings.Replace(signed, "0102v1", "0102v2", 1)), []SigningKey{signingKeyV1, {ID: "v2", Secret: signingKeyV1.Secret}}, ErrSignatureInvalid},
+		{"appended_after", withCRC(body + "8106000201"), []SigningKey{signingKeyV1}, ErrSignatureMissing},
+		{"moved_earlier", withCRC(body[:4] + body[tmplStart:] + body[4:tmplStart]), []SigningKey{signingKeyV1}, ErrSignatureMissing},
+		{"unsigned", plain, []SigningKey{signingKeyV1}, ErrSignatureMissing},
+		{"crc_broken", body + "6304" + badCRC, []SigningKey{signingKeyV1}, ErrCRCInvalid},
+	}
+	for _, tt := range tests {
+		err := VerifySignature(tt.qr, tt.keys...)
+		if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: VerifySignature() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if strings.Count(resigned, signatureGUID) != 1 {
+		t.Errorf("Sign() kept the old signature: %s", resigned)
+	}
+}
+
+func TestSignInvalidKey(t *testing.T) {
+	t.Parallel()
+
+	_, signed := signedTestCode(t)
+	for _, key := range []SigningKey{
+		{ID: "", Secret: signingKeyV1.Secret},
+		{ID: "too-long-1", Secret: signingKeyV1.Secret},
+		{ID: "v 1", Secret: signingKeyV1.Secret},
+		{ID: "v1", Secret: []byte("short")},
+	} {
+		if _, err := Sign(signed, key); !errors.Is(err, ErrSigningKeyInvalid) {
+			t.Errorf("Sign(key %q) err = %v, want ErrSigningKeyInvalid", key.ID, err)
+		}
+	}
+	if _, err := Sign(signed[:len(signed)-4]+"GGGG", signingKeyV1); !errors.Is(err, ErrCRCInvalid) {
+		t.Errorf("Sign(bad CRC) err = %v, want ErrCRCInvalid", err)
+	}
+}
+
+func TestGeneratorWithSigningKey(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	g := NewGenerator(WithStore(store), WithSigningKey(signingKeyV1))
+	data, err := g.GenerateMerchant(context.Background(), profileMerchant)
+	if err != nil {
+		t.Fatalf("GenerateMerchant() unexpected error: %v", err)
+	}
+	if err := VerifySignature(data.QR, signingKeyV1); err != nil {
+		t.Errorf("VerifySignature() unexpected error: %v", err)
+	}
+	r, err := store.Get(context.Background(), data.MD5())
+	if err != nil || r.QR != data.QR {
+		t.Errorf("recorded %+v, %v; want the signed code", r, err)
+	}
+
+	// The option keeps its own copy of the secret.
+	key := SigningKey{ID: "v1", Secret: []byte(string(signingKeyV1.Secret))}
+	g = NewGenerator(WithSigningKey(key))
+	clear(key.Secret)
+	data, err = g.GenerateMerchant(context.Background(), profileMerchant)
+	if err != nil {
+		t.Fatalf("GenerateMerchant() unexpected error: %v", err)
+	}
+	if err := VerifySignature(data.QR, signingKeyV1); err != nil {
+		t.Errorf("VerifySignature() after caller changed the secret = %v", err)
+	}
+
+	// A bad key fails issuance without recording anything.
+	store = NewMemoryStore()
+	g = NewGenerator(WithStore(store), WithSigningKey(SigningKey{ID: "v1"}))
+	if _, err := g.GenerateMerchant(context.Background(), profileMerchant); !errors.Is(err, ErrSigningKeyInvalid) {
+		t.Errorf("GenerateMerchant() with bad key error = %v, want ErrSigningKeyInvalid", err)
+	}
+	if got, _ := store.Find(context.Background(), Query{}); len(got) != 0 {
+		t.Errorf("store has %d records after a bad key, want 0", len(got))
+	}
+}
+
+func TestSignExistingTag80(t *testing.T) {
+	t.Parallel()
+
+	plain, signed := signedTestCode(t)
+	body := plain[:len(plain)-8]
+
+	// Another issuer's template in tag 80 is neither duplicated nor dropped.
+	foreign := encodeTLV(tagSignature, encodeTLV(subtagSignatureGUID, "com.example")+encodeTLV("01", "x"))
+	for _, qr := range []string{withCRC(body + foreign), withCRC(body[:12] + foreign + body[12:])} {
+		if _, err := Sign(qr, signingKeyV1); !errors.Is(err, ErrInvalidQR) {
+			t.Errorf("Sign(foreign tag 80) err = %v, want ErrInvalidQR", err)
+		}
+	}
+
+	// Our own signature is replaced even when it is not the last field.
+	sig := signed[len(body) : len(signed)-8]
+	moved := withCRC(body[:12] + sig + body[12:])
+	resigned, err := Sign(moved, signingKeyV2)
+	if err != nil {
+		t.Fatalf("Sign(moved signature) unexpected error: %v", err)
+	}
+	if strings.Count(resigned, signatureGUID) != 1 {
+		t.Errorf("Sign() kept the old signature: %s", resigned)
+	}
+	if err := VerifySignature(resigned, signingKeyV2); err != nil {
+		t.Errorf("VerifySignature(resigned) = %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package khqr
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Value implements driver.Valuer, storing the currency as its ISO 4217
+// alphabetic code.
+func (c Currency) Value() (driver.Value, error) {
+	b, err := c.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner. It accepts alphabetic or numeric codes
+// stored as text or integers. A NULL leaves the currency unset.
+func (c *Currency) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*c = 0
+		return nil
+	case int64:
+		return c.UnmarshalText(fmt.Appendf(nil, "%d", v))
+	case string:
+		return c.UnmarshalText([]byte(v))
+	case []byte:
+		return c.UnmarshalText(v)
+	default:
+		return ErrInvalidCurrency
+	}
+}
+
+// Value implements driver.Valuer.
+func (mt MerchantType) Value() (driver.Value, error) {
+	if mt != Individual && mt != Merchant {
+		return nil, ErrMerchantTypeInvalid
+	}
+	return string(mt), nil
+}
+
+// Scan implements sql.Scanner, rejecting unknown merchant types.
+// A NULL leaves the type unset.
+func (mt *MerchantType) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*mt = ""
+		return nil
+	case string:
+		return mt.UnmarshalText([]byte(v))
+	case []byte:
+		return mt.UnmarshalText(v)
+	default:
+		return ErrMerchantTypeInvalid
+	}
+}
+
+// Value implements driver.Valuer, storing the QR payload string. A nil
+// *Data is stored as NULL.
+func (d *Data) Value() (driver.Value, error) {
+	if d == nil {
+		return nil, nil
+	}
+	return d.QR, nil
+}
+
+// Scan implements sql.Scanner. The stored QR string is verified with
+// Verify before it is accepted, so a corrupted code fails to scan.
+// Expired dynamic codes are still accepted since stored rows routinely
+// outlive the codes they hold. A NULL leaves the QR empty.
+func (d *Data) Scan(src any) error {
+	var qr string
+	switch v := src.(type) {
+	case nil:
+		d.QR = ""
+		return nil
+	case string:
+		qr = v
+	case []byte:
+		qr = string(v)
+	default:
+		return ErrInvalidQR
+	}
+	if err := verify(qr); err != nil && !errors.Is(err, ErrKHQRExpired) {
+		return err
+	}
+	d.QR = qr
+	return nil
+}
+
+// Value implements driver.Valuer, storing the decoded fields as a JSON
+// document suitable for json/jsonb columns. A nil *DecodedData is stored
+// as NULL.
+func (data *DecodedData) Value() (driver.Value, error) {
+	if data == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Scan implements sql.Scanner, reading a JSON document written by Value.
+// A NULL resets data to its zero value.
+func (data *DecodedData) Scan(src any) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		*data = DecodedData{}
+		return nil
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		return fmt.Errorf("khqr: cannot scan %T into DecodedData", src)
+	}
+	var decoded DecodedData
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	*data = decoded
+	return nil
+}
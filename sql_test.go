@@ -0,0 +1,356 @@
+package khqr
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDriver is a minimal in-memory database/sql driver. Each DSN holds a
+// single column of values: statements starting with INSERT append their
+// first argument, and any other query returns every stored value.
+type fakeDriver struct {
+	mu     sync.Mutex
+	tables map[string][]driver.Value
+}
+
+var testDriver = &fakeDriver{tables: map[string][]driver.Value{}}
+
+func init() {
+	sql.Register("khqrfake", testDriver)
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) {
+	return &fakeConn{driver: d, dsn: dsn}, nil
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+	dsn    string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fake: transactions not supported")
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error { return nil }
+
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if !strings.HasPrefix(s.query, "INSERT") || len(args) != 1 {
+		return nil, errors.New("fake: unsupported statement")
+	}
+	d := s.conn.driver
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.tables[s.conn.dsn] = append(d.tables[s.conn.dsn], args[0])
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(_ []driver.Value) (driver.Rows, error) {
+	d := s.conn.driver
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	values := append([]driver.Value(nil), d.tables[s.conn.dsn]...)
+	return &fakeRows{values: values}, nil
+}
+
+type fakeRows struct {
+	values []driver.Value
+	pos    int
+}
+
+func (r *fakeRows) Columns() []string { return []string{"value"} }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.values) {
+		return io.EOF
+	}
+	dest[0] = r.values[r.pos]
+	r.pos++
+	return nil
+}
+
+// openFakeDB opens a fresh fake database named after the test and seeds it
+// with the given raw column values.
+func openFakeDB(t *testing.T, seed ...driver.Value) *sql.DB {
+	t.Helper()
+	testDriver.mu.Lock()
+	testDriver.tables[t.Name()] = seed
+	testDriver.mu.Unlock()
+
+	db, err := sql.Open("khqrfake", t.Name())
+	if err != nil {
+		t.Fatalf("sql.Open() unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestCurrencySQLRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	db := openFakeDB(t)
+	for _, c := range []Currency{KHR, USD} {
+		if _, err := db.Exec("INSERT", c); err != nil {
+			t.Fatalf("Exec(%v) unexpected error: %v", c, err)
+		}
+	}
+
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatalf("Query() unexpected error: %v", err)
+	}
+	defer rows.Close()
+
+	var got []Currency
+	for rows.Next() {
+		var c Currency
+		if err := rows.Scan(&c); err != nil {
+			t.Fatalf("Scan() unexpected error: %v", err)
+		}
+		got = append(got, c)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows.Err() = %v", err)
+	}
+	if len(got) != 2 || got[0] != KHR || got[1] != USD {
+		t.Errorf("scanned currencies = %v, want [KHR USD]", got)
+	}
+}
+
+func TestCurrencyValueUnsupported(t *testing.T) {
+	t.Parallel()
+
+	db := openFakeDB(t)
+	if _, err := db.Exec("INSERT", Currency(999)); !errors.Is(err, ErrInvalidCurrency) {
+		t.Errorf("Exec() error = %v, want %v", err, ErrInvalidCurrency)
+	}
+}
+
+func TestCurrencyScan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		src     any
+		want    Currency
+		wantErr error
+	}{
+		{"alpha_string", "USD", USD, nil},
+		{"alpha_bytes", []byte("KHR"), KHR, nil},
+		{"numeric_int", int64(840), USD, nil},
+		{"numeric_string", "116", KHR, nil},
+		{"null", nil, 0, nil},
+		{"unknown", "EUR", 0, ErrInvalidCurrency},
+		{"unsupported_type", 840.0, 0, ErrInvalidCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got Currency
+			err := got.Scan(tt.src)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Scan(%v) error = %v, want %v", tt.src, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Scan(%v) = %v, want %v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerchantTypeSQL(t *testing.T) {
+	t.Parallel()
+
+	db := openFakeDB(t, "merchant", []byte("individual"), "bank")
+	if _, err := db.Exec("INSERT", MerchantType("bank")); !errors.Is(err, ErrMerchantTypeInvalid) {
+		t.Errorf("Exec() error = %v, want %v", err, ErrMerchantTypeInvalid)
+	}
+
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatalf("Query() unexpected error: %v", err)
+	}
+	defer rows.Close()
+
+	var got []MerchantType
+	var scanErr error
+	for rows.Next() {
+		var mt MerchantType
+		if err := rows.Scan(&mt); err != nil {
+			scanErr = err
+			continue
+		}
+		got = append(got, mt)
+	}
+	if len(got) != 2 || got[0] != Merchant || got[1] != Individual {
+		t.Errorf("scanned merchant types = %v, want [merchant individual]", got)
+	}
+	if !errors.Is(scanErr, ErrMerchantTypeInvalid) {
+		t.Errorf("Scan() error = %v, want %v", scanErr, ErrMerchantTypeInvalid)
+	}
+}
+
+func TestDataSQLRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	data, err := GenerateIndividual(IndividualInfo{
+		BakongAccountID:     "jonhsmith@nbcq",
+		MerchantName:        "Jonh Smith",
+		Currency:            USD,
+		Amount:              1.5,
+		ExpirationTimestamp: time.Now().Add(time.Minute).UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("GenerateIndividual() unexpected error: %v", err)
+	}
+
+	db := openFakeDB(t)
+	if _, err := db.Exec("INSERT", data); err != nil {
+		t.Fatalf("Exec() unexpected error: %v", err)
+	}
+
+	var got Data
+	if err := db.QueryRow("SELECT").Scan(&got); err != nil {
+		t.Fatalf("Scan() unexpected error: %v", err)
+	}
+	if got.QR != data.QR {
+		t.Errorf("scanned QR = %q, want %q", got.QR, data.QR)
+	}
+	if got.MD5() != data.MD5() {
+		t.Errorf("scanned MD5 = %s, want %s", got.MD5(), data.MD5())
+	}
+}
+
+func TestDataScan(t *testing.T) {
+	t.Parallel()
+
+	expired := "00020101021229180014jonhsmith@nbcq52045999530384054031.05802KH5910Jonh Smith6010Phnom Penh" +
+		"993400131726749117626011317267497176266304"
+	expired += crc16Hex(expired)
+	static := "00020101021130470009khqr@aclb0111855124649170215ACLEDA Bank Plc5204599953038405802KH5907BUN MAO" +
+		"6010Phnom Penh6102126213020901050033164310002KM0107BUN MAO0210Phnom Penh6304E313"
+
+	tests := []struct {
+		name    string
+		src     any
+		wantErr error
+	}{
+		{"static_string", static, nil},
+		{"static_bytes", []byte(static), nil},
+		{"expired_dynamic", expired, nil},
+		{"bad_crc", static[:len(static)-4] + "0000", ErrCRCInvalid},
+		{"garbage", "not a qr", ErrCRCInvalid},
+		{"too_short", "0002", ErrInvalidQR},
+		{"null", nil, nil},
+		{"unsupported_type", int64(1), ErrInvalidQR},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got Data
+			err := got.Scan(tt.src)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Scan() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil && got.QR != "" {
+				t.Errorf("Scan() left QR = %q after error", got.QR)
+			}
+		})
+	}
+}
+
+func TestDecodedDataSQLRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	qr := "00020101021230410015john_smith@devb01061234560208Dev Bank52045999530384054035.05802KH5916john smith actor" +
+		"6010Phnom Penh62130709Counter 299170013161343857579463048EF2"
+	decoded, err := Decode(qr)
+	if err != nil {
+		t.Fatalf("Decode() unexpected error: %v", err)
+	}
+
+	db := openFakeDB(t)
+	if _, err := db.Exec("INSERT", decoded); err != nil {
+		t.Fatalf("Exec() unexpected error: %v", err)
+	}
+
+	var got DecodedData
+	if err := db.QueryRow("SELECT").Scan(&got); err != nil {
+		t.Fatalf("Scan() unexpected error: %v", err)
+	}
+	if got != *decoded {
+		t.Errorf("scanned = %+v, want %+v", got, *decoded)
+	}
+}
+
+func TestDecodedDataScanInvalid(t *testing.T) {
+	t.Parallel()
+
+	var got DecodedData
+	if err := got.Scan(int64(1)); err == nil {
+		t.Error("Scan(int64) expected error, got nil")
+	}
+	if err := got.Scan(`{"merchant_type":"bank"}`); !errors.Is(err, ErrMerchantTypeInvalid) {
+		t.Errorf("Scan() error = %v, want %v", err, ErrMerchantTypeInvalid)
+	}
+}
+
+func TestSQLNull(t *testing.T) {
+	t.Parallel()
+
+	db := openFakeDB(t)
+	for _, v := range []any{(*Data)(nil), (*DecodedData)(nil)} {
+		if _, err := db.Exec("INSERT", v); err != nil {
+			t.Fatalf("Exec(%T nil) unexpected error: %v", v, err)
+		}
+	}
+
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatalf("Query() unexpected error: %v", err)
+	}
+	defer rows.Close()
+
+	var n int
+	for rows.Next() {
+		c, mt := USD, Merchant
+		data := Data{QR: "stale"}
+		decoded := DecodedData{MerchantName: "stale"}
+		for _, dest := range []any{&c, &mt, &data, &decoded} {
+			if err := rows.Scan(dest); err != nil {
+				t.Fatalf("Scan(NULL into %T) unexpected error: %v", dest, err)
+			}
+		}
+		if c != 0 || mt != "" || data.QR != "" || decoded != (DecodedData{}) {
+			t.Errorf("Scan(NULL) left %v, %q, %q, %+v; want zero values", c, mt, data.QR, decoded)
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows.Err() = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("scanned %d rows, want 2", n)
+	}
+}
@@ -0,0 +1,308 @@
+package khqr
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// StickerLayout selects how WriteStickerPDF arranges codes on paper.
+type StickerLayout int
+
+const (
+	// StickerGrid tiles stickers on A4 sheets, 2 columns by 3 rows unless
+	// changed with WithStickerGrid.
+	StickerGrid StickerLayout = iota
+	// TableTent prints one code per A4 sheet, twice, so that folded across
+	// the middle both faces read upright.
+	TableTent
+	// Poster prints one A5 poster per code, centered on an A4 sheet.
+	Poster
+)
+
+// Sticker is one static code to print, with the merchant details shown
+// around it.
+type Sticker struct {
+	Data    *Data
+	Name    string // defaults to the merchant name in Data
+	AltName string // defaults to the alternate-language name in Data when a font is set and can draw it
+	Caption string // optional line under the code, such as "Table 5"
+	Copies  int    // times to print; 0 means once
+}
+
+// StickerOption configures WriteStickerPDF.
+type StickerOption func(*stickerConfig)
+
+type stickerConfig struct {
+	font       []byte
+	cols, rows int
+	cropMarks  bool
+}
+
+// WithStickerFont embeds a TrueType font and uses it for all text. Without
+// it the standard Helvetica font is used, which only covers ASCII.
+//
+// Khmer text is shaped with the font's own OpenType tables: vowels written
+// before the consonant are moved in front of it, subscript consonants take
+// their subscript forms from GSUB and marks are placed with GPOS. Text that
+// needs a subscript form the font does not have returns
+// ErrTextShapingUnsupported instead of printing garbled.
+func WithStickerFont(ttf []byte) StickerOption {
+	return func(c *stickerConfig) { c.font = ttf }
+}
+
+// WithStickerGrid sets the number of columns and rows of the StickerGrid
+// layout. Values below 1 are ignored.
+func WithStickerGrid(cols, rows int) StickerOption {
+	return func(c *stickerConfig) {
+		if cols > 0 && rows > 0 {
+			c.cols, c.rows = cols, rows
+		}
+	}
+}
+
+// WithCropMarks enables or disables the trim and fold marks. They are
+// enabled by default.
+func WithCropMarks(enabled bool) StickerOption {
+	return func(c *stickerConfig) { c.cropMarks = enabled }
+}
+
+// Page and print geometry in points.
+const (
+	mm         = 72 / 25.4
+	a4Width    = 210 * mm
+	a4Height   = 297 * mm
+	a5Width    = 148 * mm
+	a5Height   = 210 * mm
+	pageMargin = 12 * mm
+	gridGutter = 8 * mm
+	markOffset = 2 * mm // gap between the trim edge and its mark
+	markLength = 3 * mm
+	markWidth  = 0.25
+	bleed      = 1 * mm // how far the header band runs past the trim edge
+
+	khqrRed   = 0xE1232E
+	textColor = 0x000000
+	mutedText = 0x666666
+	white     = 0xFFFFFF
+)
+
+// stickerContent is a validated sticker ready to draw.
+type stickerContent struct {
+	qr                      *qrCode
+	name, alt, caption, acc string
+}
+
+// WriteStickerPDF writes print-ready sheets of static KHQR codes as a PDF.
+// Every Data must be static; a code with an amount returns
+// ErrStaticKHQRRequired. Text the font cannot draw returns
+// ErrFontGlyphMissing or ErrTextShapingUnsupported, see WithStickerFont.
+func WriteStickerPDF(w io.Writer, layout StickerLayout, stickers []Sticker, opts ...StickerOption) error {
+	cfg := stickerConfig{cols: 2, rows: 3, cropMarks: true}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	var font pdfFont = helvetica{}
+	if cfg.font != nil {
+		ttf, err := parseTrueType(cfg.font)
+		if err != nil {
+			return err
+		}
+		font = newEmbeddedFont(ttf)
+	}
+
+	var contents []*stickerContent
+	for i := range stickers {
+		s, err := newStickerContent(&stickers[i], font, cfg.font != nil)
+		if err != nil {
+			return fmt.Errorf("sticker %d: %w", i, err)
+		}
+		for range max(stickers[i].Copies, 1) {
+			contents = append(contents, s)
+		}
+	}
+
+	var pages []*pdfCanvas
+	switch layout {
+	case StickerGrid:
+		pages = layoutGrid(contents, font, &cfg)
+	case TableTent:
+		pages = layoutTent(contents, font, &cfg)
+	case Poster:
+		pages = layoutPoster(contents, font, &cfg)
+	default:
+		return fmt.Errorf("khqr: unknown sticker layout %d", layout)
+	}
+	return writePages(w, pages, font)
+}
+
+func newStickerContent(s *Sticker, font pdfFont, altDefault bool) (*stickerContent, error) {
+	if s.Data == nil {
+		return nil, ErrInvalidQR
+	}
+	d, err := Decode(s.Data.QR)
+	if err != nil {
+		return nil, err
+	}
+	if d.PointOfInitiationMethod != staticQR || d.TransactionAmount != "" {
+		return nil, ErrStaticKHQRRequired
+	}
+	qr, err := encodeQR([]byte(s.Data.QR), qrLevelM)
+	if err != nil {
+		return nil, err
+	}
+	c := &stickerContent{qr: qr, name: s.Name, alt: s.AltName, caption: s.Caption, acc: d.BakongAccountID}
+	if c.name == "" {
+		c.name = d.MerchantName
+	}
+	if c.alt == "" && altDefault {
+		c.alt = d.AltMerchantName
+	}
+	for _, text := range []string{c.name, c.alt, c.caption, c.acc} {
+		if err := font.check(text); err != nil {
+			return nil, fmt.Errorf("%w: %q", err, text)
+		}
+	}
+	return c, nil
+}
+
+// layoutGrid tiles stickers row by row over as many A4 pages as needed.
+func layoutGrid(contents []*stickerContent, font pdfFont, cfg *stickerConfig) []*pdfCanvas {
+	cellW := (a4Width - 2*pageMargin - float64(cfg.cols-1)*gridGutter) / float64(cfg.cols)
+	cellH := (a4Height - 2*pageMargin - float64(cfg.rows-1)*gridGutter) / float64(cfg.rows)
+	perPage := cfg.cols * cfg.rows
+
+	var pages []*pdfCanvas
+	for i, s := range contents {
+		if i%perPage == 0 {
+			pages = append(pages, &pdfCanvas{font: font})
+		}
+		c := pages[len(pages)-1]
+		col, row := i%perPage%cfg.cols, i%perPage/cfg.cols
+		cell := pdfRect{
+			x: pageMargin + float64(col)*(cellW+gridGutter),
+			y: a4Height - pageMargin - float64(row+1)*cellH - float64(row)*gridGutter,
+			w: cellW,
+			h: cellH,
+		}
+		drawSticker(c, cell, s, cfg.cropMarks)
+		if cfg.cropMarks {
+			c.stroke(textColor, markWidth)
+			c.cropMarks(cell, markOffset, markLength)
+		}
+	}
+	return pages
+}
+
+// layoutTent prints each sticker on both halves of an A4 page, the top half
+// rotated 180 degrees, with fold marks in the margin.
+func layoutTent(contents []*stickerContent, font pdfFont, cfg *stickerConfig) []*pdfCanvas {
+	trim := pdfRect{pageMargin, pageMargin, a4Width - 2*pageMargin, a4Height - 2*pageMargin}
+	bottom := pdfRect{trim.x, trim.y, trim.w, trim.h / 2}
+	top := pdfRect{trim.x, trim.y + trim.h/2, trim.w, trim.h / 2}
+	inner := 10 * mm // keeps the faces clear of the fold and base
+
+	pages := make([]*pdfCanvas, 0, len(contents))
+	for _, s := range contents {
+		c := &pdfCanvas{font: font}
+		drawSticker(c, bottom.inset(inner, inner), s, false)
+		c.save(-1, 0, 0, -1, 2*top.x+top.w, 2*top.y+top.h)
+		drawSticker(c, top.inset(inner, inner), s, false)
+		c.restore()
+		if cfg.cropMarks {
+			c.stroke(textColor, markWidth)
+			c.cropMarks(trim, markOffset, markLength)
+			fold := trim.y + trim.h/2
+			c.dash(1 * mm)
+			c.line(trim.x-markOffset-markLength, fold, trim.x-markOffset, fold)
+			c.line(trim.x+trim.w+markOffset, fold, trim.x+trim.w+markOffset+markLength, fold)
+			c.dash(0)
+		}
+		pages = append(pages, c)
+	}
+	return pages
+}
+
+// layoutPoster prints each sticker as an A5 poster centered on A4.
+func layoutPoster(contents []*stickerContent, font pdfFont, cfg *stickerConfig) []*pdfCanvas {
+	trim := pdfRect{(a4Width - a5Width) / 2, (a4Height - a5Height) / 2, a5Width, a5Height}
+	pages := make([]*pdfCanvas, 0, len(contents))
+	for _, s := range contents {
+		c := &pdfCanvas{font: font}
+		drawSticker(c, trim, s, cfg.cropMarks)
+		if cfg.cropMarks {
+			c.stroke(textColor, markWidth)
+			c.cropMarks(trim, markOffset, markLength)
+		}
+		pages = append(pages, c)
+	}
+	return pages
+}
+
+// drawSticker draws one sticker filling box: a red KHQR header, the
+// merchant names, the code and a footer with the caption and account ID.
+// With bleed the header runs past the top and sides of box so that trimming
+// leaves no white edge.
+//
+//nolint:mnd // proportions of the sticker design
+func drawSticker(c *pdfCanvas, box pdfRect, s *stickerContent, withBleed bool) {
+	pad := box.w * 0.06
+	maxText := box.w - 2*pad
+	cx := box.x + box.w/2
+	top := box.y + box.h
+
+	band := box.h * 0.12
+	header := pdfRect{box.x, top - band, box.w, band}
+	if withBleed {
+		header = pdfRect{box.x - bleed, top - band, box.w + 2*bleed, band + bleed}
+	}
+	c.fill(khqrRed)
+	c.rect(header)
+	c.fill(white)
+	c.centeredText(cx, top-band*0.68, band*0.45, maxText, "KHQR")
+
+	c.fill(textColor)
+	y := top - band - pad
+	size := c.centeredText(cx, y-box.h*0.05, box.h*0.06, maxText, s.name)
+	y -= box.h*0.05 + size*0.4
+	if s.alt != "" {
+		size = c.centeredText(cx, y-box.h*0.045, box.h*0.05, maxText, s.alt)
+		y -= box.h*0.045 + size*0.4
+	}
+
+	footer := box.y + pad*0.8
+	c.fill(mutedText)
+	size = c.centeredText(cx, footer, box.h*0.032, maxText, s.acc)
+	footer += size * 1.6
+	if s.caption != "" {
+		c.fill(textColor)
+		size = c.centeredText(cx, footer, box.h*0.045, maxText, s.caption)
+		footer += size * 1.3
+	}
+
+	// The code's own quiet zone separates it from the text.
+	side := min(box.w-pad, y-footer)
+	c.fill(textColor)
+	c.qr(s.qr, cx-side/2, footer+(y-footer-side)/2, side)
+}
+
+// writePages assembles the page content streams into a PDF document.
+func writePages(w io.Writer, pages []*pdfCanvas, font pdfFont) error {
+	var d pdfDoc
+	catalog := d.reserve()
+	tree := d.reserve()
+	fontRef := d.reserve()
+
+	kids := make([]string, 0, len(pages))
+	for _, p := range pages {
+		content := d.addStream("", p.b.Bytes())
+		page := d.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			tree, pdfNum(a4Width), pdfNum(a4Height), fontRef, content))
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+	font.embed(&d, fontRef)
+	d.set(tree, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	d.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", tree))
+	return d.writeTo(w, catalog)
+}
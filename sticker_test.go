@@ -0,0 +1,281 @@
+package khqr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testFont builds a minimal TrueType font mapping each rune in runes to
+// glyphs 1..n, all 500 units wide on a 1000 unit em.
+func testFont(runes ...rune) []byte {
+	return testFontTables(nil, runes...)
+}
+
+// testFontTables is testFont with extra tables, such as GSUB, added.
+func testFontTables(extra map[string][]byte, runes ...rune) []byte {
+	numGlyphs := len(runes) + 1
+
+	var head, hhea, maxp, hmtx, cmap bytes.Buffer
+	head.Write(make([]byte, 18))
+	u16(&head, 1000)
+	head.Write(make([]byte, 16))
+	u16(&head, 0, 0xFFFF-199, 1000, 800) // bbox 0 -200 1000 800
+	head.Write(make([]byte, 10))
+	u16(&hhea, 1, 0, 800, 0xFFFF-199)
+	hhea.Write(make([]byte, 26))
+	u16(&hhea, numGlyphs)
+	u16(&maxp, 0, 0x5000, numGlyphs)
+	for range numGlyphs {
+		u16(&hmtx, 500, 0)
+	}
+
+	// Format 4: one segment per rune plus the final 0xFFFF segment.
+	segs := len(runes) + 1
+	u16(&cmap, 0, 1, 3, 1, 0, 12)
+	u16(&cmap, 4, 16+segs*8, 0, segs*2, 0, 0, 0)
+	for _, r := range runes {
+		u16(&cmap, int(r))
+	}
+	u16(&cmap, 0xFFFF, 0)
+	for _, r := range runes {
+		u16(&cmap, int(r))
+	}
+	u16(&cmap, 0xFFFF)
+	for i, r := range runes {
+		u16(&cmap, (i+1-int(r))&0xFFFF)
+	}
+	u16(&cmap, 1)
+	for range segs {
+		u16(&cmap, 0)
+	}
+
+	tables := []struct {
+		tag  string
+		data []byte
+	}{
+		{"cmap", cmap.Bytes()}, {"glyf", make([]byte, 4)}, {"head", head.Bytes()},
+		{"hhea", hhea.Bytes()}, {"hmtx", hmtx.Bytes()}, {"maxp", maxp.Bytes()},
+	}
+	for _, tag := range slices.Sorted(maps.Keys(extra)) {
+		tables = append(tables, struct {
+			tag  string
+			data []byte
+		}{tag, extra[tag]})
+	}
+	var out bytes.Buffer
+	u16(&out, 1, 0, len(tables), 0, 0, 0)
+	off := 12 + len(tables)*16
+	for _, t := range tables {
+		out.WriteString(t.tag)
+		u16(&out, 0, 0, off>>16, off&0xFFFF, 0, len(t.data))
+		off += len(t.data)
+	}
+	for _, t := range tables {
+		out.Write(t.data)
+	}
+	return out.Bytes()
+}
+
+// u16 appends vs as big-endian 16-bit values.
+func u16(b *bytes.Buffer, vs ...int) {
+	for _, v := range vs {
+		_ = binary.Write(b, binary.BigEndian, uint16(v)) //nolint:gosec // test values fit
+	}
+}
+
+func TestParseTrueType(t *testing.T) {
+	t.Parallel()
+
+	f, err := parseTrueType(testFont('A', 'B', 'ក'))
+	if err != nil {
+		t.Fatalf("parseTrueType() unexpected error: %v", err)
+	}
+	if f.unitsPerEm != 1000 || f.ascent != 800 || f.descent != -200 || f.bbox != [4]int{0, -200, 1000, 800} {
+		t.Errorf("metrics = %+v", f)
+	}
+	for r, want := range map[rune]uint16{'A': 1, 'B': 2, 'ក': 3} {
+		if g, ok := f.glyph(r); !ok || g != want {
+			t.Errorf("glyph(%q) = %d, %v; want %d", r, g, ok, want)
+		}
+	}
+	if _, ok := f.glyph('Z'); ok {
+		t.Error("glyph('Z') found, want missing")
+	}
+	if got := f.advance(1); got != 500 {
+		t.Errorf("advance(1) = %v, want 500", got)
+	}
+
+	for _, bad := range [][]byte{nil, []byte("OTTO0000000000000000"), testFont('A')[:40]} {
+		if _, err := parseTrueType(bad); !errors.Is(err, ErrFontInvalid) {
+			t.Errorf("parseTrueType(%q...) = %v, want ErrFontInvalid", bad[:min(len(bad), 4)], err)
+		}
+	}
+}
+
+func staticSticker(t *testing.T) *Data {
+	t.Helper()
+	data, err := GenerateMerchant(profileMerchant)
+	if err != nil {
+		t.Fatalf("GenerateMerchant() unexpected error: %v", err)
+	}
+	return data
+}
+
+// pdfPages checks the cross-reference table of a PDF and returns its page
+// count and inflated content streams.
+func pdfPages(t *testing.T, pdf []byte) (int, string) {
+	t.Helper()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	lines := strings.Split(string(pdf[xref:]), "\n")
+	n, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	for i := 1; i < n; i++ {
+		off, _ := strconv.Atoi(lines[2+i][:10])
+		if want := strconv.Itoa(i) + " 0 obj\n"; !bytes.HasPrefix(pdf[off:], []byte(want)) {
+			t.Fatalf("xref entry %d points at %q", i, pdf[off:off+10])
+		}
+	}
+
+	var content strings.Builder
+	for _, s := range regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindAllSubmatch(pdf, -1) {
+		zr, err := zlib.NewReader(bytes.NewReader(s[1]))
+		if err != nil {
+			t.Fatalf("inflate: %v", err)
+		}
+		b, _ := io.ReadAll(zr)
+		content.Write(b)
+	}
+	return bytes.Count(pdf, []byte("/Type /Page ")), content.String()
+}
+
+func TestWriteStickerPDF(t *testing.T) {
+	t.Parallel()
+
+	data := staticSticker(t)
+	tests := []struct {
+		name      string
+		layout    StickerLayout
+		stickers  []Sticker
+		opts      []StickerOption
+		wantPages int
+	}{
+		{"grid", StickerGrid, []Sticker{{Data: data, Copies: 7}}, nil, 2},
+		{"grid_custom", StickerGrid, []Sticker{{Data: data, Copies: 12}}, []StickerOption{WithStickerGrid(3, 4)}, 1},
+		{"tent", TableTent, []Sticker{{Data: data, Caption: "Table 5"}, {Data: data}}, nil, 2},
+		{"poster", Poster, []Sticker{{Data: data, Copies: 3}}, []StickerOption{WithCropMarks(false)}, 3},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := WriteStickerPDF(&buf, tt.layout, tt.stickers, tt.opts...); err != nil {
+			t.Fatalf("%s: WriteStickerPDF() unexpected error: %v", tt.name, err)
+		}
+		pages, content := pdfPages(t, buf.Bytes())
+		if pages != tt.wantPages {
+			t.Errorf("%s: pages = %d, want %d", tt.name, pages, tt.wantPages)
+		}
+		if !strings.Contains(content, "(Jonh Smith) Tj") || !strings.Contains(content, "(jonhsmith@devb) Tj") {
+			t.Errorf("%s: merchant text missing from content", tt.name)
+		}
+		if marks := strings.Contains(content, " l S"); marks != (tt.opts == nil || tt.name == "grid_custom") {
+			t.Errorf("%s: crop marks drawn = %v", tt.name, marks)
+		}
+	}
+}
+
+func TestWriteStickerPDFFont(t *testing.T) {
+	t.Parallel()
+
+	data := staticSticker(t)
+	var runes []rune
+	for _, r := range "Jonh Smithjonsm@devbKHQRចនស្មី\u17C1" {
+		runes = append(runes, r)
+	}
+	font := shapingTestFont(runes...)
+
+	var buf bytes.Buffer
+	if err := WriteStickerPDF(&buf, Poster, []Sticker{{Data: data}}, WithStickerFont(font)); err != nil {
+		t.Fatalf("WriteStickerPDF() unexpected error: %v", err)
+	}
+	_, content := pdfPages(t, buf.Bytes())
+	for _, want := range []string{"/FontFile2", "/Identity-H", "/CIDFontType2"} {
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("PDF missing %s", want)
+		}
+	}
+	// The default Khmer name is shaped, and its subscript form maps back to
+	// the coeng sequence for copy and search.
+	if !strings.Contains(content, "<179F>") || !strings.Contains(content, "<17D21798>") || !strings.Contains(content, " Ts ") {
+		t.Error("default AltName not drawn with its subscript form and raised vowel")
+	}
+
+	buf.Reset()
+	if err := WriteStickerPDF(&buf, Poster, []Sticker{{Data: data, AltName: "ចន"}}, WithStickerFont(font)); err != nil {
+		t.Fatalf("WriteStickerPDF(AltName %q) unexpected error: %v", "ចន", err)
+	}
+	if _, content = pdfPages(t, buf.Bytes()); !strings.Contains(content, "<1785>") || !strings.Contains(content, "beginbfchar") {
+		t.Error("ToUnicode CMap missing Khmer glyph")
+	}
+	for _, alt := range []string{"ស្មី", "ចន ស្មីន", "ស\u17C1"} {
+		if err := WriteStickerPDF(io.Discard, Poster, []Sticker{{Data: data, AltName: alt}}, WithStickerFont(font)); err != nil {
+			t.Errorf("AltName %+q: unexpected error: %v", alt, err)
+		}
+	}
+	// Without subscript forms the name would print garbled.
+	plain := testFont(runes...)
+	if err := WriteStickerPDF(io.Discard, Poster, []Sticker{{Data: data}}, WithStickerFont(plain)); !errors.Is(err, ErrTextShapingUnsupported) {
+		t.Errorf("font without GSUB: err = %v, want ErrTextShapingUnsupported", err)
+	}
+
+	err := WriteStickerPDF(io.Discard, Poster, []Sticker{{Data: data, Caption: "Table 5"}}, WithStickerFont(font))
+	if !errors.Is(err, ErrFontGlyphMissing) {
+		t.Errorf("caption outside font: err = %v, want ErrFontGlyphMissing", err)
+	}
+	if err := WriteStickerPDF(io.Discard, Poster, []Sticker{{Data: data}}, WithStickerFont([]byte("nope"))); !errors.Is(err, ErrFontInvalid) {
+		t.Errorf("bad font: err = %v, want ErrFontInvalid", err)
+	}
+}
+
+func TestWriteStickerPDFErrors(t *testing.T) {
+	t.Parallel()
+
+	static := staticSticker(t)
+	info := profileMerchant
+	info.Amount = 5
+	info.ExpirationTimestamp = 4102444800000 // 2100-01-01
+	dynamic, err := GenerateMerchant(info)
+	if err != nil {
+		t.Fatalf("GenerateMerchant() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		sticker Sticker
+		want    error
+	}{
+		{"dynamic", Sticker{Data: dynamic}, ErrStaticKHQRRequired},
+		{"nil_data", Sticker{}, ErrInvalidQR},
+		{"invalid_qr", Sticker{Data: &Data{QR: "nope"}}, ErrInvalidQR},
+		{"khmer_without_font", Sticker{Data: static, AltName: "ចន ស្មីន"}, ErrFontGlyphMissing},
+	}
+	for _, tt := range tests {
+		err := WriteStickerPDF(io.Discard, StickerGrid, []Sticker{tt.sticker})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
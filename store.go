@@ -0,0 +1,196 @@
+package khqr
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RecordStatus is the lifecycle status of an issued code.
+type RecordStatus string
+
+const (
+	StatusIssued    RecordStatus = "issued"
+	StatusPaid      RecordStatus = "paid"
+	StatusExpired   RecordStatus = "expired"
+	StatusCancelled RecordStatus = "cancelled"
+)
+
+// Record is the audit entry for one issued KHQR code.
+type Record struct {
+	MD5        string          `json:"md5"`
+	QR         string          `json:"qr"`
+	Issuer     string          `json:"issuer,omitempty"` // who generated the code, see ContextWithIssuer
+	Individual *IndividualInfo `json:"individual,omitempty"`
+	Merchant   *MerchantInfo   `json:"merchant,omitempty"`
+	Status     RecordStatus    `json:"status"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ExpiresAt  time.Time       `json:"expires_at,omitzero"` // zero for static codes
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
+// BillNumber returns the bill number from the recorded inputs.
+func (r *Record) BillNumber() string {
+	switch {
+	case r.Individual != nil:
+		return r.Individual.BillNumber
+	case r.Merchant != nil:
+		return r.Merchant.BillNumber
+	}
+	return ""
+}
+
+// Amount returns the amount and currency from the recorded inputs.
+func (r *Record) Amount() (float64, Currency) {
+	switch {
+	case r.Individual != nil:
+		return r.Individual.Amount, r.Individual.Currency
+	case r.Merchant != nil:
+		return r.Merchant.Amount, r.Merchant.Currency
+	}
+	return 0, 0
+}
+
+// StatusAt returns the record's status at t. An issued dynamic code whose
+// expiration has passed reports StatusExpired.
+func (r *Record) StatusAt(t time.Time) RecordStatus {
+	if r.Status == StatusIssued && !r.ExpiresAt.IsZero() && !t.Before(r.ExpiresAt) {
+		return StatusExpired
+	}
+	return r.Status
+}
+
+// Query selects records. Zero-valued fields match everything.
+type Query struct {
+	MD5        string
+	BillNumber string
+	From       time.Time    // CreatedAt >= From
+	To         time.Time    // CreatedAt < To
+	Status     RecordStatus // compared with Record.StatusAt(time.Now())
+	Limit      int          // maximum results; 0 means no limit
+}
+
+func (q *Query) matches(r *Record, now time.Time) bool {
+	return (q.MD5 == "" || r.MD5 == q.MD5) &&
+		(q.BillNumber == "" || r.BillNumber() == q.BillNumber) &&
+		(q.From.IsZero() || !r.CreatedAt.Before(q.From)) &&
+		(q.To.IsZero() || r.CreatedAt.Before(q.To)) &&
+		(q.Status == "" || r.StatusAt(now) == q.Status)
+}
+
+// Store persists issued codes for audit and reconciliation.
+type Store interface {
+	// Save records a newly issued code.
+	Save(ctx context.Context, r *Record) error
+	// UpdateStatus changes the status of the record with the given MD5.
+	UpdateStatus(ctx context.Context, md5 string, status RecordStatus) error
+	// Get returns the record with the given MD5, or ErrRecordNotFound.
+	Get(ctx context.Context, md5 string) (*Record, error)
+	// Find returns records matching q, oldest first.
+	Find(ctx context.Context, q Query) ([]Record, error)
+}
+
+// clone returns a copy of r that shares no pointers with it, so stored
+// records cannot be changed through a value handed to a caller.
+func (r *Record) clone() *Record {
+	cp := *r
+	if r.Individual != nil {
+		info := *r.Individual
+		cp.Individual = &info
+	}
+	if r.Merchant != nil {
+		info := *r.Merchant
+		cp.Merchant = &info
+	}
+	return &cp
+}
+
+// recordIndex is the in-memory index shared by MemoryStore and FileStore.
+// Callers must hold mu.
+type recordIndex struct {
+	mu      sync.RWMutex
+	records map[string]*Record
+	order   []string // MD5s in insertion order
+}
+
+func (x *recordIndex) put(r *Record) {
+	if x.records == nil {
+		x.records = map[string]*Record{}
+	}
+	if _, ok := x.records[r.MD5]; !ok {
+		x.order = append(x.order, r.MD5)
+	}
+	x.records[r.MD5] = r.clone()
+}
+
+func (x *recordIndex) get(md5 string) (*Record, error) {
+	r, ok := x.records[md5]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+	return r.clone(), nil
+}
+
+func (x *recordIndex) find(q *Query) []Record {
+	now := time.Now()
+	var out []Record
+	for _, md5 := range x.order {
+		r := x.records[md5]
+		if !q.matches(r, now) {
+			continue
+		}
+		out = append(out, *r.clone())
+	}
+	slices.SortStableFunc(out, func(a, b Record) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[:q.Limit]
+	}
+	return out
+}
+
+// MemoryStore is a Store kept in memory. It is safe for concurrent use.
+type MemoryStore struct {
+	index recordIndex
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Save implements Store.
+func (m *MemoryStore) Save(_ context.Context, r *Record) error {
+	m.index.mu.Lock()
+	defer m.index.mu.Unlock()
+	m.index.put(r)
+	return nil
+}
+
+// UpdateStatus implements Store.
+func (m *MemoryStore) UpdateStatus(_ context.Context, md5 string, status RecordStatus) error {
+	m.index.mu.Lock()
+	defer m.index.mu.Unlock()
+	r, err := m.index.get(md5)
+	if err != nil {
+		return err
+	}
+	r.Status = status
+	r.UpdatedAt = time.Now()
+	m.index.put(r)
+	return nil
+}
+
+// Get implements Store.
+func (m *MemoryStore) Get(_ context.Context, md5 string) (*Record, error) {
+	m.index.mu.RLock()
+	defer m.index.mu.RUnlock()
+	return m.index.get(md5)
+}
+
+// Find implements Store.
+func (m *MemoryStore) Find(_ context.Context, q Query) ([]Record, error) { //nolint:gocritic // Query is passed by value so callers can use literals
+	m.index.mu.RLock()
+	defer m.index.mu.RUnlock()
+	return m.index.find(&q), nil
+}
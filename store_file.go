@@ -0,0 +1,122 @@
+package khqr
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// FileStore is a Store backed by an append-only JSON Lines file. Every
+// save or status change appends the full record, so the file doubles as an
+// audit log; on open the latest line for each MD5 wins. All records are
+// also indexed in memory.
+type FileStore struct {
+	index recordIndex
+	file  *os.File
+}
+
+// OpenFileStore opens or creates the JSON Lines file at path and loads its
+// records.
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{}
+	if err := s.load(path); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //nolint:gosec,mnd // path is chosen by the caller; owner read/write only
+	if err != nil {
+		return nil, err
+	}
+	s.file = f
+	return s, nil
+}
+
+func (s *FileStore) load(path string) error {
+	f, err := os.Open(path) //nolint:gosec // path is chosen by the caller
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20) //nolint:mnd // records are well under 1 MiB
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			return fmt.Errorf("khqr: %s line %d: %w", path, line, err)
+		}
+		s.index.put(&r)
+	}
+	return sc.Err()
+}
+
+// append writes r as one JSON line and syncs the file.
+func (s *FileStore) append(r *Record) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Save implements Store.
+func (s *FileStore) Save(_ context.Context, r *Record) error {
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	if err := s.append(r); err != nil {
+		return err
+	}
+	s.index.put(r)
+	return nil
+}
+
+// UpdateStatus implements Store.
+func (s *FileStore) UpdateStatus(_ context.Context, md5 string, status RecordStatus) error {
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	r, err := s.index.get(md5)
+	if err != nil {
+		return err
+	}
+	r.Status = status
+	r.UpdatedAt = time.Now()
+	if err := s.append(r); err != nil {
+		return err
+	}
+	s.index.put(r)
+	return nil
+}
+
+// Get implements Store.
+func (s *FileStore) Get(_ context.Context, md5 string) (*Record, error) {
+	s.index.mu.RLock()
+	defer s.index.mu.RUnlock()
+	return s.index.get(md5)
+}
+
+// Find implements Store.
+func (s *FileStore) Find(_ context.Context, q Query) ([]Record, error) { //nolint:gocritic // Query is passed by value so callers can use literals
+	s.index.mu.RLock()
+	defer s.index.mu.RUnlock()
+	return s.index.find(&q), nil
+}
+
+// Close closes the underlying file.
+func (s *FileStore) Close() error {
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,199 @@
+package khqr
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// storeFactories builds each Store implementation for shared behavior tests.
+func storeFactories(t *testing.T) map[string]func() Store {
+	t.Helper()
+	return map[string]func() Store{
+		"memory": func() Store { return NewMemoryStore() },
+		"file": func() Store {
+			s, err := OpenFileStore(filepath.Join(t.TempDir(), "issued.jsonl"))
+			if err != nil {
+				t.Fatalf("OpenFileStore() unexpected error: %v", err)
+			}
+			t.Cleanup(func() { _ = s.Close() })
+			return s
+		},
+	}
+}
+
+func testRecord(md5, bill string, created time.Time, expires time.Time) *Record {
+	return &Record{
+		MD5:       md5,
+		QR:        "qr-" + md5,
+		Merchant:  &MerchantInfo{BillNumber: bill, Amount: 10, Currency: USD},
+		Status:    StatusIssued,
+		CreatedAt: created,
+		ExpiresAt: expires,
+		UpdatedAt: created,
+	}
+}
+
+func TestStoreQueries(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	for name, newStore := range storeFactories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newStore()
+			for _, r := range []*Record{
+				testRecord("b", "INV-2", base.Add(2*time.Hour), future),
+				testRecord("a", "INV-1", base, future),
+				testRecord("c", "INV-1", base.Add(4*time.Hour), past),
+				testRecord("d", "", base.Add(6*time.Hour), time.Time{}),
+			} {
+				if err := s.Save(ctx, r); err != nil {
+					t.Fatalf("Save(%s) unexpected error: %v", r.MD5, err)
+				}
+			}
+			if err := s.UpdateStatus(ctx, "b", StatusPaid); err != nil {
+				t.Fatalf("UpdateStatus() unexpected error: %v", err)
+			}
+
+			tests := []struct {
+				name string
+				q    Query
+				want []string
+			}{
+				{"all_oldest_first", Query{}, []string{"a", "b", "c", "d"}},
+				{"by_md5", Query{MD5: "c"}, []string{"c"}},
+				{"by_bill", Query{BillNumber: "INV-1"}, []string{"a", "c"}},
+				{"time_range", Query{From: base.Add(time.Hour), To: base.Add(6 * time.Hour)}, []string{"b", "c"}},
+				{"status_paid", Query{Status: StatusPaid}, []string{"b"}},
+				{"status_expired", Query{Status: StatusExpired}, []string{"c"}},
+				{"status_issued", Query{Status: StatusIssued}, []string{"a", "d"}},
+				{"limit", Query{Limit: 2}, []string{"a", "b"}},
+				{"no_match", Query{BillNumber: "INV-9"}, nil},
+			}
+			for _, tt := range tests {
+				got, err := s.Find(ctx, tt.q)
+				if err != nil {
+					t.Fatalf("%s: Find() unexpected error: %v", tt.name, err)
+				}
+				var ids []string
+				for _, r := range got {
+					ids = append(ids, r.MD5)
+				}
+				if !slices.Equal(ids, tt.want) {
+					t.Errorf("%s: Find() = %v, want %v", tt.name, ids, tt.want)
+				}
+			}
+
+			got, err := s.Get(ctx, "b")
+			if err != nil || got.Status != StatusPaid || got.BillNumber() != "INV-2" {
+				t.Errorf("Get(b) = %+v, %v, want paid INV-2", got, err)
+			}
+			if _, err := s.Get(ctx, "missing"); !errors.Is(err, ErrRecordNotFound) {
+				t.Errorf("Get(missing) error = %v, want %v", err, ErrRecordNotFound)
+			}
+			if err := s.UpdateStatus(ctx, "missing", StatusPaid); !errors.Is(err, ErrRecordNotFound) {
+				t.Errorf("UpdateStatus(missing) error = %v, want %v", err, ErrRecordNotFound)
+			}
+		})
+	}
+}
+
+func TestStoreReturnsCopies(t *testing.T) {
+	t.Parallel()
+
+	for name, newStore := range storeFactories(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			s := newStore()
+			r := testRecord("a", "INV-1", time.Now(), time.Time{})
+			if err := s.Save(ctx, r); err != nil {
+				t.Fatal(err)
+			}
+			r.Status = StatusPaid
+			r.Merchant.BillNumber = "INV-saved"
+			got, _ := s.Get(ctx, "a")
+			got.Status = StatusCancelled
+			got.Merchant.BillNumber = "INV-get"
+			found, _ := s.Find(ctx, Query{})
+			found[0].Merchant.Amount = 99
+			again, _ := s.Get(ctx, "a")
+			if again.Status != StatusIssued {
+				t.Errorf("stored status = %v, want %v", again.Status, StatusIssued)
+			}
+			if again.BillNumber() != "INV-1" || again.Merchant.Amount != 10 {
+				t.Errorf("stored merchant = %+v, want bill INV-1 amount 10", *again.Merchant)
+			}
+		})
+	}
+}
+
+func TestFileStoreReopen(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "issued.jsonl")
+
+	s, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("OpenFileStore() unexpected error: %v", err)
+	}
+	created := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	if err := s.Save(ctx, testRecord("a", "INV-1", created, time.Time{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(ctx, testRecord("b", "INV-2", created.Add(time.Minute), time.Time{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateStatus(ctx, "a", StatusCancelled); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("OpenFileStore() reopen unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = reopened.Close() })
+
+	got, err := reopened.Find(ctx, Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].MD5 != "a" || got[0].Status != StatusCancelled || got[1].MD5 != "b" {
+		t.Errorf("reopened records = %+v, want a (cancelled), b", got)
+	}
+	if got[0].Merchant == nil || got[0].Merchant.Currency != USD {
+		t.Errorf("reopened inputs = %+v, want merchant USD", got[0].Merchant)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := len(strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")); lines != 3 {
+		t.Errorf("file has %d lines, want 3 (append-only audit log)", lines)
+	}
+}
+
+func TestFileStoreCorrupt(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "issued.jsonl")
+	if err := os.WriteFile(path, []byte("{\"md5\":\"a\"}\nnot json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenFileStore(path); err == nil {
+		t.Error("OpenFileStore() with corrupt line expected error, got nil")
+	}
+}
@@ -0,0 +1,95 @@
+package khqr
+
+import (
+	"bufio"
+	"io"
+)
+
+// TerminalOption configures Data.WriteTerminal.
+type TerminalOption func(*terminalConfig)
+
+type terminalConfig struct {
+	ansi   bool
+	invert bool
+	quiet  int
+}
+
+// WithTerminalANSI draws each module as two spaces with an ANSI black or
+// white background instead of Unicode half blocks. The output is twice as
+// tall but does not depend on the terminal's colors or font.
+func WithTerminalANSI() TerminalOption {
+	return func(c *terminalConfig) { c.ansi = true }
+}
+
+// WithTerminalInvert swaps dark and light. Half blocks are drawn for the
+// light modules, which suits the usual light-on-dark terminal; invert for
+// terminals with dark text on a light background.
+func WithTerminalInvert() TerminalOption {
+	return func(c *terminalConfig) { c.invert = true }
+}
+
+// WithTerminalQuietZone sets the light border around the code in modules.
+// The standard asks for 4, the default; most phone scanners cope with 2,
+// which helps on small terminals.
+func WithTerminalQuietZone(modules int) TerminalOption {
+	return func(c *terminalConfig) { c.quiet = max(modules, 0) }
+}
+
+// Half-block characters indexed by top<<1 | bottom, where 1 draws the
+// foreground.
+var halfBlocks = [4]string{" ", "▄", "▀", "█"}
+
+const (
+	ansiDark  = "\x1b[40m  "
+	ansiLight = "\x1b[47m  "
+	ansiReset = "\x1b[0m"
+)
+
+// WriteTerminal draws the QR code to w as text, for terminals without image
+// support such as a POS over SSH. Each line of output covers two rows of
+// modules using Unicode half blocks, or one row with WithTerminalANSI.
+func (d *Data) WriteTerminal(w io.Writer, opts ...TerminalOption) error {
+	cfg := terminalConfig{quiet: 4}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	q, err := encodeQR([]byte(d.QR), qrLevelM)
+	if err != nil {
+		return err
+	}
+
+	// lit reports whether a module is drawn in the foreground (half blocks)
+	// or with the light background (ANSI).
+	lit := func(x, y int) bool { return q.dark(x, y) == cfg.invert }
+	lo, hi := -cfg.quiet, q.size+cfg.quiet
+
+	bw := bufio.NewWriter(w)
+	if cfg.ansi {
+		for y := lo; y < hi; y++ {
+			for x := lo; x < hi; x++ {
+				if lit(x, y) {
+					_, _ = bw.WriteString(ansiLight)
+				} else {
+					_, _ = bw.WriteString(ansiDark)
+				}
+			}
+			_, _ = bw.WriteString(ansiReset + "\n")
+		}
+		return bw.Flush()
+	}
+
+	for y := lo; y < hi; y += 2 {
+		for x := lo; x < hi; x++ {
+			i := 0
+			if lit(x, y) {
+				i |= 2
+			}
+			if y+1 < hi && lit(x, y+1) {
+				i |= 1
+			}
+			_, _ = bw.WriteString(halfBlocks[i])
+		}
+		_ = bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
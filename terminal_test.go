@@ -0,0 +1,76 @@
+package khqr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// terminalModules reads WriteTerminal output back into rows of modules,
+// true where the module is drawn lit.
+func terminalModules(t *testing.T, out string, ansi bool) [][]bool {
+	t.Helper()
+	var rows [][]bool
+	for line := range strings.Lines(out) {
+		line = strings.TrimSuffix(line, "\n")
+		if ansi {
+			line = strings.TrimSuffix(line, ansiReset)
+			var row []bool
+			for cell := range strings.SplitSeq(strings.TrimPrefix(line, "\x1b["), "\x1b[") {
+				row = append(row, cell == ansiLight[2:])
+			}
+			rows = append(rows, row)
+			continue
+		}
+		var top, bottom []bool
+		for _, r := range line {
+			top = append(top, r == '▀' || r == '█')
+			bottom = append(bottom, r == '▄' || r == '█')
+		}
+		rows = append(rows, top, bottom)
+	}
+	return rows
+}
+
+func TestWriteTerminal(t *testing.T) {
+	t.Parallel()
+
+	data := staticSticker(t)
+	q, err := encodeQR([]byte(data.QR), qrLevelM)
+	if err != nil {
+		t.Fatalf("encodeQR() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		opts   []TerminalOption
+		ansi   bool
+		invert bool
+		quiet  int
+	}{
+		{"half_blocks", nil, false, false, 4},
+		{"inverted", []TerminalOption{WithTerminalInvert()}, false, true, 4},
+		{"ansi", []TerminalOption{WithTerminalANSI()}, true, false, 4},
+		{"ansi_inverted", []TerminalOption{WithTerminalANSI(), WithTerminalInvert()}, true, true, 4},
+		{"quiet_zone", []TerminalOption{WithTerminalQuietZone(1)}, false, false, 1},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := data.WriteTerminal(&buf, tt.opts...); err != nil {
+			t.Fatalf("%s: WriteTerminal() unexpected error: %v", tt.name, err)
+		}
+		rows := terminalModules(t, buf.String(), tt.ansi)
+		width := q.size + 2*tt.quiet
+		if len(rows) < width || len(rows[0]) != width {
+			t.Fatalf("%s: got %d rows of %d modules, want %d square", tt.name, len(rows), len(rows[0]), width)
+		}
+		for y := range width {
+			for x := range width {
+				want := q.dark(x-tt.quiet, y-tt.quiet) == tt.invert
+				if rows[y][x] != want {
+					t.Fatalf("%s: module (%d, %d) lit = %v, want %v", tt.name, x, y, rows[y][x], want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package khqr
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	khmerFirstConsonant = '\u1780' // KHMER LETTER KA
+	khmerLastBase       = '\u17B3' // KHMER INDEPENDENT VOWEL QAU
+	khmerRo             = '\u179A'
+	khmerLetterQA       = '\u17A2'
+	khmerVowelAA        = '\u17B6' // first dependent vowel
+	khmerVowelAU        = '\u17C5' // last dependent vowel
+	khmerNikahit        = '\u17C6' // first sign
+	khmerMuusikatoan    = '\u17C9' // register shifters
+	khmerTriisap        = '\u17CA'
+	khmerRobat          = '\u17CC'
+	khmerCoeng          = '\u17D2' // subscripts the next consonant
+	khmerBathamasat     = '\u17D3' // last sign before the punctuation
+	khmerAtthacan       = '\u17DD'
+	zeroWidthJoiner     = '\u200D'
+)
+
+// khmerDeprecated replaces characters Unicode says not to use with their
+// recommended spelling.
+var khmerDeprecated = strings.NewReplacer(
+	"\u17A3", string(khmerLetterQA), // KHMER INDEPENDENT VOWEL QAQ
+	"\u17A4", string([]rune{khmerLetterQA, khmerVowelAA}), // KHMER INDEPENDENT VOWEL QAA
+)
+
+// NormalizeText returns s in a canonical form, so that names that look the
+// same are encoded the same and produce the same MD5. It removes invisible
+// characters (zero-width space and joiners, soft hyphen, Khmer inherent
+// vowels), replaces deprecated Khmer letters, puts the marks of each Khmer
+// syllable in the standard order and applies Unicode NFC.
+// GenerateIndividual and GenerateMerchant apply it to AltMerchantName and
+// AltMerchantCity before checking their length.
+func NormalizeText(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if isInvisibleRune(r) {
+			return -1
+		}
+		return r
+	}, s)
+	s = khmerDeprecated.Replace(s)
+	return norm.NFC.String(reorderKhmer(s))
+}
+
+// TruncateText shortens s to at most n runes, the unit KHQR lengths are
+// counted in, without splitting a character from its combining marks or a
+// Khmer subscript from its consonant. Normalize s first.
+func TruncateText(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	count := 0
+	end := 0
+	for _, c := range textClusters(s) {
+		count += utf8.RuneCountInString(c)
+		if count > n {
+			break
+		}
+		end += len(c)
+	}
+	return s[:end]
+}
+
+// textClusters splits s into user-perceived characters: a base rune with
+// the combining marks after it, keeping a Khmer coeng together with the
+// consonant it subscripts.
+func textClusters(s string) []string {
+	var clusters []string
+	start := 0
+	var prev rune
+	for i, r := range s {
+		if i > 0 && !joinsCluster(prev, r) {
+			clusters = append(clusters, s[start:i])
+			start = i
+		}
+		prev = r
+	}
+	if start < len(s) {
+		clusters = append(clusters, s[start:])
+	}
+	return clusters
+}
+
+func joinsCluster(prev, r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Mc, unicode.Me) || prev == khmerCoeng || r == zeroWidthJoiner || prev == zeroWidthJoiner
+}
+
+// Positions of Khmer marks within a syllable, after the base.
+const (
+	khmerRankSubscript = iota + 1
+	khmerRankSubscriptRo
+	khmerRankShifter
+	khmerRankRobat
+	khmerRankVowel
+	khmerRankSign
+)
+
+// khmerMarkRank returns the position of a Khmer mark within its syllable,
+// or 0 if r does not attach to the preceding base.
+func khmerMarkRank(r rune) int {
+	switch {
+	case r == khmerMuusikatoan || r == khmerTriisap:
+		return khmerRankShifter
+	case r == khmerRobat:
+		return khmerRankRobat
+	case r >= khmerVowelAA && r <= khmerVowelAU:
+		return khmerRankVowel
+	case r >= khmerNikahit && r <= khmerBathamasat && r != khmerCoeng, r == khmerAtthacan:
+		return khmerRankSign
+	}
+	return 0
+}
+
+func isKhmerBase(r rune) bool {
+	return r >= khmerFirstConsonant && r <= khmerLastBase
+}
+
+// reorderKhmer sorts the marks of each Khmer syllable into the order
+// subscripts (coeng ro last), register shifter, robat, vowel, signs, so
+// that the different orders in which people type a syllable compare equal.
+func reorderKhmer(s string) string {
+	if !strings.ContainsFunc(s, isKhmerBase) {
+		return s
+	}
+	type unit struct {
+		runes []rune
+		rank  int
+	}
+	in := []rune(s)
+	out := make([]rune, 0, len(in))
+	for i := 0; i < len(in); {
+		out = append(out, in[i])
+		if !isKhmerBase(in[i]) {
+			i++
+			continue
+		}
+		i++
+		var marks []unit
+		for i < len(in) {
+			if in[i] == khmerCoeng && i+1 < len(in) && isKhmerBase(in[i+1]) {
+				rank := khmerRankSubscript
+				if in[i+1] == khmerRo {
+					rank = khmerRankSubscriptRo
+				}
+				marks = append(marks, unit{in[i : i+2], rank})
+				i += 2
+				continue
+			}
+			rank := khmerMarkRank(in[i])
+			if rank == 0 {
+				break
+			}
+			marks = append(marks, unit{in[i : i+1], rank})
+			i++
+		}
+		slices.SortStableFunc(marks, func(a, b unit) int { return a.rank - b.rank })
+		for _, m := range marks {
+			out = append(out, m.runes...)
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,96 @@
+package khqr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name, in, want string
+	}{
+		{"empty", "", ""},
+		{"latin", "Jonh Smith", "Jonh Smith"},
+		{"nfc", "Cafe\u0301", "Caf\u00E9"},
+		{"zwsp", "ចន\u200Bស្មីន", "ចនស្មីន"},
+		{"zwnj_zwj_bom", "\uFEFFចន\u200C\u200D", "ចន"},
+		{"inherent_vowel", "ក\u17B4ខ", "កខ"},
+		{"deprecated_qaq", "\u17A3ង", "\u17A2ង"},
+		{"deprecated_qaa", "\u17A4ង", "\u17A2\u17B6ង"},
+		{"coeng_ro_last", "\u179F\u17D2\u179A\u17D2\u178F\u17B8", "\u179F\u17D2\u178F\u17D2\u179A\u17B8"},
+		{"vowel_before_coeng", "\u1780\u17B6\u17D2\u1780", "\u1780\u17D2\u1780\u17B6"},
+		{"shifter_before_vowel", "\u179F\u17B8\u17CA", "\u179F\u17CA\u17B8"},
+		{"sign_after_vowel", "\u1780\u17C6\u17BB", "\u1780\u17BB\u17C6"},
+		{"already_normal", "ភ្នំពេញ", "ភ្នំពេញ"},
+		{"punctuation_untouched", "ក។ ខ", "ក។ ខ"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeText(tt.in); got != tt.want {
+			t.Errorf("%s: NormalizeText(%+q) = %+q, want %+q", tt.name, tt.in, got, tt.want)
+		}
+		if got := NormalizeText(tt.want); got != tt.want {
+			t.Errorf("%s: NormalizeText not idempotent: %+q", tt.name, got)
+		}
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"Jonh Smith", 4, "Jonh"},
+		{"Jonh Smith", 25, "Jonh Smith"},
+		{"Cafe\u0301", 0, ""},
+		{"Cafe\u0301", 4, "Caf"},
+		{"ចន ស្មីន", 8, "ចន ស្មីន"},
+		{"ចន ស្មីន", 7, "ចន ស្មី"},
+		{"ចន ស្មីន", 6, "ចន "},
+		{"\u179F\u17D2\u178F\u17D2\u179A\u17B8", 5, ""},
+	}
+	for _, tt := range tests {
+		if got := TruncateText(tt.in, tt.n); got != tt.want {
+			t.Errorf("TruncateText(%+q, %d) = %+q, want %+q", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateNormalizesAltText(t *testing.T) {
+	t.Parallel()
+
+	info := IndividualInfo{
+		BakongAccountID:       "john_smith@devb",
+		MerchantName:          "John Smith",
+		AltLanguagePreference: "km",
+		AltMerchantName:       "\u179F\u17D2\u179A\u17D2\u178F\u17B8 ចន",
+		AltMerchantCity:       "ភ្នំ\u200Bពេញ",
+	}
+	typed, err := GenerateIndividual(info)
+	if err != nil {
+		t.Fatalf("GenerateIndividual() unexpected error: %v", err)
+	}
+	info.AltMerchantName, info.AltMerchantCity = "\u179F\u17D2\u178F\u17D2\u179A\u17B8 ចន", "ភ្នំពេញ"
+	canonical, err := GenerateIndividual(info)
+	if err != nil {
+		t.Fatalf("GenerateIndividual() unexpected error: %v", err)
+	}
+	if a, b := withoutCreation(t, typed.QR), withoutCreation(t, canonical.QR); a != b {
+		t.Errorf("differently typed names encode differently:\n%+v\n%+v", a, b)
+	}
+
+	// Invisible characters no longer count towards the limit.
+	info.AltMerchantName = strings.Repeat("ក\u200B", 25)
+	if _, err := GenerateIndividual(info); err != nil {
+		t.Errorf("GenerateIndividual(25 letters + 25 ZWSP) unexpected error: %v", err)
+	}
+	info.AltMerchantName = strings.Repeat("ក", 26)
+	if _, err := GenerateIndividual(info); !errors.Is(err, ErrMerchantNameAltTooLong) {
+		t.Errorf("GenerateIndividual(26 letters) err = %v, want ErrMerchantNameAltTooLong", err)
+	}
+}
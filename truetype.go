@@ -0,0 +1,266 @@
+package khqr
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+// trueTypeFont is the subset of a TrueType font needed to embed it in a PDF
+// and lay out text with it: the character map, horizontal metrics and the
+// OpenType layout tables used to shape Khmer, see shape.
+type trueTypeFont struct {
+	data       []byte
+	name       string // PostScript name
+	unitsPerEm int
+	bbox       [4]int
+	ascent     int
+	descent    int
+	capHeight  int
+	advances   []int // per glyph, font units
+	cmap       map[rune]uint16
+
+	gsub, gpos, gdef []byte
+	// lookups enabled for Khmer, in application order
+	gsubBasic, gsubPresentation, gposLookups []int
+}
+
+// parseTrueType parses a TrueType (glyf outline) font. OpenType CFF fonts
+// and font collections are not supported.
+//
+//nolint:mnd // table offsets from the TrueType specification
+func parseTrueType(data []byte) (*trueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, ErrFontInvalid
+	}
+	if v := binary.BigEndian.Uint32(data); v != 0x00010000 && v != 0x74727565 { // 1.0 or "true"
+		return nil, ErrFontInvalid
+	}
+	tables := map[string][]byte{}
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	for i := range n {
+		rec := 12 + i*16
+		if rec+16 > len(data) {
+			return nil, ErrFontInvalid
+		}
+		off := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if off < 0 || length < 0 || off+length > len(data) {
+			return nil, ErrFontInvalid
+		}
+		tables[string(data[rec:rec+4])] = data[off : off+length]
+	}
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "cmap", "glyf"} {
+		if tables[tag] == nil {
+			return nil, ErrFontInvalid
+		}
+	}
+
+	head, hhea, maxp := tables["head"], tables["hhea"], tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, ErrFontInvalid
+	}
+	f := &trueTypeFont{
+		data:       data,
+		name:       fontPostScriptName(tables["name"]),
+		unitsPerEm: int(binary.BigEndian.Uint16(head[18:])),
+		ascent:     int(int16(binary.BigEndian.Uint16(hhea[4:]))), //nolint:gosec // signed field
+		descent:    int(int16(binary.BigEndian.Uint16(hhea[6:]))), //nolint:gosec // signed field
+	}
+	if f.unitsPerEm == 0 {
+		return nil, ErrFontInvalid
+	}
+	for i := range f.bbox {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+i*2:]))) //nolint:gosec // signed field
+	}
+	f.capHeight = f.ascent
+	if os2 := tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		f.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:]))) //nolint:gosec // signed field
+	}
+
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	numMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := tables["hmtx"]
+	if numMetrics == 0 || numMetrics > numGlyphs || len(hmtx) < numMetrics*4 {
+		return nil, ErrFontInvalid
+	}
+	f.advances = make([]int, numGlyphs)
+	for i := range f.advances {
+		f.advances[i] = int(binary.BigEndian.Uint16(hmtx[min(i, numMetrics-1)*4:]))
+	}
+
+	cmap, ok := parseCmap(tables["cmap"], numGlyphs)
+	if !ok {
+		return nil, ErrFontInvalid
+	}
+	f.cmap = cmap
+
+	f.gsub, f.gpos, f.gdef = tables["GSUB"], tables["GPOS"], tables["GDEF"]
+	f.gsubBasic = otLookups(f.gsub, "khmr", khmerBasicFeatures...)
+	f.gsubPresentation = otLookups(f.gsub, "khmr", khmerPresentationFeatures...)
+	f.gposLookups = otLookups(f.gpos, "khmr", khmerPositionFeatures...)
+	return f, nil
+}
+
+// glyph returns the glyph for r, or false if the font has none.
+func (f *trueTypeFont) glyph(r rune) (uint16, bool) {
+	g, ok := f.cmap[r]
+	return g, ok && g != 0
+}
+
+// advance returns the advance width of glyph g in thousandths of an em.
+func (f *trueTypeFont) advance(g uint16) float64 {
+	return float64(f.advances[g]) * 1000 / float64(f.unitsPerEm)
+}
+
+// scale converts font units to thousandths of an em.
+func (f *trueTypeFont) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
+
+// em converts font units to thousandths of an em without rounding.
+func (f *trueTypeFont) em(v int) float64 {
+	return float64(v) * 1000 / float64(f.unitsPerEm)
+}
+
+// parseCmap reads the best Unicode subtable: format 12 for the full range,
+// otherwise format 4 for the Basic Multilingual Plane.
+//
+//nolint:mnd // table layout from the TrueType specification
+func parseCmap(t []byte, numGlyphs int) (map[rune]uint16, bool) {
+	if len(t) < 4 {
+		return nil, false
+	}
+	var bmp, full []byte
+	n := int(binary.BigEndian.Uint16(t[2:]))
+	for i := range n {
+		rec := 4 + i*8
+		if rec+8 > len(t) {
+			return nil, false
+		}
+		platform := binary.BigEndian.Uint16(t[rec:])
+		encoding := binary.BigEndian.Uint16(t[rec+2:])
+		off := int(binary.BigEndian.Uint32(t[rec+4:]))
+		if off+4 > len(t) {
+			return nil, false
+		}
+		sub := t[off:]
+		switch format := binary.BigEndian.Uint16(sub); {
+		case format == 12 && (platform == 0 || (platform == 3 && encoding == 10)):
+			full = sub
+		case format == 4 && (platform == 0 || (platform == 3 && encoding == 1)):
+			bmp = sub
+		}
+	}
+	m := map[rune]uint16{}
+	switch {
+	case full != nil:
+		return m, parseCmap12(full, numGlyphs, m)
+	case bmp != nil:
+		return m, parseCmap4(bmp, numGlyphs, m)
+	}
+	return nil, false
+}
+
+//nolint:mnd // table layout from the TrueType specification
+func parseCmap4(t []byte, numGlyphs int, m map[rune]uint16) bool {
+	if len(t) < 14 {
+		return false
+	}
+	segs := int(binary.BigEndian.Uint16(t[6:])) / 2
+	ends, starts, deltas, ranges := 14, 16+segs*2, 16+segs*4, 16+segs*6
+	if len(t) < ranges+segs*2 {
+		return false
+	}
+	for i := range segs {
+		end := int(binary.BigEndian.Uint16(t[ends+i*2:]))
+		start := int(binary.BigEndian.Uint16(t[starts+i*2:]))
+		delta := int(binary.BigEndian.Uint16(t[deltas+i*2:]))
+		ro := int(binary.BigEndian.Uint16(t[ranges+i*2:]))
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			g := (c + delta) & 0xFFFF
+			if ro != 0 {
+				at := ranges + i*2 + ro + (c-start)*2
+				if at+2 > len(t) {
+					return false
+				}
+				if g = int(binary.BigEndian.Uint16(t[at:])); g != 0 {
+					g = (g + delta) & 0xFFFF
+				}
+			}
+			if g != 0 && g < numGlyphs {
+				m[rune(c)] = uint16(g) //nolint:gosec // bounded by numGlyphs
+			}
+		}
+	}
+	return true
+}
+
+//nolint:mnd // table layout from the TrueType specification
+func parseCmap12(t []byte, numGlyphs int, m map[rune]uint16) bool {
+	if len(t) < 16 {
+		return false
+	}
+	groups := int(binary.BigEndian.Uint32(t[12:]))
+	if groups < 0 || len(t) < 16+groups*12 {
+		return false
+	}
+	for i := range groups {
+		g := t[16+i*12:]
+		start := int(binary.BigEndian.Uint32(g))
+		end := int(binary.BigEndian.Uint32(g[4:]))
+		gid := int(binary.BigEndian.Uint32(g[8:]))
+		if end > 0x10FFFF || end-start > numGlyphs {
+			return false
+		}
+		for c := start; c <= end; c++ {
+			if id := gid + c - start; id > 0 && id < numGlyphs {
+				m[rune(c)] = uint16(id) //nolint:gosec // bounded by numGlyphs
+			}
+		}
+	}
+	return true
+}
+
+// fontPostScriptName reads name ID 6 from the name table, keeping only the
+// characters a PDF name may contain unescaped.
+//
+//nolint:mnd // table layout from the TrueType specification
+func fontPostScriptName(t []byte) string {
+	const fallback = "KHQRFont"
+	if len(t) < 6 {
+		return fallback
+	}
+	n := int(binary.BigEndian.Uint16(t[2:]))
+	strs := int(binary.BigEndian.Uint16(t[4:]))
+	for i := range n {
+		rec := 6 + i*12
+		if rec+12 > len(t) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(t[rec:])
+		if binary.BigEndian.Uint16(t[rec+6:]) != 6 {
+			continue
+		}
+		length := int(binary.BigEndian.Uint16(t[rec+8:]))
+		off := strs + int(binary.BigEndian.Uint16(t[rec+10:]))
+		if off+length > len(t) {
+			continue
+		}
+		raw := t[off : off+length]
+		var b strings.Builder
+		step := 1
+		if platform == 0 || platform == 3 {
+			step = 2 // UTF-16BE; PostScript names are ASCII
+		}
+		for j := step - 1; j < len(raw); j += step {
+			c := raw[j]
+			if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' {
+				b.WriteByte(c)
+			}
+		}
+		if b.Len() > 0 {
+			return b.String()
+		}
+	}
+	return fallback
+}
@@ -3,6 +3,7 @@ package khqr
 import (
 	"math"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -11,6 +12,7 @@ import (
 
 var accountIDRegex = regexp.MustCompile(`^[^@]+@[^@]+$`)
 var merchantCategoryCodeRegex = regexp.MustCompile(`^\d{1,4}$`)
+var transactionCurrencyRegex = regexp.MustCompile(`^\d{3}$`)
 
 var (
 	khrCode = formatCurrency(KHR)
@@ -64,13 +66,25 @@ func validateAccountID(id string) error {
 	return nil
 }
 
+// validateCurrency restricts generation to the currencies Bakong accepts.
 func validateCurrency(c Currency) error {
-	if c != KHR && c != USD {
+	if !slices.Contains(khqrCurrencies, c) {
 		return ErrInvalidCurrency
 	}
 	return nil
 }
 
+// validateAmountPrecision checks that amount has no more decimal places
+// than the currency's minor units allow.
+func validateAmountPrecision(amount float64, currency Currency) error {
+	scale := math.Pow10(minorUnits(currency))
+	rounded := math.Round(amount*scale) / scale
+	if math.Abs(amount-rounded) > 1e-9 { //nolint:mnd // float epsilon
+		return ErrInvalidAmount
+	}
+	return nil
+}
+
 func validateAmount(amount float64, currency Currency) error {
 	if amount < 0 {
 		return ErrInvalidAmount
@@ -79,16 +93,8 @@ func validateAmount(amount float64, currency Currency) error {
 		return nil
 	}
 
-	switch currency {
-	case KHR:
-		if math.Abs(amount-math.Floor(amount)) > 1e-9 { //nolint:mnd // float epsilon
-			return ErrInvalidAmount
-		}
-	case USD:
-		rounded := math.Round(amount*100) / 100 //nolint:mnd // cents multiplier
-		if math.Abs(amount-rounded) > 1e-9 {    //nolint:mnd // float epsilon
-			return ErrInvalidAmount
-		}
+	if err := validateAmountPrecision(amount, currency); err != nil {
+		return err
 	}
 
 	amountStr := formatAmount(amount, currency)
@@ -250,12 +256,25 @@ func validateTransactionCurrency(tc string) error {
 	if len(tc) != 3 {
 		return ErrTransactionCurrencyTooLong
 	}
-	if tc != khrCode && tc != usdCode {
-		return ErrInvalidCurrency
+	if _, err := parseTransactionCurrency(tc); err != nil {
+		return err
 	}
 	return nil
 }
 
+// parseTransactionCurrency resolves tag 53, which holds the ISO 4217
+// numeric code only. Alphabetic codes such as "USD" are rejected.
+func parseTransactionCurrency(tc string) (Currency, error) {
+	if !transactionCurrencyRegex.MatchString(tc) {
+		return 0, ErrInvalidCurrency
+	}
+	n, _ := strconv.Atoi(tc)
+	if _, ok := LookupCurrency(Currency(n)); !ok {
+		return 0, ErrInvalidCurrency
+	}
+	return Currency(n), nil
+}
+
 func validateCountryCode(cc string) error {
 	if strings.TrimSpace(cc) == "" {
 		return ErrCountryCodeRequired
@@ -385,18 +404,11 @@ func (data *DecodedData) validateTransactionAmount() error {
 	if len(formatted) > maxAmountLength {
 		return ErrInvalidAmount
 	}
-	switch data.TransactionCurrency {
-	case khrCode:
-		if math.Abs(amount-math.Floor(amount)) > 1e-9 { //nolint:mnd // float epsilon
-			return ErrInvalidAmount
-		}
-	case usdCode:
-		rounded := math.Round(amount*100) / 100 //nolint:mnd // cents multiplier
-		if math.Abs(amount-rounded) > 1e-9 {    //nolint:mnd // float epsilon
-			return ErrInvalidAmount
-		}
+	currency, err := parseTransactionCurrency(data.TransactionCurrency)
+	if err != nil {
+		return err
 	}
-	return nil
+	return validateAmountPrecision(amount, currency)
 }
 
 func (data *DecodedData) validateDynamicFields() error {
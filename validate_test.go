@@ -45,6 +45,7 @@ func TestValidateCurrency(t *testing.T) {
 		{"USD", USD, nil},
 		{"invalid", Currency(999), ErrInvalidCurrency},
 		{"zero", Currency(0), ErrInvalidCurrency},
+		{"registered_not_khqr", THB, ErrInvalidCurrency},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -456,6 +457,7 @@ func TestValidateTransactionCurrency(t *testing.T) {
 	}{
 		{"KHR", formatCurrency(KHR), nil},
 		{"USD", formatCurrency(USD), nil},
+		{"THB", formatCurrency(THB), nil},
 		{"empty", "", ErrCurrencyRequired},
 		{"too_long", "1234", ErrTransactionCurrencyTooLong},
 		{"too_short", "12", ErrTransactionCurrencyTooLong},
@@ -0,0 +1,313 @@
+package khqr
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookSignatureHeader carries the signature of a payment callback, in
+// the form "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">".
+const WebhookSignatureHeader = "X-KHQR-Signature"
+
+// DefaultWebhookTolerance is how far a callback's signed timestamp may be
+// from the receiver's clock before it is rejected as a replay.
+const DefaultWebhookTolerance = 5 * time.Minute
+
+// maxWebhookBody bounds the size of a callback body.
+const maxWebhookBody = 1 << 20
+
+// minWebhookSecretBytes is the shortest secret callbacks may be signed
+// with; an empty or guessable secret would let anyone forge a payment.
+const minWebhookSecretBytes = 16
+
+// PaymentNotification is the body of a payment callback.
+type PaymentNotification struct {
+	ID            string    `json:"id"`             // unique per delivery attempt series
+	MD5           string    `json:"md5,omitempty"`  // MD5 of the paid QR string
+	Hash          string    `json:"hash,omitempty"` // Bakong transaction hash
+	BillNumber    string    `json:"bill_number,omitempty"`
+	FromAccountID string    `json:"from_account_id,omitempty"`
+	ToAccountID   string    `json:"to_account_id,omitempty"`
+	Amount        float64   `json:"amount"`
+	Currency      Currency  `json:"currency"`
+	PaidAt        time.Time `json:"paid_at"`
+
+	// Record is the issued code the payment was correlated to. It is set
+	// by WebhookHandler when a Store is configured and is never sent.
+	Record *Record `json:"-"`
+}
+
+// NewPaymentNotification builds a notification paying data in full, as
+// Bakong would report it. Static codes carry no amount; set Amount before
+// sending.
+func NewPaymentNotification(data *Data) (*PaymentNotification, error) {
+	d, err := Decode(data.QR)
+	if err != nil {
+		return nil, err
+	}
+	n := &PaymentNotification{
+		ID:            randomHex(16), //nolint:mnd // 128-bit delivery ID
+		MD5:           data.MD5(),
+		Hash:          randomHex(32), //nolint:mnd // 256-bit transaction hash
+		BillNumber:    d.BillNumber,
+		FromAccountID: "simulator@devb",
+		ToAccountID:   d.BakongAccountID,
+		PaidAt:        time.Now().UTC(),
+	}
+	if n.Currency, err = parseTransactionCurrency(d.TransactionCurrency); err != nil {
+		return nil, err
+	}
+	if d.TransactionAmount != "" {
+		if n.Amount, err = strconv.ParseFloat(d.TransactionAmount, 64); err != nil {
+			return nil, ErrInvalidAmount
+		}
+	}
+	return n, nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// SignWebhook returns the WebhookSignatureHeader value for body signed
+// with secret at time t. Receivers reject secrets shorter than 16 bytes.
+func SignWebhook(secret []byte, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + webhookMAC(secret, ts, body)
+}
+
+func webhookMAC(secret []byte, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks a WebhookSignatureHeader value against
+// body. The signed timestamp must be within tolerance of now. A secret
+// shorter than 16 bytes fails with ErrWebhookSecretInvalid.
+func VerifyWebhookSignature(secret []byte, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	if len(secret) < minWebhookSecretBytes {
+		return ErrWebhookSecretInvalid
+	}
+	var ts string
+	var sigs []string
+	for part := range strings.SplitSeq(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(sigs) == 0 {
+		return fmt.Errorf("%w: malformed header", ErrWebhookSignatureInvalid)
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > tolerance || d < -tolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrWebhookSignatureInvalid)
+	}
+	want := webhookMAC(secret, ts, body)
+	for _, sig := range sigs {
+		if hmac.Equal([]byte(sig), []byte(want)) {
+			return nil
+		}
+	}
+	return ErrWebhookSignatureInvalid
+}
+
+// PaymentHandler handles a verified payment callback. Returning an error
+// makes the receiver answer 500 so the sender retries.
+type PaymentHandler func(ctx context.Context, n *PaymentNotification) error
+
+// WebhookOption configures a WebhookHandler.
+type WebhookOption func(*WebhookHandler)
+
+// WithPaymentHandler adds fn to the handlers called for each payment, in
+// the order they were added.
+func WithPaymentHandler(fn PaymentHandler) WebhookOption {
+	return func(h *WebhookHandler) { h.handlers = append(h.handlers, fn) }
+}
+
+// WithWebhookStore correlates callbacks with codes recorded in s, by MD5
+// and then by bill number, and marks them paid once every handler has
+// succeeded. Callbacks for codes already marked paid are acknowledged
+// without calling the handlers again, so sender retries are harmless.
+func WithWebhookStore(s Store) WebhookOption {
+	return func(h *WebhookHandler) { h.store = s }
+}
+
+// WithWebhookTolerance sets the allowed clock skew for signed timestamps.
+// Defaults to DefaultWebhookTolerance.
+func WithWebhookTolerance(d time.Duration) WebhookOption {
+	return func(h *WebhookHandler) { h.tolerance = d }
+}
+
+// WebhookHandler is an http.Handler receiving payment callbacks.
+type WebhookHandler struct {
+	secret    []byte
+	store     Store
+	tolerance time.Duration
+	handlers  []PaymentHandler
+	now       func() time.Time
+}
+
+// NewWebhookHandler returns a handler verifying callbacks signed with
+// secret. A secret shorter than 16 bytes returns ErrWebhookSecretInvalid.
+func NewWebhookHandler(secret []byte, opts ...WebhookOption) (*WebhookHandler, error) {
+	if len(secret) < minWebhookSecretBytes {
+		return nil, ErrWebhookSecretInvalid
+	}
+	h := &WebhookHandler{secret: bytes.Clone(secret), tolerance: DefaultWebhookTolerance, now: time.Now}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h, nil
+}
+
+// ServeHTTP implements http.Handler. It answers 204 once the payment is
+// handled, 401 for a bad signature, 400 for a malformed body and 500 when
+// a handler or the store fails.
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		writeWebhookError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := VerifyWebhookSignature(h.secret, r.Header.Get(WebhookSignatureHeader), body, h.tolerance, h.now()); err != nil {
+		writeWebhookError(w, http.StatusUnauthorized, err)
+		return
+	}
+	var n PaymentNotification
+	if err := json.Unmarshal(body, &n); err != nil {
+		writeWebhookError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.handle(r.Context(), &n); err != nil {
+		writeWebhookError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) handle(ctx context.Context, n *PaymentNotification) error {
+	if h.store != nil {
+		rec, err := h.correlate(ctx, n)
+		if err != nil {
+			return err
+		}
+		if rec != nil && rec.Status == StatusPaid {
+			return nil
+		}
+		n.Record = rec
+		if rec != nil {
+			n.MD5 = rec.MD5
+		}
+	}
+	for _, fn := range h.handlers {
+		if err := fn(ctx, n); err != nil {
+			return err
+		}
+	}
+	if n.Record != nil {
+		return h.store.UpdateStatus(ctx, n.Record.MD5, StatusPaid)
+	}
+	return nil
+}
+
+// correlate finds the issued code for n, or returns nil if there is none.
+// A bill number shared by several codes resolves to the most recent one.
+func (h *WebhookHandler) correlate(ctx context.Context, n *PaymentNotification) (*Record, error) {
+	if n.MD5 != "" {
+		rec, err := h.store.Get(ctx, n.MD5)
+		if !errors.Is(err, ErrRecordNotFound) {
+			return rec, err
+		}
+	}
+	if n.BillNumber == "" {
+		return nil, nil //nolint:nilnil // no record is not an error
+	}
+	recs, err := h.store.Find(ctx, Query{BillNumber: n.BillNumber})
+	if err != nil || len(recs) == 0 {
+		return nil, err
+	}
+	return &recs[len(recs)-1], nil
+}
+
+func writeWebhookError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// WebhookSimulator sends signed payment callbacks to a receiver, standing
+// in for the real notification sender in integration tests.
+type WebhookSimulator struct {
+	URL    string
+	Secret []byte
+	Client *http.Client // defaults to http.DefaultClient
+}
+
+// Pay sends a callback paying data in full and returns the notification
+// sent. Use NewPaymentNotification and Send to pay static codes or to
+// simulate partial payments.
+func (s *WebhookSimulator) Pay(ctx context.Context, data *Data) (*PaymentNotification, error) {
+	n, err := NewPaymentNotification(data)
+	if err != nil {
+		return nil, err
+	}
+	return n, s.Send(ctx, n)
+}
+
+// Send signs and posts n. A non-2xx response is returned as an error.
+func (s *WebhookSimulator) Send(ctx context.Context, n *PaymentNotification) error {
+	if len(s.Secret) < minWebhookSecretBytes {
+		return ErrWebhookSecretInvalid
+	}
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(s.Secret, time.Now(), body))
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10)) //nolint:mnd // enough of the body for an error message
+		return fmt.Errorf("webhook: unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package khqr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var webhookSecret = []byte("whsec_test_0123456789")
+
+func newWebhookHandler(t *testing.T, opts ...WebhookOption) *WebhookHandler {
+	t.Helper()
+	h, err := NewWebhookHandler(webhookSecret, opts...)
+	if err != nil {
+		t.Fatalf("NewWebhookHandler() unexpected error: %v", err)
+	}
+	return h
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1_800_000_000, 0)
+	body := []byte(`{"id":"1"}`)
+	valid := SignWebhook(webhookSecret, now, body)
+
+	tests := []struct {
+		name    string
+		secret  []byte
+		header  string
+		body    []byte
+		wantErr error
+	}{
+		{"valid", webhookSecret, valid, body, nil},
+		{"rotated_secret_list", webhookSecret, valid + ",v1=deadbeef", body, nil},
+		{"wrong_secret", []byte("whsec_other_0123456789"), valid, body, ErrWebhookSignatureInvalid},
+		{"short_secret", []byte("whsec"), SignWebhook([]byte("whsec"), now, body), body, ErrWebhookSecretInvalid},
+		{"empty_secret", nil, SignWebhook(nil, now, body), body, ErrWebhookSecretInvalid},
+		{"tampered_body", webhookSecret, valid, []byte(`{"id":"2"}`), ErrWebhookSignatureInvalid},
+		{"stale", webhookSecret, SignWebhook(webhookSecret, now.Add(-10*time.Minute), body), body, ErrWebhookSignatureInvalid},
+		{"future", webhookSecret, SignWebhook(webhookSecret, now.Add(10*time.Minute), body), body, ErrWebhookSignatureInvalid},
+		{"missing", webhookSecret, "", body, ErrWebhookSignatureInvalid},
+		{"no_signature", webhookSecret, "t=1800000000", body, ErrWebhookSignatureInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := VerifyWebhookSignature(tt.secret, tt.header, tt.body, DefaultWebhookTolerance, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyWebhookSignature() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func webhookTestData(t *testing.T, g *Generator) *Data {
+	t.Helper()
+	data, err := g.GenerateIndividual(context.Background(), IndividualInfo{
+		BakongAccountID:     "jonhsmith@nbcq",
+		MerchantName:        "Jonh Smith",
+		Currency:            USD,
+		Amount:              4.5,
+		BillNumber:          "INV-100",
+		ExpirationTimestamp: time.Now().Add(5 * time.Minute).UnixMilli(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestWebhookSimulatorRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	data := webhookTestData(t, NewGenerator(WithStore(store)))
+
+	var mu sync.Mutex
+	var got []*PaymentNotification
+	h := newWebhookHandler(t,
+		WithWebhookStore(store),
+		WithPaymentHandler(func(_ context.Context, n *PaymentNotification) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, n)
+			return nil
+		}),
+	)
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	sim := &WebhookSimulator{URL: srv.URL, Secret: webhookSecret, Client: srv.Client()}
+	sent, err := sim.Pay(context.Background(), data)
+	if err != nil {
+		t.Fatalf("Pay() unexpected error: %v", err)
+	}
+	if sent.Amount != 4.5 || sent.Currency != USD || sent.BillNumber != "INV-100" || sent.ToAccountID != "jonhsmith@nbcq" {
+		t.Errorf("sent notification = %+v", sent)
+	}
+
+	// A retried delivery of an already-paid code is acknowledged only.
+	if err := sim.Send(context.Background(), sent); err != nil {
+		t.Fatalf("Send() retry unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 1 {
+		t.Fatalf("handler called %d times, want 1", len(got))
+	}
+	if got[0].Record == nil || got[0].Record.MD5 != data.MD5() || got[0].Hash != sent.Hash {
+		t.Errorf("handled notification = %+v, want correlated to %s", got[0], data.MD5())
+	}
+	if r, _ := store.Get(context.Background(), data.MD5()); r.Status != StatusPaid {
+		t.Errorf("record status = %v, want %v", r.Status, StatusPaid)
+	}
+}
+
+func TestWebhookCorrelatesByBillNumber(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	data := webhookTestData(t, NewGenerator(WithStore(store)))
+
+	var correlated *Record
+	h := newWebhookHandler(t, WithWebhookStore(store), WithPaymentHandler(func(_ context.Context, n *PaymentNotification) error {
+		correlated = n.Record
+		return nil
+	}))
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	n, err := NewPaymentNotification(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.MD5 = ""
+	sim := &WebhookSimulator{URL: srv.URL, Secret: webhookSecret}
+	if err := sim.Send(context.Background(), n); err != nil {
+		t.Fatalf("Send() unexpected error: %v", err)
+	}
+	if correlated == nil || correlated.MD5 != data.MD5() {
+		t.Errorf("correlated record = %+v, want %s", correlated, data.MD5())
+	}
+}
+
+func TestWebhookHandlerErrors(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	data := webhookTestData(t, NewGenerator(WithStore(store)))
+
+	fail := true
+	calls := 0
+	h := newWebhookHandler(t, WithWebhookStore(store), WithPaymentHandler(func(context.Context, *PaymentNotification) error {
+		calls++
+		if fail {
+			return errors.New("downstream unavailable")
+		}
+		return nil
+	}))
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	sim := &WebhookSimulator{URL: srv.URL, Secret: webhookSecret}
+	n, err := sim.Pay(context.Background(), data)
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Fatalf("Pay() with failing handler error = %v, want 500", err)
+	}
+	if r, _ := store.Get(context.Background(), data.MD5()); r.Status != StatusIssued {
+		t.Errorf("record status after failure = %v, want %v", r.Status, StatusIssued)
+	}
+
+	// The sender retries and the handler now succeeds.
+	fail = false
+	if err := sim.Send(context.Background(), n); err != nil {
+		t.Fatalf("Send() retry unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("handler calls = %d, want 2", calls)
+	}
+
+	bad := &WebhookSimulator{URL: srv.URL, Secret: []byte("whsec_wrong_0123456789")}
+	if err := bad.Send(context.Background(), n); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Send() with wrong secret error = %v, want 401", err)
+	}
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+
+	body := `not json`
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(body))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(webhookSecret, time.Now(), []byte(body)))
+	resp, err = srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("malformed body status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestWebhookWithoutStore(t *testing.T) {
+	t.Parallel()
+
+	var got *PaymentNotification
+	h := newWebhookHandler(t, WithPaymentHandler(func(_ context.Context, n *PaymentNotification) error {
+		got = n
+		return nil
+	}))
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	data, err := GenerateMerchant(MerchantInfo{
+		BakongAccountID: "jonhsmith@devb",
+		MerchantName:    "Jonh Smith",
+		MerchantCity:    "Phnom Penh",
+		MerchantID:      "123456",
+		AcquiringBank:   "Dev Bank",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := NewPaymentNotification(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Amount = 10000 // static code: the payer chooses the amount
+	sim := &WebhookSimulator{URL: srv.URL, Secret: webhookSecret}
+	if err := sim.Send(context.Background(), n); err != nil {
+		t.Fatalf("Send() unexpected error: %v", err)
+	}
+	if got == nil || got.Record != nil || got.Currency != KHR || got.Amount != 10000 || got.MD5 != data.MD5() {
+		t.Errorf("handled notification = %+v", got)
+	}
+}
+
+func TestWebhookSecretTooShort(t *testing.T) {
+	t.Parallel()
+
+	for _, secret := range [][]byte{nil, {}, []byte("0123456789abcde")} {
+		if _, err := NewWebhookHandler(secret); !errors.Is(err, ErrWebhookSecretInvalid) {
+			t.Errorf("NewWebhookHandler(%q) error = %v, want %v", secret, err, ErrWebhookSecretInvalid)
+		}
+	}
+	sim := &WebhookSimulator{URL: "http://127.0.0.1:0", Secret: []byte("short")}
+	if err := sim.Send(context.Background(), &PaymentNotification{ID: "1"}); !errors.Is(err, ErrWebhookSecretInvalid) {
+		t.Errorf("Send() with short secret error = %v, want %v", err, ErrWebhookSecretInvalid)
+	}
+}